@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"log"
 	"reflect"
 	"strings"
 
@@ -10,6 +9,7 @@ import (
 )
 
 type IfExpression struct {
+	Pos
 	VM         *runtime.VMRuntime
 	Check      runtime.Invokable
 	Statements Expressions
@@ -53,7 +53,7 @@ func (IfExpression) GetType() lexer.Type {
 func (exp IfExpression) Invoke() runtime.Invokable {
 	check := exp.Check.Invoke()
 	if _, ok := check.(Bool); !ok {
-		log.Panic("if Statements Check require boolObject", reflect.TypeOf(check).String())
+		Panicln("if Statements Check require boolObject", reflect.TypeOf(check).String())
 	}
 	if check.(Bool) {
 		exp.VM.PushStackFrame(false) //make  if brock stack
@@ -64,7 +64,7 @@ func (exp IfExpression) Invoke() runtime.Invokable {
 		for _, stm := range exp.ElseIf {
 			elseIf := stm.Check.Invoke()
 			if _, ok := elseIf.(Bool); !ok {
-				log.Panicln("else if require bool result")
+				Panicln("else if require bool result")
 			}
 			if elseIf.(Bool) {
 				exp.VM.PushStackFrame(false) //make  if brock stack
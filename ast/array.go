@@ -31,6 +31,10 @@ func (a *Array) AllocObject(label string) *runtime.Object {
 	if obj := a.GetObject(label); obj != nil {
 		return obj
 	}
+	return a.allocField(label)
+}
+
+func (a *Array) allocField(label string) *runtime.Object {
 	if a.Object == nil {
 		a.Object = map[string]*runtime.Object{}
 	}
@@ -46,4 +50,4 @@ func (a *Array) Clone() BaseObject {
 	var data = make([]runtime.Invokable, len(a.Data))
 	copy(data, a.Data)
 	return &Array{Data: data}
-}
\ No newline at end of file
+}
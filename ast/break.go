@@ -8,9 +8,13 @@ import (
 var BreakObj = &BreakObject{}
 
 type BreakObject struct {
+	Label string // label of loop to break,empty is the innermost loop
 }
 
 func (b *BreakObject) String() string {
+	if b.Label != "" {
+		return "break " + b.Label
+	}
 	return "break"
 }
 
@@ -21,3 +25,24 @@ func (b *BreakObject) Invoke() runtime.Invokable {
 func (b *BreakObject) GetType() lexer.Type {
 	return lexer.BreakType
 }
+
+var ContinueObj = &ContinueObject{}
+
+type ContinueObject struct {
+	Label string // label of loop to continue,empty is the innermost loop
+}
+
+func (c *ContinueObject) String() string {
+	if c.Label != "" {
+		return "continue " + c.Label
+	}
+	return "continue"
+}
+
+func (c *ContinueObject) Invoke() runtime.Invokable {
+	return c
+}
+
+func (c *ContinueObject) GetType() lexer.Type {
+	return lexer.ContinueType
+}
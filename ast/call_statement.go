@@ -1,17 +1,20 @@
 package ast
 
 import (
-	"log"
 	"reflect"
 
 	"gitlab.com/akzj/qp/lexer"
 	"gitlab.com/akzj/qp/runtime"
 )
 
+// CallStatement call function,object of method is bound by Function,
+// `u.print()` is PeriodStatement bound to u
 type CallStatement struct {
-	ParentExp runtime.Invokable
+	Pos
 	Function  runtime.Invokable
 	Arguments Expressions
+	// ArgumentPositions is position of Arguments,literals have no position
+	ArgumentPositions []lexer.Position
 }
 
 func (f *CallStatement) GetType() lexer.Type {
@@ -29,40 +32,41 @@ func (f *CallStatement) String() string {
 	return str + ") "
 }
 
+// Invoke return the first value when function return multiple values
 func (f *CallStatement) Invoke() runtime.Invokable {
+	value := f.Values()
+	if tuple, ok := value.(Tuple); ok {
+		return tuple[0]
+	}
+	return value
+}
+
+// Values call function and return all values it returned
+func (f *CallStatement) Values() runtime.Invokable {
+	if function, ok := f.Function.(GetVarStatement); ok && function.VM.GetObject(function.Label) == nil {
+		PanicAt(f.Position, "undefined function `%s`", function.Label)
+	}
 	exp := f.Function.Invoke()
 	switch obj := exp.(type) {
 	case *runtime.Object:
+		if obj == nil {
+			PanicAt(f.Position, "undefined function `%s`", f.Function.String())
+		}
 		exp = obj.Invoke()
 	case ReturnStatement:
 		exp = obj.Val
 	}
 	if exp == nil {
-		log.Panic("Function nil")
+		Panicln("Function nil")
 	}
 	var arguments []runtime.Invokable
-	if Func, ok := exp.(*FuncExpression); f.ParentExp != nil && (ok == false || Func.Closure == false) {
-		switch argument := f.ParentExp.Invoke().(type) {
-		case *runtime.Object:
-			if argument.Pointer == nil {
-				panic(argument.Label)
-			}
-			arguments = append(arguments, argument.Pointer)
-		default:
-			if argument == nil {
-				panic("argument nil")
-			}
-			arguments = append(arguments, argument)
-		}
-	}
-
 	if function, ok := exp.(Function); ok {
 		for _, argument := range f.Arguments {
 			obj := argument.Invoke()
 			switch obj := obj.(type) {
 			case *runtime.Object:
 				if obj == nil {
-					log.Panicf("no find object %s\n", argument.String())
+					Panicf("no find object %s\n", argument.String())
 				}
 				if obj.Pointer == nil {
 					panic(obj.Label + " " + f.Function.String())
@@ -77,6 +81,6 @@ func (f *CallStatement) Invoke() runtime.Invokable {
 		}
 		return function.Call(arguments...)
 	}
-	log.Panicf("Exp`%s` `%s` is no callable", exp.String(), reflect.TypeOf(exp).String())
+	Panicf("Exp`%s` `%s` is no callable", exp.String(), reflect.TypeOf(exp).String())
 	return nil
 }
@@ -38,6 +38,7 @@ type BinaryOpExpression struct {
 	OP    lexer.Type
 	Left  runtime.Invokable
 	Right runtime.Invokable
+	Int   bool // operands are int,known by type checker
 }
 
 func (b BinaryOpExpression) String() string {
@@ -60,6 +61,8 @@ func (b BinaryOpExpression) Invoke() runtime.Invokable {
 				return lVal * rVal
 			case lexer.DivOpType:
 				return lVal / rVal
+			case lexer.ModOpType:
+				return lVal % rVal
 			case lexer.LessType:
 				return Bool(lVal < rVal)
 			case lexer.LessEqualType:
@@ -73,6 +76,10 @@ func (b BinaryOpExpression) Invoke() runtime.Invokable {
 			case lexer.NoEqualType:
 				return Bool(lVal != rVal)
 			}
+		case Float:
+			if result := floatOp(b.OP, Float(lVal), rVal); result != nil {
+				return result
+			}
 		case NilObject:
 			switch b.OP {
 			case lexer.EqualType:
@@ -84,6 +91,51 @@ func (b BinaryOpExpression) Invoke() runtime.Invokable {
 			panic("no support type " + reflect.TypeOf(lVal).String() +
 				"\n" + reflect.TypeOf(rVal).String() + " op type" + b.OP.String())
 		}
+	case Float:
+		switch rVal := right.(type) {
+		case Float:
+			if result := floatOp(b.OP, lVal, rVal); result != nil {
+				return result
+			}
+		case Int:
+			if result := floatOp(b.OP, lVal, Float(rVal)); result != nil {
+				return result
+			}
+		case NilObject:
+			switch b.OP {
+			case lexer.EqualType:
+				return FalseObject
+			case lexer.NoEqualType:
+				return TrueObject
+			}
+		}
+	case String:
+		switch rVal := right.(type) {
+		case String:
+			switch b.OP {
+			case lexer.AddType:
+				return lVal + rVal
+			case lexer.LessType:
+				return Bool(lVal < rVal)
+			case lexer.LessEqualType:
+				return Bool(lVal <= rVal)
+			case lexer.GreaterType:
+				return Bool(lVal > rVal)
+			case lexer.GreaterEqualType:
+				return Bool(lVal >= rVal)
+			case lexer.EqualType:
+				return Bool(lVal == rVal)
+			case lexer.NoEqualType:
+				return Bool(lVal != rVal)
+			}
+		case NilObject:
+			switch b.OP {
+			case lexer.EqualType:
+				return FalseObject
+			case lexer.NoEqualType:
+				return TrueObject
+			}
+		}
 	case Bool:
 		switch rVal := right.(type) {
 		case Bool:
@@ -117,7 +169,14 @@ func (b BinaryOpExpression) Invoke() runtime.Invokable {
 			}
 		}
 	case *TypeObject:
-		switch right.(type) {
+		switch rVal := right.(type) {
+		case *TypeObject:
+			switch b.OP {
+			case lexer.EqualType:
+				return Bool(lVal == rVal)
+			case lexer.NoEqualType:
+				return Bool(lVal != rVal)
+			}
 		case NilObject:
 			switch b.OP {
 			case lexer.NoEqualType:
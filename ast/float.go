@@ -0,0 +1,53 @@
+package ast
+
+import (
+	"math"
+	"strconv"
+
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+type Float float64
+
+func (f Float) Invoke() runtime.Invokable {
+	return f
+}
+
+func (Float) GetType() lexer.Type {
+	return lexer.FloatType
+}
+
+func (f Float) String() string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 64)
+}
+
+// floatOp eval float binary op,int operand is promoted to float before,
+// nil is returned if op is no supported
+func floatOp(op lexer.Type, lVal, rVal Float) runtime.Invokable {
+	switch op {
+	case lexer.AddType:
+		return lVal + rVal
+	case lexer.SubType:
+		return lVal - rVal
+	case lexer.MulOpType:
+		return lVal * rVal
+	case lexer.DivOpType:
+		return lVal / rVal
+	case lexer.ModOpType:
+		return Float(math.Mod(float64(lVal), float64(rVal)))
+	case lexer.LessType:
+		return Bool(lVal < rVal)
+	case lexer.LessEqualType:
+		return Bool(lVal <= rVal)
+	case lexer.GreaterType:
+		return Bool(lVal > rVal)
+	case lexer.GreaterEqualType:
+		return Bool(lVal >= rVal)
+	case lexer.EqualType:
+		return Bool(lVal == rVal)
+	case lexer.NoEqualType:
+		return Bool(lVal != rVal)
+	}
+	return nil
+}
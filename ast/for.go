@@ -1,24 +1,47 @@
 package ast
 
 import (
-	"log"
+	"reflect"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"gitlab.com/akzj/qp/lexer"
 	"gitlab.com/akzj/qp/runtime"
 )
 
 type ForExpression struct {
+	Pos
 	VM         *runtime.VMRuntime
 	Pre        runtime.Invokable
 	Check      runtime.Invokable
 	Post       runtime.Invokable
 	Statements Expressions
+	// for Key, Value := range Range {},Key and Value may be empty
+	Range runtime.Invokable
+	Key   string
+	Value string
+	// Label is the name of loop for labeled break and continue
+	Label string
 }
 
 func (exp ForExpression) String() string {
 	var codes = "for "
-	codes += exp.Pre.String() + ";" + exp.Check.String() + ";" + exp.Post.String() + " {"
+	if exp.Label != "" {
+		codes = exp.Label + ": " + codes
+	}
+	if exp.Range != nil {
+		if exp.Key != "" {
+			codes += exp.Key
+			if exp.Value != "" {
+				codes += ", " + exp.Value
+			}
+			codes += " := "
+		}
+		codes += "range " + exp.Range.String() + " {"
+	} else {
+		codes += exp.Pre.String() + ";" + exp.Check.String() + ";" + exp.Post.String() + " {"
+	}
 
 	for _, str := range strings.Split(exp.Statements.String(), "\n") {
 		codes += "\n\t" + str
@@ -28,6 +51,9 @@ func (exp ForExpression) String() string {
 }
 
 func (exp ForExpression) Invoke() runtime.Invokable {
+	if exp.Range != nil {
+		return exp.invokeRange()
+	}
 
 	//make stack frame
 	exp.VM.PushStackFrame(false)
@@ -35,27 +61,126 @@ func (exp ForExpression) Invoke() runtime.Invokable {
 	for {
 		val, ok := exp.Check.Invoke().(Bool)
 		if !ok {
-			log.Panic("for Check expect Bool")
+			Panicln("for Check expect Bool")
 		}
 		if !val {
 			exp.VM.PopStackFrame() //end of for
 			return nil
 		}
 		exp.VM.PushStackFrame(false) //make stack frame for `{` brock
-		for _, statement := range exp.Statements {
-			val := statement.Invoke()
-			if val == BreakObj {
-				return nil
-			}
-			if _, ok := val.(ReturnStatement); ok {
-				return val
-			}
-		}
+		result, done := exp.loopControl(exp.Statements.Invoke())
 		exp.VM.PopStackFrame()
+		if done {
+			exp.VM.PopStackFrame() //end of for
+			return result
+		}
 		exp.Post.Invoke()
 	}
 }
 
+func (exp ForExpression) invokeRange() runtime.Invokable {
+	object := unwrapObject(exp.Range.Invoke())
+	keys := rangeKeys(object)
+	for _, key := range keys {
+		value, ok := rangeValue(object, key)
+		if ok == false {
+			continue // deleted in loop
+		}
+		exp.VM.PushStackFrame(false) //make stack frame for `{` brock
+		if exp.Key != "" && exp.Key != "_" {
+			exp.VM.AllocObject(exp.Key).Pointer = key
+		}
+		if exp.Value != "" && exp.Value != "_" {
+			exp.VM.AllocObject(exp.Value).Pointer = value
+		}
+		result, done := exp.loopControl(exp.Statements.Invoke())
+		exp.VM.PopStackFrame()
+		if done {
+			return result
+		}
+	}
+	return nil
+}
+
+// loopControl check the value of loop statements,done is true if the loop
+// is over.break,continue of outer loop and return are passed to the caller
+func (exp ForExpression) loopControl(val runtime.Invokable) (result runtime.Invokable, done bool) {
+	switch val := val.(type) {
+	case *BreakObject:
+		if val.Label == "" || val.Label == exp.Label {
+			return nil, true
+		}
+		return val, true
+	case *ContinueObject:
+		if val.Label == "" || val.Label == exp.Label {
+			return nil, false
+		}
+		return val, true
+	case ReturnStatement:
+		return val, true
+	}
+	return nil, false
+}
+
+// rangeKeys return the keys of loop,they are index of array,
+// byte offset of string,keys of map or field names of object
+func rangeKeys(object runtime.Invokable) []runtime.Invokable {
+	var keys []runtime.Invokable
+	switch object := object.(type) {
+	case *Array:
+		for index := range object.Data {
+			keys = append(keys, Int(index))
+		}
+	case String:
+		for index := range string(object) {
+			keys = append(keys, Int(index))
+		}
+	case *Map:
+		keys = object.Keys()
+	case *TypeObject:
+		var names []string
+		for name, field := range object.GetObjects() {
+			if _, ok := field.Pointer.(Function); ok {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			keys = append(keys, String(name))
+		}
+	case NilObject:
+	default:
+		Panicf("can't range over `%s`", reflect.TypeOf(object).String())
+	}
+	return keys
+}
+
+func rangeValue(object runtime.Invokable, key runtime.Invokable) (runtime.Invokable, bool) {
+	switch object := object.(type) {
+	case *Array:
+		if int(key.(Int)) >= len(object.Data) {
+			return nil, false
+		}
+		return unwrapObject(object.Data[key.(Int)]), true
+	case String:
+		char, _ := utf8.DecodeRuneInString(string(object[key.(Int):]))
+		return String(char), true
+	case *Map:
+		if _, ok := object.values[key]; ok == false {
+			return nil, false
+		}
+		return unwrapObject(object.Get(key)), true
+	case *TypeObject:
+		field := object.GetObject(string(key.(String)))
+		if field == nil {
+			return nil, false
+		}
+		return unwrapObject(field.Pointer), true
+	}
+	return nil, false
+}
+
 func (f ForExpression) GetType() lexer.Type {
 	return lexer.ForType
 }
@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"log"
 	"strings"
 
 	"gitlab.com/akzj/qp/lexer"
@@ -9,6 +8,7 @@ import (
 )
 
 type FuncExpression struct {
+	Pos
 	Closure      bool
 	Label        string
 	Labels       []string // struct objects Function eg:user.add(){}
@@ -18,6 +18,11 @@ type FuncExpression struct {
 	Statements   Expressions        // Function body
 	VM           *runtime.VMRuntime // VM context
 	ClosureObjs  []runtime.Invokable
+
+	// ParameterTypes is the annotated types of Parameters,"" for untyped,
+	// nil when no parameter annotated
+	ParameterTypes []string
+	Returns        []string // annotated types of return values
 }
 
 func (f *FuncExpression) String() string {
@@ -27,8 +32,17 @@ func (f *FuncExpression) String() string {
 			str += ","
 		}
 		str += argument
+		if index < len(f.ParameterTypes) && f.ParameterTypes[index] != "" {
+			str += " " + f.ParameterTypes[index]
+		}
+	}
+	str += ")"
+	if len(f.Returns) == 1 {
+		str += " " + f.Returns[0]
+	} else if len(f.Returns) > 1 {
+		str += " (" + strings.Join(f.Returns, ", ") + ")"
 	}
-	str += "){\n"
+	str += "{\n"
 	for _, statement := range f.Statements {
 		for _, line := range strings.Split(statement.String(), "\n") {
 			str += "\t" + line + "\n"
@@ -45,7 +59,7 @@ func (f *FuncExpression) Invoke() runtime.Invokable {
 
 func (f *FuncExpression) prepareArgumentBind(inArguments []runtime.Invokable) {
 	if len(f.Parameters) != len(inArguments) {
-		log.Panicf("call Function %s argument count %d %d no match ", f.Label, len(f.Parameters), len(inArguments))
+		Panicf("call Function %s argument count %d %d no match ", f.Label, len(f.Parameters), len(inArguments))
 	}
 
 	for index := range f.ClosureLabel {
@@ -59,6 +73,10 @@ func (f *FuncExpression) prepareArgumentBind(inArguments []runtime.Invokable) {
 }
 
 func (f *FuncExpression) Call(arguments ...runtime.Invokable) runtime.Invokable {
+	if f.VM.EnterCall() == false {
+		Panicln("stack overflow")
+	}
+	defer f.VM.LeaveCall()
 	f.VM.PushStackFrame(true)
 	defer f.VM.PopStackFrame()
 	f.prepareArgumentBind(arguments)
@@ -88,7 +106,7 @@ func (f *FuncExpression) doClosureInit() {
 		}
 		obj := f.VM.GetObject(label)
 		if obj == nil {
-			log.Panicf("no find obj with Name `%s`", label)
+			Panicf("no find obj with Name `%s`", label)
 		}
 		closureObjs = append(closureObjs, obj.Pointer)
 		closureLabel = append(closureLabel, label)
@@ -96,3 +114,31 @@ func (f *FuncExpression) doClosureInit() {
 	f.ClosureObjs = closureObjs
 	f.ClosureLabel = closureLabel
 }
+
+// IsMethod check function is declared as method of type,`func User.add(){}`
+func (f *FuncExpression) IsMethod() bool {
+	return len(f.Labels) == 2
+}
+
+// BoundMethod is method of object taken as value,`f := u.print`,the
+// object is passed as `this` when it is called
+type BoundMethod struct {
+	This   runtime.Invokable
+	Method Function
+}
+
+func (b BoundMethod) Invoke() runtime.Invokable {
+	return b
+}
+
+func (b BoundMethod) GetType() lexer.Type {
+	return lexer.FuncStatementType
+}
+
+func (b BoundMethod) String() string {
+	return b.Method.String()
+}
+
+func (b BoundMethod) Call(arguments ...runtime.Invokable) runtime.Invokable {
+	return b.Method.Call(append([]runtime.Invokable{b.This}, arguments...)...)
+}
@@ -0,0 +1,191 @@
+package ast
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+// InterfaceObject is declaration of interface,`interface Printer{ print() }`.
+// Value satisfies interface when it has methods of all names of interface,
+// signatures of methods are checked by type checker only
+type InterfaceObject struct {
+	Pos
+	VM    *runtime.VMRuntime
+	Label string
+	// Methods has no body,`this` is no in Parameters
+	Methods []*FuncExpression
+	// Embedded is interfaces embedded,methods of them are copied to
+	// Methods after parsing
+	Embedded []string
+}
+
+func (i *InterfaceObject) Invoke() runtime.Invokable {
+	return i
+}
+
+func (i *InterfaceObject) GetType() lexer.Type {
+	return lexer.InterfaceType
+}
+
+func (i *InterfaceObject) String() string {
+	var methods []string
+	for _, method := range i.Methods {
+		methods = append(methods, method.Label+"("+strings.Join(method.Parameters, ",")+")")
+	}
+	return "interface " + i.Label + "{" + strings.Join(methods, "; ") + "}"
+}
+
+// Method return method declared of name,nil if no found
+func (i *InterfaceObject) Method(name string) *FuncExpression {
+	for _, method := range i.Methods {
+		if method.Label == name {
+			return method
+		}
+	}
+	return nil
+}
+
+// MethodNames return names of methods sorted
+func (i *InterfaceObject) MethodNames() []string {
+	var names []string
+	for _, method := range i.Methods {
+		names = append(names, method.Label)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Implemented check value has all methods of interface,empty interface is
+// implemented by all values but nil
+func (i *InterfaceObject) Implemented(value runtime.Invokable) bool {
+	value = unwrapObject(value)
+	if len(i.Methods) == 0 {
+		_, ok := value.(NilObject)
+		return ok == false && value != nil
+	}
+	object, ok := value.(*TypeObject)
+	if ok == false {
+		return false
+	}
+	for _, method := range i.Methods {
+		member := object.GetObject(method.Label)
+		if member == nil {
+			return false
+		}
+		if _, ok := unwrapObject(member.Pointer).(Function); ok == false {
+			return false
+		}
+	}
+	return true
+}
+
+// BasicTypes is names of types built in,used in `x is int`
+var BasicTypes = map[string]bool{
+	"any": true, "int": true, "float": true, "string": true, "bool": true,
+	"array": true, "map": true, "func": true, "nil": true,
+}
+
+// TypeName is type of `x is T` and case of type switch: built in type,
+// type or interface declared,Module is set for `module.Type`
+type TypeName struct {
+	Label  string
+	VM     *runtime.VMRuntime // vm declaring type,vm of module for `module.Type`
+	Module *Module
+}
+
+func (t TypeName) String() string {
+	if t.Module != nil {
+		return path.Base(t.Module.Name) + "." + t.Label
+	}
+	return t.Label
+}
+
+// Declaration return *TypeObject or *InterfaceObject of type,nil for built
+// in type
+func (t TypeName) Declaration() runtime.Invokable {
+	if t.Module == nil && BasicTypes[t.Label] {
+		return nil
+	}
+	object := t.VM.GetTypeObject(t.Label)
+	if object == nil {
+		Panicf("undefined type `%s`", t.String())
+	}
+	return object.Pointer
+}
+
+// Match check value is of type
+func (t TypeName) Match(value runtime.Invokable) bool {
+	value = unwrapObject(value)
+	switch declaration := t.Declaration().(type) {
+	case *TypeObject:
+		object, ok := value.(*TypeObject)
+		return ok && object.Label == declaration.Label && object.VM == declaration.VM
+	case *InterfaceObject:
+		return declaration.Implemented(value)
+	}
+	switch t.Label {
+	case "any":
+		_, ok := value.(NilObject)
+		return ok == false && value != nil
+	case "int":
+		_, ok := value.(Int)
+		return ok
+	case "float":
+		_, ok := value.(Float)
+		return ok
+	case "string":
+		_, ok := value.(String)
+		return ok
+	case "bool":
+		_, ok := value.(Bool)
+		return ok
+	case "array":
+		_, ok := value.(*Array)
+		return ok
+	case "map":
+		_, ok := value.(*Map)
+		return ok
+	case "func":
+		_, ok := value.(Function)
+		return ok
+	case "nil":
+		_, ok := value.(NilObject)
+		return ok || value == nil
+	}
+	return false
+}
+
+// IsExpression check type of value,`x is User`,`x is Printer`,`x is int`.
+// It is true when value is of one of Types,cases of type switch have
+// multiple types
+type IsExpression struct {
+	Pos
+	Exp   runtime.Invokable
+	Types []TypeName
+}
+
+func (i IsExpression) Invoke() runtime.Invokable {
+	value := i.Exp.Invoke()
+	for _, t := range i.Types {
+		if t.Match(value) {
+			return Bool(true)
+		}
+	}
+	return Bool(false)
+}
+
+func (i IsExpression) GetType() lexer.Type {
+	return lexer.IsType
+}
+
+func (i IsExpression) String() string {
+	var types []string
+	for _, t := range i.Types {
+		types = append(types, t.String())
+	}
+	return i.Exp.String() + " is " + strings.Join(types, ",")
+}
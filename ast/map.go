@@ -0,0 +1,119 @@
+package ast
+
+import (
+	"reflect"
+	"strings"
+
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+// Map is a dictionary keep keys in insertion order,
+// key must be String,Int or Bool
+type Map struct {
+	keys   []runtime.Invokable
+	values map[runtime.Invokable]*runtime.Object
+}
+
+func NewMap() *Map {
+	return &Map{
+		values: map[runtime.Invokable]*runtime.Object{},
+	}
+}
+
+func mapKey(key runtime.Invokable) runtime.Invokable {
+	switch key := unwrapObject(key).(type) {
+	case String, Int, Bool:
+		return key
+	default:
+		Panicf("invalid map key type `%s`", reflect.TypeOf(key).String())
+	}
+	return nil
+}
+
+// Get return the value of key,NilObj if key no exist
+func (m *Map) Get(key runtime.Invokable) runtime.Invokable {
+	if object, ok := m.values[mapKey(key)]; ok {
+		return object.Pointer
+	}
+	return NilObj
+}
+
+// Alloc return the object of key,the object is created if key no exist
+func (m *Map) Alloc(key runtime.Invokable) *runtime.Object {
+	key = mapKey(key)
+	object, ok := m.values[key]
+	if ok == false {
+		object = &runtime.Object{
+			Pointer: NilObj,
+			Label:   key.String(),
+		}
+		m.values[key] = object
+		m.keys = append(m.keys, key)
+	}
+	return object
+}
+
+func (m *Map) Delete(key runtime.Invokable) {
+	key = mapKey(key)
+	if _, ok := m.values[key]; ok == false {
+		return
+	}
+	delete(m.values, key)
+	for index, it := range m.keys {
+		if it == key {
+			m.keys = append(m.keys[:index], m.keys[index+1:]...)
+			break
+		}
+	}
+}
+
+func (m *Map) Keys() []runtime.Invokable {
+	return append([]runtime.Invokable{}, m.keys...)
+}
+
+func (m *Map) Len() int {
+	return len(m.keys)
+}
+
+func (m *Map) String() string {
+	var items []string
+	for _, key := range m.keys {
+		items = append(items, key.String()+":"+m.values[key].Pointer.String())
+	}
+	return "map[" + strings.Join(items, " ") + "]"
+}
+
+func (m *Map) Invoke() runtime.Invokable {
+	return m
+}
+
+func (m *Map) GetType() lexer.Type {
+	return lexer.MapObjectType
+}
+
+// MakeMapStatement make map with literal {k:v,...}
+type MakeMapStatement struct {
+	Keys   Expressions
+	Values Expressions
+}
+
+func (m *MakeMapStatement) String() string {
+	var items []string
+	for index := range m.Keys {
+		items = append(items, m.Keys[index].String()+": "+m.Values[index].String())
+	}
+	return "{" + strings.Join(items, ", ") + "}"
+}
+
+func (m *MakeMapStatement) Invoke() runtime.Invokable {
+	var object = NewMap()
+	for index := range m.Keys {
+		object.Alloc(m.Keys[index].Invoke()).Pointer = unwrapObject(m.Values[index].Invoke())
+	}
+	return object
+}
+
+func (m *MakeMapStatement) GetType() lexer.Type {
+	return lexer.MapObjectType
+}
@@ -0,0 +1,69 @@
+package ast
+
+import (
+	"path"
+	"strconv"
+
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+// Module is the script loaded by `import`,functions and types of module
+// are referenced by `name.member`
+type Module struct {
+	Name        string // import path,`import "container/list"`
+	File        string
+	VM          *runtime.VMRuntime
+	Statements  Expressions
+	initialized bool
+}
+
+// Init run statements of module,only the first call runs them
+func (m *Module) Init() {
+	if m.initialized {
+		return
+	}
+	m.initialized = true
+	m.Statements.Invoke()
+}
+
+// ImportStatement is `import "list"`
+type ImportStatement struct {
+	Pos
+	Module *Module
+}
+
+func (i ImportStatement) Invoke() runtime.Invokable {
+	i.Module.Init()
+	return nil
+}
+
+func (i ImportStatement) GetType() lexer.Type {
+	return lexer.ImportType
+}
+
+func (i ImportStatement) String() string {
+	return "import " + strconv.Quote(i.Module.Name)
+}
+
+// ModuleMember is function or type of module: list.List
+type ModuleMember struct {
+	Module *Module
+	Label  string
+}
+
+func (m ModuleMember) Invoke() runtime.Invokable {
+	object := m.Module.VM.GetObject(m.Label)
+	if object == nil {
+		Panicf("module `%s` has no member `%s`", m.Module.Name, m.Label)
+	}
+	return object
+}
+
+func (m ModuleMember) GetType() lexer.Type {
+	return lexer.ModuleMemberType
+}
+
+func (m ModuleMember) String() string {
+	return path.Base(m.Module.Name) + "." + m.Label
+}
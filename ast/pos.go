@@ -0,0 +1,49 @@
+package ast
+
+import (
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+// Pos is embedded by statements to record the source position
+type Pos struct {
+	Position lexer.Position
+}
+
+// GetPosition return the source position,Line is 0 when unknown
+func (pos Pos) GetPosition() lexer.Position {
+	return pos.Position
+}
+
+// Positioned is statement with source position
+type Positioned interface {
+	GetPosition() lexer.Position
+}
+
+// PositionOf return the source position of expression,the position of
+// its left most operand for expression without position.Line is 0 when
+// unknown
+func PositionOf(exp runtime.Invokable) lexer.Position {
+	switch exp := exp.(type) {
+	case *CallStatement:
+		if pos := PositionOf(exp.Function); pos.Line != 0 {
+			return pos
+		}
+		return exp.Position
+	case PeriodStatement:
+		return PositionOf(exp.Exp)
+	case IndexExpression:
+		return PositionOf(exp.Exp)
+	case SliceExpression:
+		return PositionOf(exp.Exp)
+	case BinaryOpExpression:
+		return PositionOf(exp.Left)
+	case ParenthesisExpression:
+		return PositionOf(exp.Exp)
+	case NoStatement:
+		return PositionOf(exp.Exp)
+	case Positioned:
+		return exp.GetPosition()
+	}
+	return lexer.Position{}
+}
@@ -1,7 +1,6 @@
 package ast
 
 import (
-	"log"
 	"reflect"
 	"strings"
 
@@ -27,6 +26,7 @@ type Expression interface {
 }
 
 type ReturnStatement struct {
+	Pos
 	Exp runtime.Invokable
 	Val runtime.Invokable
 }
@@ -40,17 +40,67 @@ func (r ReturnStatement) String() string {
 }
 
 type PeriodStatement struct {
+	VM  *runtime.VMRuntime // methods of string and array are looked up in VM
 	Val string
 	Exp runtime.Invokable
+	// Bind is false for target of assignment,method of value is bound to
+	// the object,`f := u.print`,`u.print()`
+	Bind bool
 }
 
 func (p PeriodStatement) Invoke() runtime.Invokable {
 	object := unwrapObject(p.Exp.Invoke())
 	switch obj := object.(type) {
 	case BaseObject:
-		return obj.AllocObject(p.Val)
+		member := p.member(obj)
+		if p.Bind {
+			if method := methodOf(obj, member); method != nil {
+				return BoundMethod{This: obj, Method: method}
+			}
+		}
+		return member
 	default:
-		log.Panicf("Left `%s` `%s` is no Exp type", p.Val, reflect.TypeOf(obj).String())
+		Panicf("Left `%s` `%s` is no Exp type", p.Val, reflect.TypeOf(obj).String())
+	}
+	return nil
+}
+
+func (p PeriodStatement) member(object BaseObject) *runtime.Object {
+	if p.VM == nil {
+		return object.AllocObject(p.Val)
+	}
+	switch object := object.(type) {
+	case String:
+		if method, ok := p.VM.StringFunctions[p.Val]; ok {
+			return method
+		}
+		Panicf("%s undefined (type string has no method %s)", p.String(), p.Val)
+	case *Array:
+		if method, ok := p.VM.ArrayFunctions[p.Val]; ok {
+			return method
+		}
+		return object.allocField(p.Val)
+	}
+	return object.AllocObject(p.Val)
+}
+
+// methodOf return member as method of object,nil if member is field.
+// Methods are declared by `func Type.name(){}` or built in methods of
+// string and array
+func methodOf(object BaseObject, member *runtime.Object) Function {
+	if member == nil {
+		return nil
+	}
+	switch function := unwrapObject(member.Pointer).(type) {
+	case *FuncExpression:
+		if function.IsMethod() {
+			return function
+		}
+	case Function:
+		switch object.(type) {
+		case String, *Array:
+			return function
+		}
 	}
 	return nil
 }
@@ -64,6 +114,7 @@ func (p PeriodStatement) String() string {
 }
 
 type GetVarStatement struct {
+	Pos
 	VM    *runtime.VMRuntime
 	Label string
 }
@@ -72,7 +123,7 @@ func (f GetVarStatement) String() string {
 	return f.Label
 }
 
-//a.b.c.d
+// a.b.c.d
 type getObjectPropStatement struct {
 	this      bool
 	getObject *getObjectObjectStatement
@@ -88,6 +139,7 @@ type getObjectObjectStatement struct {
 }
 
 type AssignStatement struct {
+	Pos
 	Exp  runtime.Invokable
 	Left runtime.Invokable
 }
@@ -97,6 +149,7 @@ func (expression AssignStatement) String() string {
 }
 
 type IncFieldStatement struct {
+	Pos
 	Exp runtime.Invokable
 }
 
@@ -118,9 +171,21 @@ type ObjectInitStatement struct {
 	VM            *runtime.VMRuntime
 	Exp           runtime.Invokable
 	PropTemplates []TypeObjectPropTemplate
+	// Constructor is true for `Type(arguments)`,init is called with
+	// Arguments. `Type{}` call init only when it has no parameter
+	Constructor       bool
+	Arguments         Expressions
+	ArgumentPositions []lexer.Position
 }
 
 func (statement ObjectInitStatement) String() string {
+	if statement.Constructor {
+		var arguments []string
+		for _, argument := range statement.Arguments {
+			arguments = append(arguments, argument.String())
+		}
+		return statement.Exp.String() + "(" + strings.Join(arguments, ",") + ")"
+	}
 	var str string
 	for _, statement := range statement.PropTemplates {
 		str += statement.String() + "\n"
@@ -128,21 +193,110 @@ func (statement ObjectInitStatement) String() string {
 	return statement.Exp.String() + "{" + str + "}"
 }
 
-type ArrayGetElement struct {
+// IndexExpression get element of map or array: Exp[Index]
+type IndexExpression struct {
+	Pos
 	Exp   runtime.Invokable
 	Index runtime.Invokable
 }
 
-func (g ArrayGetElement) Invoke() runtime.Invokable {
-	panic("implement me")
+func (g IndexExpression) Invoke() runtime.Invokable {
+	switch object := unwrapObject(g.Exp.Invoke()).(type) {
+	case *Map:
+		return object.Get(g.Index.Invoke())
+	case *Array:
+		return object.Data[g.arrayIndex(object)]
+	default:
+		PanicAt(g.Position, "`%s` is no map or array", g.Exp.String())
+	}
+	return nil
 }
 
-func (g ArrayGetElement) GetType() lexer.Type {
-	panic("implement me")
+// arrayIndex return index of element of array,error raised when index is
+// no int or out of range
+func (g IndexExpression) arrayIndex(array *Array) int {
+	index, ok := unwrapObject(g.Index.Invoke()).(Int)
+	if ok == false {
+		PanicAt(g.Position, "array index `%s` is no int", g.Index.String())
+	}
+	if index < 0 {
+		PanicAt(g.Position, "invalid array index %d (index must be non-negative)", index)
+	}
+	if int(index) >= len(array.Data) {
+		PanicAt(g.Position, "index %d out of range [0:%d]", index, len(array.Data))
+	}
+	return int(index)
 }
 
-func (g ArrayGetElement) String() string {
-	panic("implement me")
+// Store assign value to the element of index
+func (g IndexExpression) Store(value runtime.Invokable) {
+	switch object := unwrapObject(g.Exp.Invoke()).(type) {
+	case *Map:
+		object.Alloc(g.Index.Invoke()).Pointer = unwrapObject(value)
+	case *Array:
+		object.Data[g.arrayIndex(object)] = unwrapObject(value)
+	default:
+		PanicAt(g.Position, "`%s` is no map or array", g.Exp.String())
+	}
+}
+
+func (g IndexExpression) GetType() lexer.Type {
+	return lexer.LeftBracketType
+}
+
+func (g IndexExpression) String() string {
+	return g.Exp.String() + "[" + g.Index.String() + "]"
+}
+
+// SliceExpression make array of elements of array from Low to High,
+// `a[lo:hi]`,`a[lo:]`,`a[:hi]`.Low and High are nil when omitted,elements
+// are copied
+type SliceExpression struct {
+	Pos
+	Exp  runtime.Invokable
+	Low  runtime.Invokable
+	High runtime.Invokable
+}
+
+func (s SliceExpression) Invoke() runtime.Invokable {
+	array, ok := unwrapObject(s.Exp.Invoke()).(*Array)
+	if ok == false {
+		PanicAt(s.Position, "`%s` is no array", s.Exp.String())
+	}
+	low, high := s.bound(s.Low, 0), s.bound(s.High, len(array.Data))
+	if low < 0 || high > len(array.Data) || low > high {
+		PanicAt(s.Position, "slice bounds out of range [%d:%d] with length %d", low, high, len(array.Data))
+	}
+	data := make([]runtime.Invokable, high-low)
+	copy(data, array.Data[low:high])
+	return &Array{Data: data}
+}
+
+// bound return value of bound,value is the default of bound omitted
+func (s SliceExpression) bound(exp runtime.Invokable, value int) int {
+	if exp == nil {
+		return value
+	}
+	index, ok := unwrapObject(exp.Invoke()).(Int)
+	if ok == false {
+		PanicAt(s.Position, "slice index `%s` is no int", exp.String())
+	}
+	return int(index)
+}
+
+func (s SliceExpression) GetType() lexer.Type {
+	return lexer.LeftBracketType
+}
+
+func (s SliceExpression) String() string {
+	var low, high string
+	if s.Low != nil {
+		low = s.Low.String()
+	}
+	if s.High != nil {
+		high = s.High.String()
+	}
+	return s.Exp.String() + "[" + low + ":" + high + "]"
 }
 
 type MakeArrayStatement struct {
@@ -184,11 +338,11 @@ func (g *getObjectPropStatement) Invoke() runtime.Invokable {
 func (g *getObjectObjectStatement) Invoke() runtime.Invokable {
 	object := g.vmContext.GetObject(g.labels[0])
 	if object == nil {
-		log.Panicf("Left failed `%s`", g.labels[0])
+		Panicf("Left failed `%s`", g.labels[0])
 	}
 	structObj, ok := object.Pointer.(BaseObject)
 	if ok == false {
-		log.Panic("objects type no struct objects,error",
+		Panicln("objects type no struct objects,error",
 			g.labels, reflect.TypeOf(object.Pointer).String())
 	}
 	/*
@@ -204,7 +358,7 @@ func (g *getObjectObjectStatement) Invoke() runtime.Invokable {
 			structObj, ok = obj.Pointer.(*TypeObject)
 			if ok == false {
 				label := strings.Join(g.labels[:i+1], ".")
-				log.Panic("objects is no struct objects type", label)
+				Panicln("objects is no struct objects type", label)
 			}
 		}
 	}
@@ -230,21 +384,48 @@ Loop:
 			}
 		}
 		propObject := object.AllocObject(init.Name)
-		propObject.Pointer = init.Exp.Invoke()
+		propObject.Pointer = unwrapObject(init.Exp.Invoke())
 	}
 
 	for _, init := range statement.PropTemplates {
 		propObject := object.AllocObject(init.Name)
-		propObject.Pointer = init.Exp.Invoke()
+		propObject.Pointer = unwrapObject(init.Exp.Invoke())
 	}
+	statement.construct(object)
 	return object
 }
 
+// construct call init of object after fields initialized
+func (statement ObjectInitStatement) construct(object *TypeObject) {
+	method := object.Constructor()
+	if method == nil {
+		if len(statement.Arguments) != 0 {
+			Panicf("type %s has no method `%s`", object.Label, ConstructorName)
+		}
+		return
+	}
+	if statement.Constructor == false && len(method.Parameters) != 1 {
+		return
+	}
+	if msg := ConstructorArity(statement, method); msg != "" {
+		Panicln(msg)
+	}
+	arguments := []runtime.Invokable{object}
+	for _, argument := range statement.Arguments {
+		arguments = append(arguments, unwrapObject(argument.Invoke()))
+	}
+	method.Call(arguments...)
+}
+
 func (statement ObjectInitStatement) GetType() lexer.Type {
 	return lexer.TypeObjectInitStatementType
 }
 
 func (expression AssignStatement) Invoke() runtime.Invokable {
+	if index, ok := expression.Left.(IndexExpression); ok {
+		index.Store(expression.Exp.Invoke())
+		return nil
+	}
 	left := expression.Left.Invoke()
 	switch right := expression.Exp.Invoke().(type) {
 	case *runtime.Object:
@@ -268,6 +449,10 @@ func (n NopStatement) GetType() lexer.Type {
 }
 
 func (statement IncFieldStatement) Invoke() runtime.Invokable {
+	if index, ok := statement.Exp.(IndexExpression); ok {
+		index.Store(index.Invoke().(Int) + 1)
+		return nil
+	}
 	object := statement.Exp.Invoke().(*runtime.Object)
 	object.Pointer = object.Pointer.(Int) + 1
 	return nil
@@ -282,7 +467,11 @@ func (Expressions) GetType() lexer.Type {
 }
 
 func (f GetVarStatement) Invoke() runtime.Invokable {
-	return f.VM.GetObject(f.Label)
+	object := f.VM.GetObject(f.Label)
+	if object == nil {
+		PanicAt(f.Position, "undefined `%s`", f.Label)
+	}
+	return object
 }
 
 func (f GetVarStatement) GetType() lexer.Type {
@@ -293,9 +482,18 @@ func (r ReturnStatement) Invoke() runtime.Invokable {
 	if r.Val != nil {
 		return r
 	}
-	exp := r.Exp.Invoke()
+	var exp runtime.Invokable
+	if call, ok := r.Exp.(*CallStatement); ok {
+		// all values of `return f()` are returned
+		exp = call.Values()
+	} else {
+		exp = r.Exp.Invoke()
+	}
 	switch obj := exp.(type) {
 	case *runtime.Object:
+		if obj == nil {
+			Panicf("no find object %s", r.Exp.String())
+		}
 		exp = obj.Pointer
 	case ReturnStatement:
 		return obj
@@ -314,7 +512,9 @@ func (statements Expressions) Invoke() runtime.Invokable {
 		if _, ok := val.(ReturnStatement); ok {
 			return val
 		} else if _, ok := val.(*BreakObject); ok {
-			return BreakObj
+			return val
+		} else if _, ok := val.(*ContinueObject); ok {
+			return val
 		}
 	}
 	return val
@@ -32,4 +32,4 @@ func (d DurationObject) GetType() lexer.Type {
 
 func (d DurationObject) String() string {
 	return time.Duration(d).String()
-}
\ No newline at end of file
+}
@@ -0,0 +1,126 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+// Error is the runtime error bound to the var of `catch`
+type Error struct {
+	Message string
+	// Position is where the error raised,Line is 0 when unknown
+	Position lexer.Position
+}
+
+// NewError make Error of the value recovered from runtime fault
+func NewError(r interface{}) *Error {
+	switch r := r.(type) {
+	case *Error:
+		return r
+	case error:
+		return &Error{Message: r.Error()}
+	default:
+		return &Error{Message: fmt.Sprint(r)}
+	}
+}
+
+// Error return message prefixed by position,message only is bound to
+// `catch`
+func (e *Error) Error() string {
+	if e.Position.Line != 0 {
+		return e.Position.String() + ": " + e.Message
+	}
+	return e.Message
+}
+
+func (e *Error) Invoke() runtime.Invokable {
+	return e
+}
+
+func (e *Error) GetType() lexer.Type {
+	return lexer.ErrorObjectType
+}
+
+func (e *Error) String() string {
+	return e.Message
+}
+
+func (e *Error) GetObject(label string) *runtime.Object {
+	if label == "message" {
+		return &runtime.Object{Label: label, Pointer: String(e.Message)}
+	}
+	return nil
+}
+
+func (e *Error) AllocObject(label string) *runtime.Object {
+	object := e.GetObject(label)
+	if object == nil {
+		Panicf("error has no field `%s`", label)
+	}
+	return object
+}
+
+func (e *Error) Clone() BaseObject {
+	return e
+}
+
+// Panicf raise runtime error,it can be caught by `catch`
+func Panicf(format string, v ...interface{}) {
+	panic(&Error{Message: fmt.Sprintf(format, v...)})
+}
+
+// PanicAt raise runtime error at position of source
+func PanicAt(pos lexer.Position, format string, v ...interface{}) {
+	panic(&Error{Message: fmt.Sprintf(format, v...), Position: pos})
+}
+
+// Panicln raise runtime error,operands are separated by space
+func Panicln(v ...interface{}) {
+	panic(&Error{Message: strings.TrimSuffix(fmt.Sprintln(v...), "\n")})
+}
+
+// TryStatement run Statements,runtime error raised by Statements is bound to
+// Label and Catch is run
+type TryStatement struct {
+	Pos
+	VM         *runtime.VMRuntime
+	Statements Expressions
+	Label      string // var of error,`catch e`
+	Catch      Expressions
+}
+
+func (t TryStatement) Invoke() runtime.Invokable {
+	val, err := t.try()
+	if err == nil {
+		return val
+	}
+	t.VM.PushStackFrame(false)
+	defer t.VM.PopStackFrame()
+	t.VM.AllocObject(t.Label).Pointer = err
+	return t.Catch.Invoke()
+}
+
+func (t TryStatement) try() (val runtime.Invokable, err *Error) {
+	mark := t.VM.Mark()
+	defer func() {
+		if r := recover(); r != nil {
+			t.VM.Reset(mark)
+			err = NewError(r)
+		}
+	}()
+	t.VM.PushStackFrame(false)
+	defer t.VM.PopStackFrame()
+	return t.Statements.Invoke(), nil
+}
+
+func (t TryStatement) GetType() lexer.Type {
+	return lexer.TryType
+}
+
+func (t TryStatement) String() string {
+	return "try {\n\t" + addTag(t.Statements.String()) + "\n} catch " + t.Label +
+		" {\n\t" + addTag(t.Catch.String()) + "\n}"
+}
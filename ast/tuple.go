@@ -0,0 +1,107 @@
+package ast
+
+import (
+	"strings"
+
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+// Tuple is the values returned by `return a, b`
+type Tuple []runtime.Invokable
+
+func (t Tuple) Invoke() runtime.Invokable {
+	return t
+}
+
+func (t Tuple) GetType() lexer.Type {
+	return lexer.TupleObjectType
+}
+
+func (t Tuple) String() string {
+	var values []string
+	for _, value := range t {
+		values = append(values, value.String())
+	}
+	return strings.Join(values, ", ")
+}
+
+// TupleExpression is the expressions of `return a, b` or `a, b = b, a`
+type TupleExpression struct {
+	Exps Expressions
+}
+
+func (t TupleExpression) Invoke() runtime.Invokable {
+	var tuple = make(Tuple, 0, len(t.Exps))
+	for _, exp := range t.Exps {
+		tuple = append(tuple, unwrapObject(exp.Invoke()))
+	}
+	return tuple
+}
+
+func (t TupleExpression) GetType() lexer.Type {
+	return lexer.TupleObjectType
+}
+
+func (t TupleExpression) String() string {
+	var exps []string
+	for _, exp := range t.Exps {
+		exps = append(exps, exp.String())
+	}
+	return strings.Join(exps, ", ")
+}
+
+// MultiAssignStatement is `a, b = f()`,or `a, b := f()` when Define,
+// `_` discard the value
+type MultiAssignStatement struct {
+	Pos
+	VM     *runtime.VMRuntime
+	Define bool
+	Lefts  []runtime.Invokable
+	Exp    runtime.Invokable
+}
+
+func (m MultiAssignStatement) Invoke() runtime.Invokable {
+	var exp = m.Exp.Invoke
+	if call, ok := m.Exp.(*CallStatement); ok {
+		exp = call.Values
+	}
+	var values Tuple
+	switch value := unwrapObject(exp()).(type) {
+	case Tuple:
+		values = value
+	case nil:
+	default:
+		values = Tuple{value}
+	}
+	if len(values) != len(m.Lefts) {
+		Panicf("assignment mismatch: %d variables but %d values", len(m.Lefts), len(values))
+	}
+	for index, left := range m.Lefts {
+		if left, ok := left.(GetVarStatement); ok && left.Label == "_" {
+			continue
+		}
+		if m.Define {
+			m.VM.AllocObject(left.(GetVarStatement).Label).Pointer = values[index]
+			continue
+		}
+		AssignStatement{Left: left, Exp: values[index]}.Invoke()
+	}
+	return nil
+}
+
+func (m MultiAssignStatement) GetType() lexer.Type {
+	return lexer.MultiAssignStatementType
+}
+
+func (m MultiAssignStatement) String() string {
+	var lefts []string
+	for _, left := range m.Lefts {
+		lefts = append(lefts, left.String())
+	}
+	var op = " = "
+	if m.Define {
+		op = " := "
+	}
+	return strings.Join(lefts, ", ") + op + m.Exp.String()
+}
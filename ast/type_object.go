@@ -1,6 +1,10 @@
 package ast
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+
 	"gitlab.com/akzj/qp/lexer"
 	"gitlab.com/akzj/qp/runtime"
 )
@@ -14,33 +18,77 @@ type BaseObject interface {
 
 type TypeObjectPropTemplate struct {
 	Name string
-	Exp  runtime.Invokable
+	Type string // annotated type,"" for untyped
+	// Embedded is true for type embedded,`type Admin { User }`,Name and
+	// Type are the name of type embedded
+	Embedded bool
+	Exp      runtime.Invokable
 }
 
 func (t TypeObjectPropTemplate) String() string {
+	if t.Embedded {
+		return t.Name
+	}
+	if t.Type != "" {
+		return t.Name + " " + t.Type
+	}
 	return t.Name + ":" + t.Exp.String()
 }
 
 type TypeObject struct {
+	Pos
 	VM    *runtime.VMRuntime
 	Label string
 	//Init Statement when create objects
 	Init                    bool
 	TypeObjectPropTemplates []TypeObjectPropTemplate
+	// Strict reject fields no declared by TypeObjectPropTemplates,
+	// `type User strict {}`
+	Strict bool
 	//user define Function
 	objects map[string]*runtime.Object
 }
 
+// String show values of fields sorted by name,`User{age:1 name:bob}`,
+// methods are skipped
 func (sObj *TypeObject) String() string {
-	str := "type " + sObj.Label + "{"
-	for index, exp := range sObj.TypeObjectPropTemplates {
-		if index == 0 {
-			str += "\n"
-		}
-		str += "\t" + exp.String() + ";\n"
+	return sObj.format(map[*TypeObject]bool{})
+}
 
+// format print object,object printing already is shown as `User{...}`
+func (sObj *TypeObject) format(printing map[*TypeObject]bool) string {
+	if printing[sObj] {
+		return sObj.Label + "{...}"
+	}
+	printing[sObj] = true
+	defer delete(printing, sObj)
+	values := map[string]runtime.Invokable{}
+	for _, template := range sObj.TypeObjectPropTemplates {
+		values[template.Name] = template.Exp
 	}
-	return str + "}"
+	for name, object := range sObj.objects {
+		values[name] = unwrapObject(object.Pointer)
+	}
+	var names []string
+	for name, value := range values {
+		if _, ok := value.(Function); ok == false {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var items []string
+	for _, name := range names {
+		switch value := values[name].(type) {
+		case *TypeObject:
+			items = append(items, name+":"+value.format(printing))
+		case String:
+			// as strings are printed by println
+			items = append(items, name+":"+string(value))
+		default:
+			items = append(items, name+":"+value.String())
+		}
+	}
+	return sObj.Label + "{" + strings.Join(items, " ") + "}"
 }
 
 func (sObj *TypeObject) Invoke() runtime.Invokable {
@@ -67,6 +115,9 @@ func (sObj *TypeObject) AllocObject(label string) *runtime.Object {
 	if ok {
 		return object
 	} else {
+		if sObj.Strict && sObj.declared(label) == false {
+			Panicf("type %s has no field `%s`", sObj.Label, label)
+		}
 		object = &runtime.Object{
 			Pointer: NilObj,
 			Label:   label,
@@ -76,10 +127,51 @@ func (sObj *TypeObject) AllocObject(label string) *runtime.Object {
 	return object
 }
 
+// declared check label is field of TypeObjectPropTemplates
+func (sObj *TypeObject) declared(label string) bool {
+	for _, template := range sObj.TypeObjectPropTemplates {
+		if template.Name == label {
+			return true
+		}
+	}
+	return false
+}
+
+// ConstructorName is name of method called when object is created
+const ConstructorName = "init"
+
+// Constructor return method init of type,nil if no declared
+func (sObj *TypeObject) Constructor() *FuncExpression {
+	object := sObj.GetObject(ConstructorName)
+	if object == nil {
+		return nil
+	}
+	method, ok := unwrapObject(object.Pointer).(*FuncExpression)
+	if ok == false || method.IsMethod() == false {
+		return nil
+	}
+	return method
+}
+
+// ConstructorArity return the error of count of arguments passed to init
+// method,"" if it matches.`this` is the first parameter of method
+func ConstructorArity(statement ObjectInitStatement, method *FuncExpression) string {
+	switch count := len(method.Parameters) - 1; {
+	case len(statement.Arguments) < count:
+		return fmt.Sprintf("not enough arguments in call to %s.%s", statement.Exp.String(), ConstructorName)
+	case len(statement.Arguments) > count:
+		return fmt.Sprintf("too many arguments in call to %s.%s", statement.Exp.String(), ConstructorName)
+	}
+	return ""
+}
+
+// Clone make object of type,methods are copied to fields of the object,
+// so methods assigned to the object are not shared with other objects
 func (sObj *TypeObject) Clone() BaseObject {
 	clone := *sObj
+	clone.objects = nil
 	for k, v := range sObj.objects {
-		clone.AddObject(k, v)
+		clone.AddObject(k, &runtime.Object{Label: v.Label, Pointer: v.Pointer})
 	}
 	if len(sObj.TypeObjectPropTemplates) != 0 {
 		clone.TypeObjectPropTemplates = make([]TypeObjectPropTemplate, len(sObj.TypeObjectPropTemplates))
@@ -6,12 +6,17 @@ import (
 )
 
 type VarAssignStatement struct {
+	Pos
 	Ctx  *runtime.VMRuntime //global or stack var
 	Name string             //var Name : var a,`a` is the Name
+	Type string             // annotated type,"" for untyped : var a int = 1
 	Exp  runtime.Invokable  // Init Exp : var a = 1+1
 }
 
 func (statement VarAssignStatement) String() string {
+	if statement.Type != "" {
+		return "var " + statement.Name + " " + statement.Type + " = " + statement.Exp.String()
+	}
 	return "var " + statement.Name + " = " + statement.Exp.String()
 }
 
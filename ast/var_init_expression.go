@@ -6,6 +6,7 @@ import (
 )
 
 type VarInitExpression struct {
+	Pos
 	Ctx  *runtime.VMRuntime //global or stack var
 	Name string             //var Name : var a,`a` is the Name
 	Exp  runtime.Invokable  // Init Exp : a := 1+1
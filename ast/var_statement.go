@@ -6,13 +6,18 @@ import (
 )
 
 type VarStatement struct {
+	Pos
 	VM    *runtime.VMRuntime
 	Label string
+	Type  string // annotated type,"" for untyped
 	Exp   runtime.Invokable
 }
 
 func (v VarStatement) String() string {
-	return "var " + v.Label + " = " + v.Exp.String()
+	if v.Type != "" {
+		return "var " + v.Label + " " + v.Type
+	}
+	return "var " + v.Label
 }
 
 func (v VarStatement) Invoke() runtime.Invokable {
@@ -3,7 +3,6 @@ package builtin
 import (
 	"gitlab.com/akzj/qp/ast"
 	"gitlab.com/akzj/qp/runtime"
-	"log"
 )
 
 func registerArrayFunction() {
@@ -17,18 +16,18 @@ func registerArrayFunction() {
 		return ast.Int(len(arguments[0].Invoke().(*ast.Array).Data))
 	})("Get", func(arguments ...runtime.Invokable) runtime.Invokable {
 		if len(arguments) != 2 {
-			log.Panic("array Get() Arguments error")
+			ast.Panicln("array Get() Arguments error")
 		}
 		array, ok := arguments[0].Invoke().(*ast.Array)
 		if ok == false {
-			log.Panic("Exp not array type")
+			ast.Panicln("Exp not array type")
 		}
 		i, ok := arguments[1].(ast.Int)
 		if ok == false {
-			log.Panic("is not array Arguments error")
+			ast.Panicln("is not array Arguments error")
 		}
 		if len(array.Data) <= int(i) {
-			log.Panic("index out of range")
+			ast.Panicln("index out of range")
 		}
 		return array.Data[i]
 	})
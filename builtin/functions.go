@@ -4,8 +4,11 @@ import (
 	"fmt"
 	"gitlab.com/akzj/qp/ast"
 	"gitlab.com/akzj/qp/runtime"
-	"log"
+	"io"
+	"os"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -16,25 +19,142 @@ func init() {
 }
 
 func registerGlobalFunction() {
-	register(runtime.Functions, "println", func(arguments ...runtime.Invokable) runtime.Invokable {
+	register(runtime.Functions, "println", Println(nil))
+
+	register(runtime.Functions, "panic", func(arguments ...runtime.Invokable) runtime.Invokable {
+		if len(arguments) == 1 {
+			if err, ok := arguments[0].Invoke().(*ast.Error); ok {
+				panic(err)
+			}
+		}
+		var messages []string
+		for _, argument := range arguments {
+			messages = append(messages, format(argument))
+		}
+		panic(&ast.Error{Message: strings.Join(messages, " ")})
+	})
+
+	register(runtime.Functions, "now", func(arguments ...runtime.Invokable) runtime.Invokable {
+		return ast.TimeObject(time.Now())
+	})("len", func(arguments ...runtime.Invokable) runtime.Invokable {
+		if len(arguments) != 1 {
+			ast.Panicln("len() Arguments error")
+		}
+		switch object := arguments[0].Invoke().(type) {
+		case *ast.Map:
+			return ast.Int(object.Len())
+		case *ast.Array:
+			return ast.Int(len(object.Data))
+		case ast.String:
+			return ast.Int(len(object))
+		default:
+			ast.Panicf("len() unsupported type `%s`", reflect.TypeOf(object).String())
+		}
+		return nil
+	})("keys", func(arguments ...runtime.Invokable) runtime.Invokable {
+		object, ok := arguments[0].Invoke().(*ast.Map)
+		if len(arguments) != 1 || ok == false {
+			ast.Panicln("keys() Arguments error")
+		}
+		return &ast.Array{Data: object.Keys()}
+	})("delete", func(arguments ...runtime.Invokable) runtime.Invokable {
+		object, ok := arguments[0].Invoke().(*ast.Map)
+		if len(arguments) != 2 || ok == false {
+			ast.Panicln("delete() Arguments error")
+		}
+		object.Delete(arguments[1].Invoke())
+		return nil
+	})("int", func(arguments ...runtime.Invokable) runtime.Invokable {
+		if len(arguments) != 1 {
+			ast.Panicln("int() Arguments error")
+		}
+		switch object := arguments[0].Invoke().(type) {
+		case ast.Int:
+			return object
+		case ast.Float:
+			return ast.Int(object)
+		case ast.String:
+			val, err := strconv.ParseInt(string(object), 10, 64)
+			if err != nil {
+				ast.Panicf("int() invalid syntax `%s`", string(object))
+			}
+			return ast.Int(val)
+		default:
+			ast.Panicf("int() unsupported type `%s`", reflect.TypeOf(object).String())
+		}
+		return nil
+	})("float", func(arguments ...runtime.Invokable) runtime.Invokable {
+		if len(arguments) != 1 {
+			ast.Panicln("float() Arguments error")
+		}
+		switch object := arguments[0].Invoke().(type) {
+		case ast.Float:
+			return object
+		case ast.Int:
+			return ast.Float(object)
+		case ast.String:
+			val, err := strconv.ParseFloat(string(object), 64)
+			if err != nil {
+				ast.Panicf("float() invalid syntax `%s`", string(object))
+			}
+			return ast.Float(val)
+		default:
+			ast.Panicf("float() unsupported type `%s`", reflect.TypeOf(object).String())
+		}
+		return nil
+	})
+}
+
+// Println make println function which write to writer,os.Stdout when nil
+func Println(writer io.Writer) CallFunc {
+	return func(arguments ...runtime.Invokable) runtime.Invokable {
+		var writer = writer
+		if writer == nil {
+			writer = os.Stdout
+		}
 		for index, argument := range arguments {
-			if argument == nil{
+			if argument == nil {
 				panic("argument")
 			}
-			if stringer, ok := argument.(fmt.Stringer); ok {
-				fmt.Print(stringer)
-			} else {
-				log.Panicf("unknown type `%s`", reflect.TypeOf(argument).String())
-			}
+			fmt.Fprint(writer, format(argument))
 			if index != len(arguments)-1 {
-				fmt.Print(" ")
+				fmt.Fprint(writer, " ")
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(writer)
 		return nil
-	})
+	}
+}
 
-	register(runtime.Functions, "now", func(arguments ...runtime.Invokable) runtime.Invokable {
-		return ast.TimeObject(time.Now())
-	})
+// format make the text of value printed,strings are unquoted
+// as they are printed by stack machine
+func format(value runtime.Invokable) string {
+	for {
+		object, ok := value.(*runtime.Object)
+		if ok == false {
+			break
+		}
+		value = object.Pointer
+	}
+	switch value := value.(type) {
+	case ast.String:
+		return string(value)
+	case *ast.Array:
+		var items []string
+		for _, element := range value.Data {
+			items = append(items, format(element))
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	case *ast.Map:
+		var items []string
+		for _, key := range value.Keys() {
+			items = append(items, format(key)+":"+format(value.Get(key)))
+		}
+		return "map[" + strings.Join(items, " ") + "]"
+	case fmt.Stringer:
+		return value.String()
+	default:
+		ast.Panicf("unknown type `%s`", reflect.TypeOf(value).String())
+	}
+	return ""
 }
@@ -19,18 +19,23 @@ type RegisterBuiltInFuncHelper func(name string, callFunc CallFunc) RegisterBuil
 func register(funcObjectMap FuncObjectMap, name string, callFunc CallFunc) RegisterBuiltInFuncHelper {
 	var helper RegisterBuiltInFuncHelper
 	helper = func(name string, callFunc CallFunc) RegisterBuiltInFuncHelper {
-		funcObjectMap[name] = &runtime.Object{
-			Label: name,
-			Pointer: &funcWrap{
-				name:     name,
-				callFunc: callFunc,
-			},
-		}
+		funcObjectMap[name] = NewFunction(name, callFunc)
 		return helper
 	}
 	return helper(name, callFunc)
 }
 
+// NewFunction wrap callFunc as built in function object with name
+func NewFunction(name string, callFunc CallFunc) *runtime.Object {
+	return &runtime.Object{
+		Label: name,
+		Pointer: &funcWrap{
+			name:     name,
+			callFunc: callFunc,
+		},
+	}
+}
+
 func (b *funcWrap) Call(arguments ...runtime.Invokable) runtime.Invokable {
 	return b.callFunc(arguments...)
 }
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	stackmachine "gitlab.com/akzj/qp/stack-machine"
+)
+
+const buildUsage = "build file.qp [-o file.qpc]"
+
+func buildCommand(args []string) int {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	output := flags.String("o", "", "output file,the source file with .qpc extension by default")
+	args = parseFlags(flags, args)
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: qp "+buildUsage)
+		return 1
+	}
+	file := args[0]
+	if *output == "" {
+		*output = strings.TrimSuffix(file, ".qp") + ".qpc"
+	}
+	statements, p, ok := parseFile(file)
+	if ok == false {
+		return 1
+	}
+	var generator *stackmachine.CodeGenerator
+	if code := execute(func() error {
+		generator = gen(statements, p)
+		return nil
+	}); code != 0 {
+		return code
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	_, err = generator.WriteTo(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		_ = os.Remove(*output)
+		return 1
+	}
+	return 0
+}
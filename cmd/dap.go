@@ -0,0 +1,28 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gitlab.com/akzj/qp/dap"
+	"gitlab.com/akzj/qp/parser"
+)
+
+const dapUsage = "dap"
+
+// dapCommand serve Debug Adapter Protocol over stdio,output of program is
+// sent as output event
+func dapCommand(args []string) int {
+	flags := flag.NewFlagSet("dap", flag.ExitOnError)
+	args = parseFlags(flags, args)
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: qp "+dapUsage)
+		return 1
+	}
+	if err := dap.NewServer(os.Stdin, os.Stdout, parser.DefaultPath()).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	stackmachine "gitlab.com/akzj/qp/stack-machine"
+)
+
+const debugUsage = "debug file.qp"
+
+func debugCommand(args []string) int {
+	flags := flag.NewFlagSet("debug", flag.ExitOnError)
+	args = parseFlags(flags, args)
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: qp "+debugUsage)
+		return 1
+	}
+	statements, p, ok := parseFile(args[0])
+	if ok == false {
+		return 1
+	}
+	return execute(func() error {
+		m := stackmachine.NewMachine(gen(statements, p), stackmachine.Options{})
+		fmt.Println("help show commands of debugger")
+		exited, err := stackmachine.NewDebugger(m, os.Stdin, os.Stdout).Run()
+		if exited && err == nil {
+			fmt.Println("program exited")
+		}
+		return err
+	})
+}
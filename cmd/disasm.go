@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	stackmachine "gitlab.com/akzj/qp/stack-machine"
+)
+
+const disasmUsage = "disasm file.qp|file.qpc"
+
+func disasmCommand(args []string) int {
+	flags := flag.NewFlagSet("disasm", flag.ExitOnError)
+	args = parseFlags(flags, args)
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: qp "+disasmUsage)
+		return 1
+	}
+	file := args[0]
+	var generator *stackmachine.CodeGenerator
+	if filepath.Ext(file) == ".qpc" {
+		var ok bool
+		if generator, ok = loadBytecode(file); ok == false {
+			return 1
+		}
+	} else {
+		statements, p, ok := parseFile(file)
+		if ok == false {
+			return 1
+		}
+		if code := execute(func() error {
+			generator = gen(statements, p)
+			return nil
+		}); code != 0 {
+			return code
+		}
+	}
+	if err := generator.Disassemble(os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"gitlab.com/akzj/qp/format"
+)
+
+const fmtUsage = "fmt [-w] [-d] file.qp..."
+
+func fmtCommand(args []string) int {
+	flags := flag.NewFlagSet("fmt", flag.ExitOnError)
+	write := flags.Bool("w", false, "write result to source file")
+	diff := flags.Bool("d", false, "show diff of formatting")
+	args = parseFlags(flags, args)
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: qp "+fmtUsage)
+		return 1
+	}
+	code := 0
+	for _, file := range args {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			code = 1
+			continue
+		}
+		result, err := format.Source(file, src)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			code = 1
+			continue
+		}
+		if *diff {
+			os.Stdout.Write(format.Diff(file, src, result))
+		}
+		if *write {
+			if bytes.Equal(src, result) == false {
+				if err := ioutil.WriteFile(file, result, 0644); err != nil {
+					fmt.Fprintln(os.Stderr, err.Error())
+					code = 1
+				}
+			}
+		} else if *diff == false {
+			os.Stdout.Write(result)
+		}
+	}
+	return code
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gitlab.com/akzj/qp/lsp"
+	"gitlab.com/akzj/qp/parser"
+)
+
+const lspUsage = "lsp"
+
+// lspCommand serve Language Server Protocol over stdio
+func lspCommand(args []string) int {
+	flags := flag.NewFlagSet("lsp", flag.ExitOnError)
+	args = parseFlags(flags, args)
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: qp "+lspUsage)
+		return 1
+	}
+	if err := lsp.NewServer(os.Stdin, os.Stdout, parser.DefaultPath()).Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return 1
+	}
+	return 0
+}
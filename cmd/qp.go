@@ -3,25 +3,71 @@ package main
 import (
 	"flag"
 	"fmt"
-	"gitlab.com/akzj/qp/parser"
-	"io/ioutil"
 	"os"
+	"sort"
 )
 
+// command is sub command of qp,the exit code is returned
+type command struct {
+	usage string
+	run   func(args []string) int
+}
+
+var commands = map[string]command{
+	"run":    {usage: runUsage, run: runCommand},
+	"build":  {usage: buildUsage, run: buildCommand},
+	"disasm": {usage: disasmUsage, run: disasmCommand},
+	"repl":   {usage: replUsage, run: replCommand},
+	"debug":  {usage: debugUsage, run: debugCommand},
+	"dap":    {usage: dapUsage, run: dapCommand},
+	"lsp":    {usage: lspUsage, run: lspCommand},
+	"fmt":    {usage: fmtUsage, run: fmtCommand},
+	"vet":    {usage: vetUsage, run: vetCommand},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: qp <command> [arguments]")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "\tqp "+commands[name].usage)
+	}
+}
+
+// parseFlags parse flags of args,flags may follow the positional arguments,
+// the positional arguments are returned
+func parseFlags(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
 func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+	if command, ok := commands[os.Args[1]]; ok {
+		os.Exit(command.run(os.Args[2:]))
+	}
+
+	// qp --file example.qp
 	file := flag.String("file", "", "--file example.qp")
+	flag.Usage = usage
 	flag.Parse()
-
 	if *file == "" {
-		fmt.Println("require source file to execute")
-		os.Exit(1)
-		return
-	}
-	data, err := ioutil.ReadFile(*file)
-	if err != nil {
-		fmt.Println(err.Error())
+		usage()
 		os.Exit(1)
-		return
 	}
-	parser.New(string(data)).Parse().Invoke()
+	os.Exit(runCommand([]string{*file}))
 }
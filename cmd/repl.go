@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/parser"
+	"gitlab.com/akzj/qp/runtime"
+	stackmachine "gitlab.com/akzj/qp/stack-machine"
+)
+
+const replUsage = "repl"
+
+const replHelp = `statements are run on tree engine,value of expression is printed
+input goes on while { ( [ are no closed
+commands:
+	:help          show this help
+	:type expr     show type of value of expr,expr is run with its side effects
+	:disasm src    show instructions of src on vm
+	:history       show history of input
+	:quit          exit repl`
+
+const (
+	replPrompt         = "qp> "
+	replContinuePrompt = "... "
+	maxHistory         = 1000
+)
+
+func replCommand(args []string) int {
+	flags := flag.NewFlagSet("repl", flag.ExitOnError)
+	args = parseFlags(flags, args)
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: qp "+replUsage)
+		return 1
+	}
+	repl := newREPL(os.Stdout)
+	if home, err := os.UserHomeDir(); err == nil {
+		repl.loadHistory(filepath.Join(home, ".qp_history"))
+	}
+	repl.run(os.Stdin)
+	return 0
+}
+
+// repl keep globals,functions,types and imported modules of input
+type repl struct {
+	out         io.Writer
+	vm          *runtime.VMRuntime
+	parser      *parser.Parser
+	history     []string
+	historyFile string
+}
+
+func newREPL(out io.Writer) *repl {
+	vm := runtime.New()
+	return &repl{
+		out:    out,
+		vm:     vm,
+		parser: parser.NewWithVM("", vm).SetFile("<repl>"),
+	}
+}
+
+// loadHistory read history from file,input is appended to it
+func (r *repl) loadHistory(file string) {
+	r.historyFile = file
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if input, err := strconv.Unquote(line); err == nil {
+			r.history = append(r.history, input)
+		}
+	}
+	if len(r.history) > maxHistory {
+		r.history = r.history[len(r.history)-maxHistory:]
+	}
+}
+
+func (r *repl) addHistory(input string) {
+	r.history = append(r.history, input)
+	if r.historyFile == "" {
+		return
+	}
+	f, err := os.OpenFile(r.historyFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, strconv.Quote(input))
+}
+
+func (r *repl) run(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	var input string
+	for {
+		if input == "" {
+			fmt.Fprint(r.out, replPrompt)
+		} else {
+			fmt.Fprint(r.out, replContinuePrompt)
+		}
+		if scanner.Scan() == false {
+			fmt.Fprintln(r.out)
+			return
+		}
+		line := scanner.Text()
+		if input == "" && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			line = strings.TrimSpace(line)
+			r.addHistory(line)
+			if r.command(line) == false {
+				return
+			}
+			continue
+		}
+		input += line + "\n"
+		if parser.Incomplete(input) {
+			continue
+		}
+		src := strings.TrimSpace(input)
+		input = ""
+		if src == "" {
+			continue
+		}
+		r.addHistory(src)
+		r.eval(src)
+	}
+}
+
+// command run :command,false is returned for :quit
+func (r *repl) command(line string) bool {
+	name, arg := line, ""
+	if index := strings.IndexAny(line, " \t"); index != -1 {
+		name, arg = line[:index], strings.TrimSpace(line[index:])
+	}
+	switch name {
+	case ":help":
+		fmt.Fprintln(r.out, replHelp)
+	case ":quit", ":q", ":exit":
+		return false
+	case ":history":
+		for index, input := range r.history {
+			fmt.Fprintf(r.out, "%4d  %s\n", index+1, strings.ReplaceAll(input, "\n", "\n      "))
+		}
+	case ":type":
+		statements, ok := r.parse(arg)
+		if ok == false {
+			return true
+		}
+		if value, ok := r.invoke(statements); ok {
+			fmt.Fprintln(r.out, typeName(value))
+		}
+	case ":disasm":
+		statements, ok := r.parse(arg)
+		if ok == false {
+			return true
+		}
+		r.disasm(statements)
+	default:
+		fmt.Fprintf(r.out, "unknown command `%s`,:help show commands\n", name)
+	}
+	return true
+}
+
+// parse src as statements,or as expression when it is no statement
+func (r *repl) parse(src string) (ast.Expressions, bool) {
+	statements, errs := r.parser.ParseMore(src)
+	if len(errs) == 0 {
+		return statements, true
+	}
+	if statements, expErrs := r.parser.ParseMore("return " + src); len(expErrs) == 0 {
+		return statements, true
+	}
+	for _, err := range errs {
+		fmt.Fprintln(r.out, err.Error())
+	}
+	return nil, false
+}
+
+// eval run src,value of expression statement is printed
+func (r *repl) eval(src string) {
+	statements, ok := r.parse(src)
+	if ok == false || len(statements) == 0 {
+		return
+	}
+	value, ok := r.invoke(statements)
+	if ok == false || isExpression(statements[len(statements)-1]) == false {
+		return
+	}
+	switch value := value.(type) {
+	case nil, ast.NilObject:
+	case ast.String:
+		fmt.Fprintln(r.out, strconv.Quote(string(value)))
+	default:
+		fmt.Fprintln(r.out, value.String())
+	}
+}
+
+// invoke run statements,the stack is reset when runtime error raised
+func (r *repl) invoke(statements ast.Expressions) (value runtime.Invokable, ok bool) {
+	mark := r.vm.Mark()
+	defer func() {
+		if err := recover(); err != nil {
+			r.vm.Reset(mark)
+			fmt.Fprintln(r.out, runtimeErrorMessage(err))
+			value, ok = nil, false
+		}
+	}()
+	value = statements.Invoke()
+	if ret, ok := value.(ast.ReturnStatement); ok {
+		value = ret.Val
+	}
+	for {
+		object, ok := value.(*runtime.Object)
+		if ok == false {
+			return value, true
+		}
+		value = object.Pointer
+	}
+}
+
+// disasm generate statements with functions and types of repl,globals of
+// repl are invisible to vm
+func (r *repl) disasm(statements ast.Expressions) {
+	defer func() {
+		if err := recover(); err != nil {
+			fmt.Fprintln(r.out, "disasm error:", err)
+		}
+	}()
+	for _, object := range r.vm.Objects() {
+		statements = append(statements, object)
+	}
+	if err := stackmachine.NewCodeGenerator().Gen(statements).Disassemble(r.out); err != nil {
+		fmt.Fprintln(r.out, err.Error())
+	}
+}
+
+// isExpression check statement is expression whose value is printed
+func isExpression(statement ast.Expression) bool {
+	switch statement.(type) {
+	case ast.VarStatement,
+		ast.VarAssignStatement,
+		ast.VarInitExpression,
+		ast.AssignStatement,
+		ast.MultiAssignStatement,
+		ast.IncFieldStatement,
+		ast.IfExpression,
+		ast.ForExpression,
+		ast.TryStatement,
+		ast.ImportStatement,
+		ast.NopStatement:
+		return false
+	}
+	return true
+}
+
+func typeName(value runtime.Invokable) string {
+	switch value := value.(type) {
+	case nil, ast.NilObject:
+		return "nil"
+	case ast.Int:
+		return "int"
+	case ast.Float:
+		return "float"
+	case ast.String:
+		return "string"
+	case ast.Bool:
+		return "bool"
+	case *ast.Array:
+		return "array"
+	case *ast.Map:
+		return "map"
+	case *ast.TypeObject:
+		return value.Label
+	case *ast.Error:
+		return "error"
+	case ast.TimeObject:
+		return "time"
+	case ast.DurationObject:
+		return "duration"
+	case ast.Tuple:
+		var types []string
+		for _, it := range value {
+			types = append(types, typeName(it))
+		}
+		return "(" + strings.Join(types, ", ") + ")"
+	case ast.Function:
+		return "func"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestREPL(t *testing.T) {
+	history := filepath.Join(t.TempDir(), "history")
+	var out bytes.Buffer
+	r := newREPL(&out)
+	r.loadHistory(history)
+	inputs := []string{
+		"func add(a, b) {",
+		"\treturn a + b",
+		"}",
+		"add(1, 2)",
+		":type add(1, 2.5)",
+		":disasm add(3, 4)",
+		"var m = [1, 2]",
+		"for i := 0; i < 1; i++ {",
+		"\tprintln(m[9])",
+		"}",
+		"i",
+		"m[1]",
+		":quit",
+	}
+	r.run(strings.NewReader(strings.Join(inputs, "\n")))
+	for _, expect := range []string{
+		"qp> ... ... qp> 3\n",
+		"qp> float\n",
+		"add:\n",
+		"runtime error: <repl>:2:11: index 9 out of range [0:2]\n",
+		"qp> runtime error: <repl>:1:8: undefined `i`\n", // stack of loop is reset
+		"qp> 2\nqp> ",
+	} {
+		if strings.Contains(out.String(), expect) == false {
+			t.Fatalf("expect %q in output\n%s", expect, out.String())
+		}
+	}
+
+	out.Reset()
+	r = newREPL(&out)
+	r.loadHistory(history)
+	r.run(strings.NewReader(":history"))
+	for _, expect := range []string{
+		"   1  func add(a, b) {\n      \treturn a + b\n      }\n",
+		"   3  :type add(1, 2.5)\n",
+		"   9  :quit\n",
+	} {
+		if strings.Contains(out.String(), expect) == false {
+			t.Fatalf("expect %q in history\n%s", expect, out.String())
+		}
+	}
+}
+
+func TestRuntimeErrorMessage(t *testing.T) {
+	var goError error
+	func() {
+		defer func() {
+			goError = recover().(error)
+		}()
+		var values []int
+		_ = values[len(values)]
+	}()
+	for r, expect := range map[interface{}]string{
+		goError:                   "runtime error: index out of range [0] with length 0",
+		"boom":                    "runtime error: boom",
+		errors.New("host failed"): "runtime error: host failed",
+	} {
+		if message := runtimeErrorMessage(r); message != expect {
+			t.Fatalf("expect %s,got %s", expect, message)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/parser"
+	stackmachine "gitlab.com/akzj/qp/stack-machine"
+	"gitlab.com/akzj/qp/types"
+)
+
+const runUsage = "run [--engine=vm|tree] file.qp|file.qpc"
+
+func runCommand(args []string) int {
+	flags := flag.NewFlagSet("run", flag.ExitOnError)
+	engine := flags.String("engine", "vm", "execution engine, vm or tree")
+	args = parseFlags(flags, args)
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: qp "+runUsage)
+		return 1
+	}
+	if *engine != "vm" && *engine != "tree" {
+		fmt.Fprintf(os.Stderr, "unknown engine `%s`\n", *engine)
+		return 1
+	}
+	file := args[0]
+	if filepath.Ext(file) == ".qpc" {
+		if *engine != "vm" {
+			fmt.Fprintln(os.Stderr, "bytecode file can only run on vm engine")
+			return 1
+		}
+		generator, ok := loadBytecode(file)
+		if ok == false {
+			return 1
+		}
+		return execute(func() error {
+			return stackmachine.NewMachine(generator, stackmachine.Options{}).Run()
+		})
+	}
+	statements, p, ok := parseFile(file)
+	if ok == false {
+		return 1
+	}
+	return execute(func() error {
+		if *engine == "tree" {
+			statements.Invoke()
+			return nil
+		}
+		return stackmachine.NewMachine(gen(statements, p), stackmachine.Options{}).Run()
+	})
+}
+
+// gen generate code of statements for vm
+func gen(statements ast.Expressions, p *parser.Parser) *stackmachine.CodeGenerator {
+	for _, object := range p.GetVMContext().Objects() {
+		statements = append(statements, object)
+	}
+	return stackmachine.NewCodeGenerator().Gen(statements)
+}
+
+// loadBytecode load .qpc file,errors are printed to stderr
+func loadBytecode(file string) (*stackmachine.CodeGenerator, bool) {
+	f, err := os.Open(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil, false
+	}
+	defer f.Close()
+	generator, err := stackmachine.LoadBytecode(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", file, err.Error())
+		return nil, false
+	}
+	return generator, true
+}
+
+// parseFile parse source file,syntax errors are printed to stderr
+func parseFile(file string) (ast.Expressions, *parser.Parser, bool) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		return nil, nil, false
+	}
+	p := parser.New(string(data)).SetFile(file)
+	statements, errs := p.ParseWithErrors()
+	if len(errs) != 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		return nil, nil, false
+	}
+	if errs := types.Check(p, statements); len(errs) != 0 {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, err.Error())
+		}
+		return nil, nil, false
+	}
+	return statements, p, true
+}
+
+// execute call fn,the error returned and panic of script are printed as
+// runtime error
+func execute(fn func() error) (code int) {
+	defer func() {
+		if r := recover(); r != nil {
+			printRuntimeError(r)
+			code = 2
+		}
+	}()
+	if err := fn(); err != nil {
+		printRuntimeError(err)
+		return 2
+	}
+	return 0
+}
+
+// printRuntimeError print r as runtime error to stderr
+func printRuntimeError(r interface{}) {
+	fmt.Fprintln(os.Stderr, runtimeErrorMessage(r))
+}
+
+// runtimeErrorMessage return r prefixed by `runtime error:`,message of
+// panic of Go runtime is prefixed already
+func runtimeErrorMessage(r interface{}) string {
+	var goError goruntime.Error
+	if err, ok := r.(error); ok && errors.As(err, &goError) {
+		return err.Error()
+	}
+	return fmt.Sprint("runtime error: ", r)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"gitlab.com/akzj/qp/vet"
+)
+
+const vetUsage = "vet [-json] file.qp..."
+
+func vetCommand(args []string) int {
+	flags := flag.NewFlagSet("vet", flag.ExitOnError)
+	jsonOutput := flags.Bool("json", false, "print diagnostics as JSON array")
+	args = parseFlags(flags, args)
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: qp "+vetUsage)
+		return 1
+	}
+	code := 0
+	diagnostics := []vet.Diagnostic{}
+	for _, file := range args {
+		src, err := ioutil.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			code = 1
+			continue
+		}
+		diagnostics = append(diagnostics, vet.Source(file, src)...)
+	}
+	if len(diagnostics) != 0 {
+		code = 1
+	}
+	if *jsonOutput {
+		data, _ := json.MarshalIndent(diagnostics, "", "\t")
+		fmt.Println(string(data))
+		return code
+	}
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintln(os.Stderr, diagnostic.String())
+	}
+	return code
+}
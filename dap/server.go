@@ -0,0 +1,463 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/internal/framing"
+	"gitlab.com/akzj/qp/parser"
+	stackmachine "gitlab.com/akzj/qp/stack-machine"
+	"gitlab.com/akzj/qp/types"
+)
+
+// threadID is the only thread of qp program
+const threadID = 1
+
+// Server is debug adapter running qp program on stack machine,requests
+// are read from in and responses and events are written to out
+type Server struct {
+	in   *bufio.Reader
+	out  io.Writer
+	path []string // module search path
+
+	mutex    sync.Mutex // guard fields below,they are shared with machine
+	seq      int
+	debugger *stackmachine.Debugger
+	paused   bool
+	refs     [][]stackmachine.Variable // variables of variablesReference-1
+	done     bool
+
+	sourceBreakpoints map[string][]int // ids of breakpoints of source
+	functionBreaks    []int
+	resume            chan stackmachine.Resume
+}
+
+// NewServer make Server,modules imported by program are searched in path
+func NewServer(in io.Reader, out io.Writer, path []string) *Server {
+	return &Server{
+		in:                bufio.NewReader(in),
+		out:               out,
+		path:              path,
+		sourceBreakpoints: map[string][]int{},
+		resume:            make(chan stackmachine.Resume),
+	}
+}
+
+// Serve handle requests until disconnect or in closed
+func (s *Server) Serve() error {
+	for {
+		content, err := framing.ReadMessage(s.in)
+		if err == io.EOF {
+			s.stop()
+			return nil
+		} else if err != nil {
+			s.stop()
+			return err
+		}
+		var request Request
+		if err := json.Unmarshal(content, &request); err != nil {
+			return fmt.Errorf("invalid message: %s", err.Error())
+		}
+		if request.Type != "request" {
+			continue
+		}
+		if s.handle(request) == false {
+			return nil
+		}
+	}
+}
+
+// handle reply request,false is returned for disconnect
+func (s *Server) handle(request Request) bool {
+	var body interface{}
+	var err error
+	switch request.Command {
+	case "initialize":
+		body = map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+			"supportsFunctionBreakpoints":      true,
+			"supportsTerminateRequest":         true,
+		}
+	case "launch":
+		if err = s.launch(request.Arguments); err == nil {
+			s.reply(request, nil, nil)
+			s.event("initialized", nil)
+			return true
+		}
+	case "setBreakpoints":
+		body, err = s.setBreakpoints(request.Arguments)
+	case "setFunctionBreakpoints":
+		body, err = s.setFunctionBreakpoints(request.Arguments)
+	case "setExceptionBreakpoints":
+	case "configurationDone":
+		if err = s.checkLaunched(); err == nil {
+			s.reply(request, nil, nil)
+			go s.run()
+			return true
+		}
+	case "threads":
+		body = map[string]interface{}{"threads": []thread{{ID: threadID, Name: "main"}}}
+	case "stackTrace":
+		body, err = s.stackTrace(request.Arguments)
+	case "scopes":
+		body, err = s.scopes(request.Arguments)
+	case "variables":
+		body, err = s.variables(request.Arguments)
+	case "continue":
+		if err = s.continueWith(request, stackmachine.ResumeContinue); err == nil {
+			return true
+		}
+	case "next":
+		if err = s.continueWith(request, stackmachine.ResumeStepOver); err == nil {
+			return true
+		}
+	case "stepIn":
+		if err = s.continueWith(request, stackmachine.ResumeStepInto); err == nil {
+			return true
+		}
+	case "stepOut":
+		if err = s.continueWith(request, stackmachine.ResumeStepOut); err == nil {
+			return true
+		}
+	case "pause":
+		if err = s.checkLaunched(); err == nil {
+			s.debugger.Pause()
+		}
+	case "terminate":
+		s.stop()
+	case "disconnect":
+		s.stop()
+		s.reply(request, nil, nil)
+		return false
+	default:
+		err = fmt.Errorf("unsupported request `%s`", request.Command)
+	}
+	s.reply(request, body, err)
+	return true
+}
+
+func (s *Server) launch(arguments json.RawMessage) error {
+	var args launchArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return err
+	}
+	if args.Program == "" {
+		return fmt.Errorf("no program to launch")
+	}
+	if s.debugger != nil {
+		return fmt.Errorf("program is launched")
+	}
+	program, err := filepath.Abs(args.Program)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(program)
+	if err != nil {
+		return err
+	}
+	p := parser.New(string(data)).SetFile(program).SetImporter(parser.NewImporter(s.path))
+	statements, errs := p.ParseWithErrors()
+	if len(errs) != 0 {
+		return errs[0]
+	}
+	if errs := types.Check(p, statements); len(errs) != 0 {
+		return errs[0]
+	}
+	for _, object := range p.GetVMContext().Objects() {
+		statements = append(statements, object)
+	}
+	gen, err := generate(statements)
+	if err != nil {
+		return err
+	}
+	m := stackmachine.NewMachine(gen, stackmachine.Options{Stdout: output{s: s, category: "stdout"}})
+	s.mutex.Lock()
+	s.debugger = stackmachine.AttachDebugger(m, args.StopOnEntry, s.pause)
+	s.mutex.Unlock()
+	return nil
+}
+
+// generate gen code of statements,panic of code generator is returned
+func generate(statements ast.Expressions) (gen *stackmachine.CodeGenerator, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return stackmachine.NewCodeGenerator().Gen(statements), nil
+}
+
+// run run program until exit,it is called after configuration done
+func (s *Server) run() {
+	exited, err := s.debugger.Run()
+	code := 0
+	if err != nil {
+		// message of panic of Go runtime is prefixed already
+		message := "runtime error: " + err.Error()
+		var goError runtime.Error
+		if errors.As(err, &goError) {
+			message = err.Error()
+		}
+		s.event("output", map[string]interface{}{
+			"category": "stderr",
+			"output":   message + "\n",
+		})
+		code = 2
+	}
+	s.mutex.Lock()
+	s.done = true
+	s.mutex.Unlock()
+	if exited {
+		s.event("exited", map[string]interface{}{"exitCode": code})
+	}
+	s.event("terminated", nil)
+}
+
+// pause is called by the machine paused,it is blocked until client
+// resume the machine
+func (s *Server) pause(d *stackmachine.Debugger) stackmachine.Resume {
+	s.mutex.Lock()
+	s.paused = true
+	s.refs = nil
+	s.mutex.Unlock()
+	s.event("stopped", map[string]interface{}{
+		"reason":            d.Reason(),
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+	return <-s.resume
+}
+
+// stop stop the program,it is paused or running
+func (s *Server) stop() {
+	s.mutex.Lock()
+	debugger, paused, done := s.debugger, s.paused, s.done
+	s.paused = false
+	s.mutex.Unlock()
+	if debugger == nil || done {
+		return
+	}
+	if paused {
+		s.resume <- stackmachine.ResumeStop
+	} else {
+		debugger.Stop()
+	}
+}
+
+// continueWith reply request and resume the paused machine
+func (s *Server) continueWith(request Request, resume stackmachine.Resume) error {
+	if err := s.checkPaused(); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	s.paused = false
+	s.mutex.Unlock()
+	if resume == stackmachine.ResumeContinue {
+		s.reply(request, map[string]interface{}{"allThreadsContinued": true}, nil)
+	} else {
+		s.reply(request, nil, nil)
+	}
+	s.resume <- resume
+	return nil
+}
+
+func (s *Server) checkLaunched() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.debugger == nil {
+		return fmt.Errorf("program is no launched")
+	}
+	return nil
+}
+
+func (s *Server) checkPaused() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.paused == false {
+		return fmt.Errorf("program is no paused")
+	}
+	return nil
+}
+
+func (s *Server) setBreakpoints(arguments json.RawMessage) (interface{}, error) {
+	var args setBreakpointsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.checkLaunched(); err != nil {
+		return nil, err
+	}
+	for _, id := range s.sourceBreakpoints[args.Source.Path] {
+		s.debugger.Delete(id)
+	}
+	var ids []int
+	breakpoints := []breakpoint{}
+	for _, it := range args.Breakpoints {
+		spec := args.Source.Path + ":" + strconv.Itoa(it.Line)
+		b, err := s.debugger.Break(spec)
+		if err != nil {
+			breakpoints = append(breakpoints, breakpoint{Line: it.Line, Message: err.Error()})
+			continue
+		}
+		ids = append(ids, b.ID)
+		breakpoints = append(breakpoints, breakpoint{
+			ID:       b.ID,
+			Verified: true,
+			Line:     it.Line,
+			Source:   &args.Source,
+		})
+	}
+	s.sourceBreakpoints[args.Source.Path] = ids
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+func (s *Server) setFunctionBreakpoints(arguments json.RawMessage) (interface{}, error) {
+	var args setFunctionBreakpointsArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.checkLaunched(); err != nil {
+		return nil, err
+	}
+	for _, id := range s.functionBreaks {
+		s.debugger.Delete(id)
+	}
+	s.functionBreaks = nil
+	breakpoints := []breakpoint{}
+	for _, it := range args.Breakpoints {
+		b, err := s.debugger.Break(it.Name)
+		if err != nil {
+			breakpoints = append(breakpoints, breakpoint{Message: err.Error()})
+			continue
+		}
+		s.functionBreaks = append(s.functionBreaks, b.ID)
+		breakpoints = append(breakpoints, breakpoint{ID: b.ID, Verified: true})
+	}
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+func (s *Server) stackTrace(arguments json.RawMessage) (interface{}, error) {
+	var args stackTraceArguments
+	if len(arguments) != 0 {
+		if err := json.Unmarshal(arguments, &args); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.checkPaused(); err != nil {
+		return nil, err
+	}
+	frames := []stackFrame{}
+	trace := s.debugger.Stack()
+	for index, frame := range trace {
+		if index < args.StartFrame || args.Levels > 0 && len(frames) >= args.Levels {
+			continue
+		}
+		it := stackFrame{ID: index, Name: frame.Function, Line: frame.Line, Column: 1}
+		if frame.File != "" {
+			it.Source = &source{Name: filepath.Base(frame.File), Path: frame.File}
+		}
+		frames = append(frames, it)
+	}
+	return map[string]interface{}{"stackFrames": frames, "totalFrames": len(trace)}, nil
+}
+
+func (s *Server) scopes(arguments json.RawMessage) (interface{}, error) {
+	var args scopesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.checkPaused(); err != nil {
+		return nil, err
+	}
+	locals := s.reference(s.debugger.Locals(args.FrameID))
+	return map[string]interface{}{"scopes": []scope{{Name: "Locals", VariablesReference: locals}}}, nil
+}
+
+func (s *Server) variables(arguments json.RawMessage) (interface{}, error) {
+	var args variablesArguments
+	if err := json.Unmarshal(arguments, &args); err != nil {
+		return nil, err
+	}
+	if err := s.checkPaused(); err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	if args.VariablesReference < 1 || args.VariablesReference > len(s.refs) {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("invalid variablesReference %d", args.VariablesReference)
+	}
+	values := s.refs[args.VariablesReference-1]
+	s.mutex.Unlock()
+	variables := []variable{}
+	for _, value := range values {
+		it := variable{Name: value.Name, Value: value.Value.Describe(), Type: value.Value.TypeName()}
+		if value.Closure {
+			it.Name += " (closure)"
+		}
+		if fields := value.Value.Fields(); len(fields) != 0 {
+			it.VariablesReference = s.reference(fields)
+		}
+		variables = append(variables, it)
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
+
+// reference return variablesReference of variables,references are valid
+// until the machine resumed
+func (s *Server) reference(variables []stackmachine.Variable) int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.refs = append(s.refs, variables)
+	return len(s.refs)
+}
+
+func (s *Server) reply(request Request, body interface{}, err error) {
+	response := Response{
+		Type:       "response",
+		RequestSeq: request.Seq,
+		Success:    err == nil,
+		Command:    request.Command,
+		Body:       body,
+	}
+	if err != nil {
+		response.Message = err.Error()
+	}
+	s.send(func(seq int) interface{} {
+		response.Seq = seq
+		return response
+	})
+}
+
+func (s *Server) event(name string, body interface{}) {
+	s.send(func(seq int) interface{} {
+		return Event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+}
+
+// send write message made with the next seq
+func (s *Server) send(message func(seq int) interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.seq++
+	_ = framing.WriteMessage(s.out, message(s.seq))
+}
+
+// output is the stdout of program,written as output event
+type output struct {
+	s        *Server
+	category string
+}
+
+func (o output) Write(p []byte) (int, error) {
+	o.s.event("output", map[string]interface{}{"category": o.category, "output": string(p)})
+	return len(p), nil
+}
@@ -0,0 +1,230 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/akzj/qp/internal/framing"
+)
+
+// client is scripted DAP client of Server
+type client struct {
+	t      *testing.T
+	in     io.WriteCloser
+	out    *bufio.Reader
+	seq    int
+	output strings.Builder // output events
+}
+
+// message is response or event read by client
+type message struct {
+	Type       string          `json:"type"`
+	Command    string          `json:"command"`
+	Event      string          `json:"event"`
+	RequestSeq int             `json:"request_seq"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Body       json.RawMessage `json:"body"`
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	go func() {
+		_ = NewServer(serverIn, serverOut, nil).Serve()
+		serverOut.Close()
+	}()
+	return &client{t: t, in: clientOut, out: bufio.NewReader(clientIn)}
+}
+
+func (c *client) read() message {
+	c.t.Helper()
+	done := make(chan struct{})
+	timer := time.AfterFunc(5*time.Second, func() {
+		c.in.Close()
+		close(done)
+	})
+	defer timer.Stop()
+	content, err := framing.ReadMessage(c.out)
+	if err != nil {
+		select {
+		case <-done:
+			c.t.Fatal("read message timeout")
+		default:
+		}
+		c.t.Fatal(err)
+	}
+	var m message
+	if err := json.Unmarshal(content, &m); err != nil {
+		c.t.Fatal(err)
+	}
+	if m.Event == "output" {
+		var body struct{ Output string }
+		_ = json.Unmarshal(m.Body, &body)
+		c.output.WriteString(body.Output)
+	}
+	return m
+}
+
+// request send request and return body of the response,events before
+// the response are skipped
+func (c *client) request(command string, arguments interface{}, body interface{}) {
+	c.t.Helper()
+	c.seq++
+	if err := framing.WriteMessage(c.in, map[string]interface{}{
+		"seq": c.seq, "type": "request", "command": command, "arguments": arguments,
+	}); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		m := c.read()
+		if m.Type != "response" || m.RequestSeq != c.seq {
+			continue
+		}
+		if m.Success == false {
+			c.t.Fatalf("%s failed: %s", command, m.Message)
+		}
+		if body != nil {
+			if err := json.Unmarshal(m.Body, body); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		return
+	}
+}
+
+// wait read messages until event,body of it is returned
+func (c *client) wait(event string) json.RawMessage {
+	c.t.Helper()
+	for {
+		if m := c.read(); m.Type == "event" && m.Event == event {
+			return m.Body
+		}
+	}
+}
+
+// stopped wait stopped event and return the top frame
+func (c *client) stopped(reason string) stackFrame {
+	c.t.Helper()
+	var event struct{ Reason string }
+	_ = json.Unmarshal(c.wait("stopped"), &event)
+	if event.Reason != reason {
+		c.t.Fatalf("expect stopped by %s,found %s", reason, event.Reason)
+	}
+	var trace struct{ StackFrames []stackFrame }
+	c.request("stackTrace", map[string]interface{}{"threadId": threadID}, &trace)
+	return trace.StackFrames[0]
+}
+
+// variables return value of variables by name
+func (c *client) variables(reference int) map[string]variable {
+	c.t.Helper()
+	var body struct{ Variables []variable }
+	c.request("variables", map[string]interface{}{"variablesReference": reference}, &body)
+	variables := map[string]variable{}
+	for _, it := range body.Variables {
+		variables[it.Name] = it
+	}
+	return variables
+}
+
+func TestServer(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "main.qp")
+	if err := ioutil.WriteFile(program, []byte(`type Point{}
+func add(x, y){
+	var s = x + y
+	return s
+}
+var p = Point{x: 1, y: 2}
+var list = [1, "a", [true]]
+var total = add(p.x, p.y)
+println(total)
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c := newClient(t)
+	c.request("initialize", map[string]interface{}{"adapterID": "qp"}, nil)
+	c.request("launch", map[string]interface{}{"program": program}, nil)
+	c.wait("initialized")
+	var breakpoints struct{ Breakpoints []breakpoint }
+	c.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": program},
+		"breakpoints": []map[string]interface{}{{"line": 8}, {"line": 100}},
+	}, &breakpoints)
+	if len(breakpoints.Breakpoints) != 2 || breakpoints.Breakpoints[0].Verified == false ||
+		breakpoints.Breakpoints[1].Verified {
+		t.Fatalf("unexpected breakpoints %+v", breakpoints.Breakpoints)
+	}
+	c.request("configurationDone", nil, nil)
+
+	if frame := c.stopped("breakpoint"); frame.Name != "main" || frame.Line != 8 {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	var threads struct{ Threads []thread }
+	c.request("threads", nil, &threads)
+	if len(threads.Threads) != 1 {
+		t.Fatalf("unexpected threads %+v", threads.Threads)
+	}
+	var scopes struct{ Scopes []scope }
+	c.request("scopes", map[string]interface{}{"frameId": 0}, &scopes)
+	locals := c.variables(scopes.Scopes[0].VariablesReference)
+	if locals["p"].Value != "{x: 1, y: 2}" || locals["p"].Type != "object" {
+		t.Fatalf("unexpected p %+v", locals["p"])
+	}
+	fields := c.variables(locals["p"].VariablesReference)
+	if fields["x"].Value != "1" || fields["y"].Type != "int" {
+		t.Fatalf("unexpected fields of p %+v", fields)
+	}
+	elements := c.variables(locals["list"].VariablesReference)
+	if elements["1"].Value != `"a"` || elements["2"].Value != "[true]" || elements["2"].VariablesReference == 0 {
+		t.Fatalf("unexpected elements of list %+v", elements)
+	}
+
+	c.request("stepIn", map[string]interface{}{"threadId": threadID}, nil)
+	if frame := c.stopped("step"); frame.Name != "add" || frame.Line != 2 {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	c.request("next", map[string]interface{}{"threadId": threadID}, nil)
+	if frame := c.stopped("step"); frame.Line != 3 {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	c.request("next", map[string]interface{}{"threadId": threadID}, nil)
+	if frame := c.stopped("step"); frame.Line != 4 {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	var trace struct {
+		StackFrames []stackFrame
+		TotalFrames int
+	}
+	c.request("stackTrace", map[string]interface{}{"threadId": threadID}, &trace)
+	if trace.TotalFrames != 2 || trace.StackFrames[1].Name != "main" || trace.StackFrames[1].Line != 8 {
+		t.Fatalf("unexpected stack trace %+v", trace)
+	}
+	c.request("scopes", map[string]interface{}{"frameId": 0}, &scopes)
+	if locals := c.variables(scopes.Scopes[0].VariablesReference); locals["s"].Value != "3" {
+		t.Fatalf("unexpected locals of add %+v", locals)
+	}
+	c.request("scopes", map[string]interface{}{"frameId": 1}, &scopes)
+	if locals := c.variables(scopes.Scopes[0].VariablesReference); locals["list"].Type != "array" {
+		t.Fatalf("unexpected locals of main %+v", locals)
+	}
+
+	c.request("stepOut", map[string]interface{}{"threadId": threadID}, nil)
+	if frame := c.stopped("step"); frame.Name != "main" || frame.Line != 8 {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	c.request("continue", map[string]interface{}{"threadId": threadID}, nil)
+	var exited struct{ ExitCode int }
+	_ = json.Unmarshal(c.wait("exited"), &exited)
+	c.wait("terminated")
+	if exited.ExitCode != 0 || c.output.String() != "3\n" {
+		t.Fatalf("unexpected exit code %d,output %q", exited.ExitCode, c.output.String())
+	}
+	c.request("disconnect", nil, nil)
+}
@@ -0,0 +1,200 @@
+// Package format print qp source in the canonical style.The parser only
+// validates source,its ast drops comments and lowers statements such as
+// switch,so tokens of source are grouped into a tree of statements,blocks
+// and brackets with comments attached.Statements of block are printed one
+// per line and blank lines between them are kept
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/parser"
+)
+
+// Source format src of file,src with syntax errors is no formatted and the
+// first error is returned.Imported modules are no loaded
+func Source(file string, src []byte) ([]byte, error) {
+	p := parser.New(string(src)).SetFile(file).SkipImports()
+	if _, errs := p.ParseWithErrors(); len(errs) != 0 {
+		return nil, errs[0]
+	}
+	tokens, err := scan(file, src)
+	if err != nil {
+		return nil, err
+	}
+	tree := (&builder{tokens: tokens}).file()
+	var pr printer
+	pr.statements(tree, 0)
+	if pr.buf.Len() != 0 {
+		pr.buf.WriteByte('\n')
+	}
+	return pr.buf.Bytes(), nil
+}
+
+type printer struct {
+	buf      bytes.Buffer
+	indent   int          // indent of current line
+	prev     *token       // the last token of current line,nil at line begin
+	unary    bool         // the last token is unary operator
+	params   bool         // the last token close parameters,`(` of return types follow
+	brackets []lexer.Type // brackets of groups being printed
+}
+
+// isOperand check token end an operand,`-` after it is binary
+func isOperand(typ lexer.Type) bool {
+	switch typ {
+	case lexer.IDType, lexer.IntType, lexer.FloatType, lexer.StringType, lexer.NilType, lexer.TrueType,
+		lexer.FalseType, lexer.RightParenthesisType, lexer.RightBracketType, lexer.RightBraceType, lexer.IncType:
+		return true
+	}
+	return false
+}
+
+// newline begin line with indent
+func (p *printer) newline(indent int, blank bool) {
+	if p.buf.Len() != 0 {
+		p.buf.WriteByte('\n')
+		if blank {
+			p.buf.WriteByte('\n')
+		}
+	}
+	p.buf.WriteString(strings.Repeat("\t", indent))
+	p.indent = indent
+	p.prev = nil
+}
+
+// statements print statements of block,one per line with indent
+func (p *printer) statements(b *block, indent int) {
+	last := 0 // the last line printed,blank line is no kept before the first statement
+	line := func(t int, indent int) {
+		p.newline(indent, last != 0 && t-last > 1)
+	}
+	for _, s := range b.statements {
+		indent := indent
+		if t, ok := s.nodes[0].(*token); ok && b.cases && isCaseClause(t.Typ) {
+			indent--
+		}
+		for _, comment := range s.comments {
+			line(comment.Line, indent)
+			p.token(comment, false)
+			last = comment.endLine
+		}
+		line(s.nodes[0].first(), indent)
+		p.items(nil, s.nodes, indent+1)
+		last = s.last()
+	}
+	for _, comment := range b.comments {
+		line(comment.Line, indent)
+		p.token(comment, false)
+		last = comment.endLine
+	}
+}
+
+// items print nodes of statement or group after prev,an item in the line
+// after the previous one begin line with indent.Blocks are kept in the line
+// of nodes around them,`} else {`
+func (p *printer) items(prev node, nodes []node, indent int) {
+	for _, n := range nodes {
+		if prev != nil && n.first() > prev.last() {
+			_, isBlock := n.(*block)
+			_, afterBlock := prev.(*block)
+			if isBlock == false && afterBlock == false {
+				p.newline(indent, false)
+			}
+		}
+		prev = n
+		switch n := n.(type) {
+		case *token:
+			p.token(n, false)
+		case *group:
+			p.group(n)
+			p.params = n.params
+		case *block:
+			p.block(n)
+		}
+	}
+}
+
+func (p *printer) group(g *group) {
+	indent := p.indent
+	p.token(g.open, g.open.Typ == lexer.LeftBraceType)
+	p.brackets = append(p.brackets, g.open.Typ)
+	p.items(g.open, g.items, indent+1)
+	p.brackets = p.brackets[:len(p.brackets)-1]
+	if g.close == nil {
+		return
+	}
+	if len(g.items) != 0 && g.close.Line > g.items[len(g.items)-1].last() {
+		p.newline(indent, false)
+	}
+	p.token(g.close, false)
+}
+
+// block print `{`,statements and `}` in its own line,empty block in one
+// line is kept,`type Point {}`
+func (p *printer) block(b *block) {
+	indent := p.indent
+	p.token(b.open, false)
+	if b.close != nil && b.close.Line == b.open.Line && len(b.statements) == 0 && len(b.comments) == 0 {
+		p.token(b.close, false)
+		return
+	}
+	if b.comment != nil {
+		p.token(b.comment, false)
+	}
+	brackets := p.brackets
+	p.brackets = nil
+	p.statements(b, indent+1)
+	p.brackets = brackets
+	if b.close != nil {
+		p.newline(indent, false)
+		p.token(b.close, false)
+	}
+}
+
+// token print t after space if it is needed,literal is `{` of object or map
+func (p *printer) token(t *token, literal bool) {
+	if p.prev != nil && p.space(p.prev, t, literal) {
+		p.buf.WriteByte(' ')
+	}
+	p.buf.WriteString(t.raw)
+	p.unary = (t.Typ == lexer.SubType || t.Typ == lexer.NoType) && (p.prev == nil || isOperand(p.prev.Typ) == false)
+	p.params = false
+	p.prev = t
+}
+
+// space check space is needed between prev and t in the same line
+func (p *printer) space(prev, t *token, literal bool) bool {
+	switch {
+	case t.Typ == lexer.CommentType:
+		return true
+	case prev.Typ == lexer.LeftBraceType:
+		// `{` of block is followed by newline
+		return false
+	case prev.Typ == lexer.LeftParenthesisType, prev.Typ == lexer.LeftBracketType, prev.Typ == lexer.PeriodType:
+		return false
+	case p.unary:
+		return false
+	case t.Typ == lexer.RightBraceType:
+		return false
+	case t.Typ == lexer.RightParenthesisType, t.Typ == lexer.RightBracketType, t.Typ == lexer.CommaType,
+		t.Typ == lexer.SemicolonType, t.Typ == lexer.PeriodType, t.Typ == lexer.IncType, t.Typ == lexer.ColonType:
+		return false
+	case prev.Typ == lexer.ColonType:
+		// `a[lo:hi]`
+		return len(p.brackets) == 0 || p.brackets[len(p.brackets)-1] != lexer.LeftBracketType
+	case t.Typ == lexer.LeftParenthesisType:
+		if p.params {
+			// `func f(a, b) (int, int)`
+			return true
+		}
+		return isOperand(prev.Typ) == false && prev.Typ != lexer.FuncType
+	case t.Typ == lexer.LeftBracketType:
+		return isOperand(prev.Typ) == false
+	case t.Typ == lexer.LeftBraceType && literal:
+		return prev.Typ != lexer.IDType
+	}
+	return true
+}
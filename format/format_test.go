@@ -0,0 +1,85 @@
+package format
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	cases := []struct {
+		src    string
+		expect string
+	}{
+		{"\n\nvar a=1+2*3\n\n\n\nprintln( a )", "var a = 1 + 2 * 3\n\nprintln(a)\n"},
+		{"func add(a,b){\nreturn a-b,a\n}", "func add(a, b) {\n\treturn a - b, a\n}\n"},
+		{"type Point{\nx:1\n  y :2\n}\nvar p=Point{x:1,y:2}", "type Point {\n\tx: 1\n\ty: 2\n}\nvar p = Point{x: 1, y: 2}\n"},
+		{"var m={\"a\":[1,2],\"b\":nil}\nm[\"a\"]=!true", "var m = {\"a\": [1, 2], \"b\": nil}\nm[\"a\"] = !true\n"},
+		{"// head\n\n\nfor k,v:=range [1] {  // loop\n\n\tprintln(k,v) // print\n\n}", "// head\n\nfor k, v := range [1] { // loop\n\tprintln(k, v) // print\n}\n"},
+		{"if 1>2 {\nprintln(1)}else if 1<2{\n// empty\n}else{\n}", "if 1 > 2 {\n\tprintln(1)\n} else if 1 < 2 {\n\t// empty\n} else {\n}\n"},
+		{"try{\npanic(\"x\")\n}catch e{\nprintln(e.message)\n}", "try {\n\tpanic(\"x\")\n} catch e {\n\tprintln(e.message)\n}\n"},
+		{"var f=func(){return 1}()\nprintln(f,`a\n  b`, \"q\\\"\")", "var f = func() {\n\treturn 1\n}()\nprintln(f, `a\n  b`, \"q\\\"\")\n"},
+		{"println(f(func(){\nreturn 1\n}))", "println(f(func() {\n\treturn 1\n}))\n"},
+		{"func f(a int,b float)(int,string){\nvar c int=a\nreturn c,\"\"\n}", "func f(a int, b float) (int, string) {\n\tvar c int = a\n\treturn c, \"\"\n}\n"},
+		{"interface Printer{\nprint( ) string\n}\nswitch v:=x.( type ){\ncase int,Printer:\nprintln(v is int)\n  default:\n}", "interface Printer {\n\tprint() string\n}\nswitch v := x.(type) {\ncase int, Printer:\n\tprintln(v is int)\ndefault:\n}\n"},
+		{"type P{}\nvar f=func(){}", "type P {}\nvar f = func() {}\n"},
+		{"func f(a,b){\nreturn a+b}", "func f(a, b) {\n\treturn a + b\n}\n"},
+		{"type P {x int = 1\ny int}", "type P {\n\tx int = 1\n\ty int\n}\n"},
+		{"switch v:=x.(type){\ncase int: println(v) // one\ncase string:\n}", "switch v := x.(type) {\ncase int:\n\tprintln(v) // one\ncase string:\n}\n"},
+		{"for k,v:=range {\"a\":1}{println(k,\n\tv)}", "for k, v := range {\"a\": 1} {\n\tprintln(k,\n\t\tv)\n}\n"},
+		{"var m={\n\"a\":1, // a\n\"b\":2,\n}\nm[\"a\"]=m[\"b\"]+\n1 // sum", "var m = {\n\t\"a\": 1, // a\n\t\"b\": 2,\n}\nm[\"a\"] = m[\"b\"] +\n\t1 // sum\n"},
+		{"try{panic(1)}catch e{\n}\n// end", "try {\n\tpanic(1)\n} catch e {\n}\n// end\n"},
+	}
+	for _, c := range cases {
+		result, err := Source("fmt.qp", []byte(c.src))
+		if err != nil {
+			t.Fatalf("%q: %s", c.src, err)
+		}
+		if string(result) != c.expect {
+			t.Fatalf("%q: expect\n%s\nfound\n%s", c.src, c.expect, result)
+		}
+		again, err := Source("fmt.qp", result)
+		if err != nil || string(again) != string(result) {
+			t.Fatalf("%q: format is no idempotent\n%s", c.src, again)
+		}
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	if _, err := Source("bad.qp", []byte("func f(){\n\treturn )\n}")); err == nil ||
+		strings.Contains(err.Error(), "bad.qp:2:9") == false {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestSourceImport(t *testing.T) {
+	result, err := Source("import.qp", []byte("import \"nosuchmod\"\nvar l=nosuchmod.List{}\n"))
+	if err != nil || string(result) != "import \"nosuchmod\"\nvar l = nosuchmod.List{}\n" {
+		t.Fatalf("unexpected result %q %v", result, err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	a := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\nl\n"
+	b := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	expect := `--- x.qp
++++ x.qp
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -9,4 +9,3 @@
+ i
+ j
+ k
+-l
+`
+	if diff := string(Diff("x.qp", []byte(a), []byte(b))); diff != expect {
+		t.Fatalf("unexpected diff\n%s", diff)
+	}
+	if diff := Diff("x.qp", []byte(a), []byte(a)); diff != nil {
+		t.Fatalf("unexpected diff of equal source\n%s", diff)
+	}
+}
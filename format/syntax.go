@@ -0,0 +1,283 @@
+package format
+
+import (
+	"bytes"
+	"strings"
+
+	"gitlab.com/akzj/qp/lexer"
+)
+
+// token is lexer token with its source text
+type token struct {
+	lexer.Token
+	raw     string
+	endLine int // line of the last byte of token,for multiline string
+}
+
+// node of syntax tree is *token,*group or *block
+type node interface {
+	first() int // line of the first token
+	last() int  // line of the last token
+}
+
+// group is `(...)`,`[...]`,or `{...}` of object and map literal,
+// line breaks of source between items are kept
+type group struct {
+	open   *token
+	close  *token
+	items  []node
+	params bool // parameters of function
+}
+
+// block is `{...}` of statements,the closing `}` is always in its own line
+type block struct {
+	open       *token
+	close      *token
+	comment    *token // comment after `{` in its line
+	statements []*statement
+	comments   []*token // comments after the last statement
+	cases      bool     // block of switch,`case` is indented as `switch`
+}
+
+// statement is nodes of a statement,comments in the lines before it are
+// attached to it
+type statement struct {
+	comments []*token
+	nodes    []node
+}
+
+func (t *token) first() int { return t.Line }
+func (t *token) last() int  { return t.endLine }
+
+func (g *group) first() int { return g.open.Line }
+func (g *group) last() int {
+	if g.close == nil {
+		return g.open.endLine
+	}
+	return g.close.endLine
+}
+
+func (b *block) first() int { return b.open.Line }
+func (b *block) last() int {
+	if b.close == nil {
+		return b.open.endLine
+	}
+	return b.close.endLine
+}
+
+func (s *statement) first() int {
+	if len(s.comments) != 0 {
+		return s.comments[0].Line
+	}
+	return s.nodes[0].first()
+}
+
+func (s *statement) last() int {
+	return s.nodes[len(s.nodes)-1].last()
+}
+
+// scan return tokens of src,comments are included
+func scan(file string, src []byte) ([]*token, error) {
+	lineStarts := []int{0, 0} // offset of line,lines begin with 1
+	for index, c := range src {
+		if c == '\n' {
+			lineStarts = append(lineStarts, index+1)
+		}
+	}
+	l := lexer.New(bytes.NewReader(src))
+	l.SetFile(file)
+	var tokens []*token
+	var offsets []int
+	for it := l.Peek(); it.Typ != lexer.EOFType; it = l.Peek() {
+		l.Next()
+		tokens = append(tokens, &token{Token: it})
+		offsets = append(offsets, lineStarts[it.Line]+it.Column-1)
+	}
+	if errs := l.Errors(); len(errs) != 0 {
+		return nil, errs[0]
+	}
+	offsets = append(offsets, len(src))
+	for index, t := range tokens {
+		t.raw = strings.TrimRight(string(src[offsets[index]:offsets[index+1]]), " \t\r\n")
+		t.endLine = t.Line + strings.Count(t.raw, "\n")
+	}
+	return tokens, nil
+}
+
+// builder make syntax tree of tokens
+type builder struct {
+	tokens []*token
+	index  int
+}
+
+// sequence is state of nodes of statement or group being built
+type sequence struct {
+	header   bool   // `if`,`for`,`func`... waiting for block
+	function bool   // `func` waiting for parameters
+	cases    bool   // `switch` waiting for block
+	prev     *token // the last token no comment
+}
+
+func (b *builder) peek() *token {
+	if b.index < len(b.tokens) {
+		return b.tokens[b.index]
+	}
+	return nil
+}
+
+func (b *builder) next() *token {
+	t := b.peek()
+	b.index++
+	return t
+}
+
+// file build all tokens as statements of top level
+func (b *builder) file() *block {
+	file := &block{}
+	file.statements, file.comments = b.statements(false)
+	return file
+}
+
+// statements build statements until `}`
+func (b *builder) statements(cases bool) ([]*statement, []*token) {
+	var statements []*statement
+	var comments []*token
+	for t := b.peek(); t != nil && t.Typ != lexer.RightBraceType; t = b.peek() {
+		if t.Typ == lexer.CommentType {
+			comments = append(comments, b.next())
+			continue
+		}
+		s := b.statement(cases)
+		s.comments, comments = comments, nil
+		statements = append(statements, s)
+	}
+	return statements, comments
+}
+
+// statement build nodes until the line ends the statement,the comment
+// after it in the line is the last node
+func (b *builder) statement(cases bool) *statement {
+	s := &statement{}
+	var seq sequence
+	isCase := cases && isCaseClause(b.peek().Typ)
+	for t := b.peek(); t != nil && t.Typ != lexer.RightBraceType; t = b.peek() {
+		if seq.prev != nil && t.Line > seq.prev.endLine && continues(seq.prev, t, seq.header) == false {
+			break
+		}
+		s.nodes = append(s.nodes, b.node(&seq))
+		if isCase && t.Typ == lexer.ColonType {
+			// statements of case clause begin after `:`
+			if t := b.peek(); t != nil && t.Typ == lexer.CommentType && t.Line == seq.prev.endLine {
+				s.nodes = append(s.nodes, b.next())
+			}
+			break
+		}
+	}
+	return s
+}
+
+// node build token,or group or block begin with token
+func (b *builder) node(seq *sequence) node {
+	t := b.next()
+	var n node = t
+	switch t.Typ {
+	case lexer.LeftParenthesisType, lexer.LeftBracketType:
+		g := b.group(t)
+		g.params = seq.function && t.Typ == lexer.LeftParenthesisType
+		seq.function = false
+		n, t = g, g.close
+	case lexer.LeftBraceType:
+		if seq.header && (seq.prev == nil || expectsOperand(seq.prev.Typ) == false) {
+			block := b.block(t, seq.cases)
+			seq.header, seq.cases = false, false
+			n, t = block, block.close
+		} else {
+			g := b.group(t)
+			n, t = g, g.close
+		}
+	case lexer.IfType, lexer.ElseType, lexer.ForType, lexer.FuncType, lexer.TryType, lexer.CatchType,
+		lexer.TypeType, lexer.InterfaceType, lexer.SwitchType:
+		seq.header = true
+		seq.function = t.Typ == lexer.FuncType
+		seq.cases = t.Typ == lexer.SwitchType
+	}
+	if t != nil && t.Typ != lexer.CommentType {
+		seq.prev = t
+	}
+	return n
+}
+
+// group build items until the bracket closing open
+func (b *builder) group(open *token) *group {
+	g := &group{open: open}
+	var seq sequence
+	for t := b.peek(); t != nil; t = b.peek() {
+		if t.Typ == closing(open.Typ) {
+			g.close = b.next()
+			return g
+		}
+		g.items = append(g.items, b.node(&seq))
+	}
+	return g
+}
+
+// block build statements until `}`
+func (b *builder) block(open *token, cases bool) *block {
+	block := &block{open: open, cases: cases}
+	if t := b.peek(); t != nil && t.Typ == lexer.CommentType && t.Line == open.Line {
+		block.comment = b.next()
+	}
+	block.statements, block.comments = b.statements(cases)
+	block.close = b.next()
+	return block
+}
+
+func closing(typ lexer.Type) lexer.Type {
+	switch typ {
+	case lexer.LeftParenthesisType:
+		return lexer.RightParenthesisType
+	case lexer.LeftBracketType:
+		return lexer.RightBracketType
+	}
+	return lexer.RightBraceType
+}
+
+func isCaseClause(typ lexer.Type) bool {
+	return typ == lexer.CaseType || typ == lexer.DefaultType
+}
+
+func isBinary(typ lexer.Type) bool {
+	switch typ {
+	case lexer.OrType, lexer.AndType, lexer.AddType, lexer.SubType, lexer.MulOpType, lexer.DivOpType,
+		lexer.ModOpType, lexer.LessType, lexer.GreaterType, lexer.LessEqualType, lexer.GreaterEqualType,
+		lexer.EqualType, lexer.NoEqualType, lexer.IsType:
+		return true
+	}
+	return false
+}
+
+// expectsOperand check an operand follows token,`{` after it is literal
+func expectsOperand(typ lexer.Type) bool {
+	switch typ {
+	case lexer.AssignType, lexer.VarInitType, lexer.CommaType, lexer.ColonType, lexer.RangeType,
+		lexer.ReturnType, lexer.NoType:
+		return true
+	}
+	return isBinary(typ)
+}
+
+// continues check t in the next line of prev belongs to the statement of prev
+func continues(prev, t *token, header bool) bool {
+	if expectsOperand(prev.Typ) && prev.Typ != lexer.ReturnType || prev.Typ == lexer.PeriodType {
+		return true
+	}
+	switch t.Typ {
+	case lexer.PeriodType, lexer.ElseType, lexer.CatchType:
+		return true
+	case lexer.LeftBraceType:
+		return header
+	case lexer.NoType:
+		return false
+	}
+	return isBinary(t.Typ)
+}
@@ -0,0 +1,54 @@
+// Package framing is the base protocol of LSP and DAP,a message is JSON
+// content after headers,`Content-Length` header is the size of content
+package framing
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ReadMessage read content of message with `Content-Length` header
+func ReadMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if index := strings.Index(line, ":"); index != -1 &&
+			strings.EqualFold(strings.TrimSpace(line[:index]), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(line[index+1:])); err != nil {
+				return nil, fmt.Errorf("invalid header `%s`", line)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("no Content-Length header")
+	}
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// WriteMessage write message as JSON with `Content-Length` header
+func WriteMessage(w io.Writer, message interface{}) error {
+	content, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
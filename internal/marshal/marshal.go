@@ -0,0 +1,163 @@
+// Package marshal is the reflection shared by qp.ToValue and
+// stackmachine.ToObject,the engines only build their own values
+package marshal
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+var (
+	TimeType     = reflect.TypeOf(time.Time{})
+	DurationType = reflect.TypeOf(time.Duration(0))
+	ErrorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// IsMapKey return true if map keyed by typ is convertible
+func IsMapKey(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return false
+}
+
+// SortedKeys return keys of map in order,so map converted keeps same order
+func SortedKeys(value reflect.Value) []reflect.Value {
+	keys := value.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		switch keys[i].Kind() {
+		case reflect.String:
+			return keys[i].String() < keys[j].String()
+		case reflect.Bool:
+			return keys[i].Bool() == false && keys[j].Bool()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return keys[i].Int() < keys[j].Int()
+		default:
+			return keys[i].Uint() < keys[j].Uint()
+		}
+	})
+	return keys
+}
+
+// Visiting is the pointers,maps and slices being converted,a value met
+// again while it is converted is a cycle
+type Visiting map[ref]bool
+
+type ref struct {
+	typ     reflect.Type
+	pointer uintptr
+}
+
+// Enter mark value being converted,false is returned when it is being
+// converted already.Values other than pointer,map and slice are no marked
+func (v Visiting) Enter(value reflect.Value) bool {
+	key, ok := refOf(value)
+	if ok == false {
+		return true
+	}
+	if v[key] {
+		return false
+	}
+	v[key] = true
+	return true
+}
+
+// Leave unmark value after it is converted
+func (v Visiting) Leave(value reflect.Value) {
+	if key, ok := refOf(value); ok {
+		delete(v, key)
+	}
+}
+
+func refOf(value reflect.Value) (ref, bool) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice:
+		if value.IsNil() || value.Kind() == reflect.Slice && value.Len() == 0 {
+			return ref{}, false
+		}
+		return ref{typ: value.Type(), pointer: value.Pointer()}, true
+	}
+	return ref{}, false
+}
+
+type StructField struct {
+	Name  string
+	Index []int
+}
+
+// StructFields return exported fields of typ,the field name is the `qp` tag
+// or the Go field name,tag `-` skip the field
+func StructFields(typ reflect.Type) []StructField {
+	var fields []StructField
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("qp"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		fields = append(fields, StructField{Name: name, Index: field.Index})
+	}
+	return fields
+}
+
+// Func is Go function called by script
+type Func struct {
+	fn          reflect.Value
+	returnError bool
+}
+
+func NewFunc(fn reflect.Value) Func {
+	typ := fn.Type()
+	return Func{
+		fn:          fn,
+		returnError: typ.NumOut() > 0 && typ.Out(typ.NumOut()-1) == ErrorType,
+	}
+}
+
+// Name return name of function,`func(int) string`
+func (f Func) Name() string {
+	return f.fn.Type().String()
+}
+
+// Call decode count arguments by decode and call the function,err is the
+// non nil error result.Argument count or type no match panics
+func (f Func) Call(count int, decode func(index int, dst reflect.Value) error) (out []reflect.Value, err error) {
+	typ := f.fn.Type()
+	if typ.IsVariadic() == false && count != typ.NumIn() ||
+		typ.IsVariadic() && count < typ.NumIn()-1 {
+		panic(fmt.Errorf("call `%s` argument count %d no match", typ, count))
+	}
+	var in []reflect.Value
+	for index := 0; index < count; index++ {
+		var argType reflect.Type
+		if typ.IsVariadic() && index >= typ.NumIn()-1 {
+			argType = typ.In(typ.NumIn() - 1).Elem()
+		} else {
+			argType = typ.In(index)
+		}
+		arg := reflect.New(argType).Elem()
+		if err := decode(index, arg); err != nil {
+			panic(fmt.Errorf("argument %d: %w", index, err))
+		}
+		in = append(in, arg)
+	}
+	out = f.fn.Call(in)
+	if f.returnError {
+		if result := out[len(out)-1]; result.IsNil() == false {
+			return nil, result.Interface().(error)
+		}
+		out = out[:len(out)-1]
+	}
+	return out, nil
+}
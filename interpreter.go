@@ -0,0 +1,187 @@
+// Package qp embeds qp script in Go program
+//
+//	interpreter := qp.New(qp.Options{})
+//	interpreter.RegisterFunc("add", func(arguments ...qp.Value) (qp.Value, error) {
+//		return arguments[0].(ast.Int) + arguments[1].(ast.Int), nil
+//	})
+//	value, err := interpreter.Eval(`return add(1,2)`)
+package qp
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	goruntime "runtime"
+	"strings"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/builtin"
+	"gitlab.com/akzj/qp/parser"
+	"gitlab.com/akzj/qp/runtime"
+	"gitlab.com/akzj/qp/types"
+)
+
+// Value is the value of qp script
+type Value = runtime.Invokable
+
+// Func is host function called by script,an error returned aborts the script
+// and is returned by Eval or Call
+type Func func(arguments ...Value) (Value, error)
+
+type Options struct {
+	// Stdout is the writer of println,os.Stdout when nil
+	Stdout io.Writer
+	// NoBuiltIn remove the built in functions and methods of string and array,
+	// only functions registered by RegisterFunc are callable by script.
+	// `import` is disabled unless Path is set
+	NoBuiltIn bool
+	// Path is the search path of `import` after the working directory,
+	// QPPATH when nil
+	Path []string
+}
+
+// Interpreter is an isolated qp runtime,globals and functions of one
+// Interpreter are invisible to others
+type Interpreter struct {
+	options Options
+	vm      *runtime.VMRuntime
+	parser  *parser.Parser
+}
+
+// SyntaxErrors is all syntax errors or type errors of the source passed to
+// Eval
+type SyntaxErrors []error
+
+func (errs SyntaxErrors) Error() string {
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "\n")
+}
+
+type hostError struct {
+	err error
+}
+
+func (e hostError) Error() string {
+	return e.err.Error()
+}
+
+func New(options Options) *Interpreter {
+	vm := runtime.New()
+	if options.NoBuiltIn {
+		vm.Functions = map[string]*runtime.Object{}
+		vm.ArrayFunctions = map[string]*runtime.Object{}
+		vm.StringFunctions = map[string]*runtime.Object{}
+	} else if options.Stdout != nil {
+		vm.AddFunction(builtin.NewFunction("println", builtin.Println(options.Stdout)))
+	}
+	path := options.Path
+	if path == nil {
+		path = parser.DefaultPath()
+	}
+	p := parser.NewWithVM("", vm).SetImporter(parser.NewImporter(path))
+	if options.NoBuiltIn && options.Path == nil {
+		p.DisableImports()
+	}
+	return &Interpreter{
+		options: options,
+		vm:      vm,
+		parser:  p,
+	}
+}
+
+// RegisterFunc make fn callable by script with name
+func (i *Interpreter) RegisterFunc(name string, fn Func) {
+	i.vm.AddFunction(builtin.NewFunction(name, func(arguments ...runtime.Invokable) runtime.Invokable {
+		value, err := fn(arguments...)
+		if err != nil {
+			panic(hostError{err: err})
+		}
+		if value == nil {
+			return ast.NilObj
+		}
+		return value
+	}))
+}
+
+// SetGlobal bind value to the global var name
+func (i *Interpreter) SetGlobal(name string, value Value) {
+	if value == nil {
+		value = ast.NilObj
+	}
+	if object := i.vm.GetVar(name); object != nil {
+		object.Pointer = value
+		return
+	}
+	i.vm.AllocObject(name).Pointer = value
+}
+
+// Eval execute src,the result is the value of `return` or of the last statement.
+// Functions,types and globals defined by src are kept for later Eval and Call
+func (i *Interpreter) Eval(src string) (value Value, err error) {
+	statements, errs := i.parser.ParseMore(src)
+	if len(errs) != 0 {
+		return nil, SyntaxErrors(errs)
+	}
+	if errs := types.Check(i.parser, statements); len(errs) != 0 {
+		return nil, SyntaxErrors(errs)
+	}
+	defer i.recover(i.vm.Mark(), &err)
+	return result(statements.Invoke()), nil
+}
+
+// Call call the function defined by script or registered by RegisterFunc
+func (i *Interpreter) Call(funcName string, arguments ...Value) (value Value, err error) {
+	object := i.vm.GetObject(funcName)
+	if object == nil {
+		return nil, fmt.Errorf("function `%s` no defined", funcName)
+	}
+	function, ok := unwrap(object).Invoke().(ast.Function)
+	if ok == false {
+		return nil, fmt.Errorf("`%s` is no callable", funcName)
+	}
+	defer i.recover(i.vm.Mark(), &err)
+	return result(function.Call(arguments...)), nil
+}
+
+func (i *Interpreter) recover(mark runtime.StackMark, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	i.vm.Reset(mark)
+	switch r := r.(type) {
+	case hostError:
+		*err = r.err
+	case goruntime.Error:
+		*err = r
+	case error:
+		*err = fmt.Errorf("runtime error: %w", r)
+	default:
+		*err = errors.New("runtime error: " + fmt.Sprint(r))
+	}
+}
+
+func unwrap(value Value) Value {
+	for {
+		object, ok := value.(*runtime.Object)
+		if ok == false {
+			return value
+		}
+		value = object.Pointer
+	}
+}
+
+func result(value Value) Value {
+	if ret, ok := value.(ast.ReturnStatement); ok {
+		value = ret.Val
+	}
+	switch value := unwrap(value).(type) {
+	case nil, ast.NopStatement:
+		return ast.NilObj
+	default:
+		return value
+	}
+}
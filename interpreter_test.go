@@ -0,0 +1,341 @@
+package qp
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp/ast"
+)
+
+func TestInterpreterEval(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := New(Options{Stdout: &stdout})
+	interpreter.RegisterFunc("add", func(arguments ...Value) (Value, error) {
+		return arguments[0].(ast.Int) + arguments[1].(ast.Int), nil
+	})
+	interpreter.SetGlobal("base", ast.Int(100))
+
+	value, err := interpreter.Eval(`
+func mul(a,b){
+	return a * b
+}
+println("base",base)
+return add(base,mul(2,3))
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != ast.Int(106) {
+		t.Fatalf("expect 106,found %s", value)
+	}
+	if stdout.String() != "base 100\n" {
+		t.Fatalf("stdout `%s`", stdout.String())
+	}
+
+	//functions are kept between Eval
+	value, err = interpreter.Call("mul", ast.Int(3), ast.Int(4))
+	if err != nil || value != ast.Int(12) {
+		t.Fatal(value, err)
+	}
+	value, err = interpreter.Eval(`return mul(base,2)`)
+	if err != nil || value != ast.Int(200) {
+		t.Fatal(value, err)
+	}
+}
+
+func TestInterpreterIsolate(t *testing.T) {
+	first := New(Options{})
+	second := New(Options{})
+	first.RegisterFunc("hello", func(arguments ...Value) (Value, error) {
+		return ast.String("hello"), nil
+	})
+	if _, err := first.Eval(`var a = hello()`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Eval(`var a = hello()`); err == nil {
+		t.Fatal("expect `hello` undefined in second interpreter")
+	}
+	if _, err := second.Call("hello"); err == nil {
+		t.Fatal("expect `hello` undefined in second interpreter")
+	}
+
+	sandbox := New(Options{NoBuiltIn: true})
+	if _, err := sandbox.Eval(`println(1)`); err == nil {
+		t.Fatal("expect println removed")
+	}
+	if _, err := sandbox.Eval(`return "A".to_lower()`); err == nil {
+		t.Fatal("expect string methods removed")
+	}
+	if value, err := first.Eval(`return "A".to_lower()`); err != nil || value != ast.String("a") {
+		t.Fatal(value, err)
+	}
+	// lib/list.qp is found in the working directory unless imports are disabled
+	if _, err := sandbox.Eval(`import "lib/list"`); err == nil || strings.Contains(err.Error(), "disabled") == false {
+		t.Fatalf("expect import disabled,found %v", err)
+	}
+	if _, err := New(Options{NoBuiltIn: true, Path: []string{"lib"}}).Eval(`import "list"`); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInterpreterErrors(t *testing.T) {
+	interpreter := New(Options{})
+	_, err := interpreter.Eval("var a = )\nvar b = (\n")
+	var syntaxErrors SyntaxErrors
+	if errors.As(err, &syntaxErrors) == false || len(syntaxErrors) != 2 {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("host failed")
+	interpreter.RegisterFunc("fail", func(arguments ...Value) (Value, error) {
+		return nil, failed
+	})
+	if _, err := interpreter.Eval(`
+for i := 0; i < 10; i++ {
+	if i == 5 {
+		fail()
+	}
+}`); err != failed {
+		t.Fatal(err)
+	}
+	if _, err := interpreter.Eval(`if 1 { }`); err == nil {
+		t.Fatal("expect runtime error")
+	}
+	_, err = interpreter.Eval("var a = [1, 2]\nprintln(a[1:2])\na[0 - 1] = 1\n")
+	if err == nil || err.Error() != "runtime error: 3:2: invalid array index -1 (index must be non-negative)" {
+		t.Fatal(err)
+	}
+	interpreter.RegisterFunc("crash", func(arguments ...Value) (Value, error) {
+		return arguments[3], nil
+	})
+	_, err = interpreter.Eval("crash()")
+	if err == nil || err.Error() != "runtime error: index out of range [3] with length 0" {
+		t.Fatal(err)
+	}
+	_, err = interpreter.Eval("println(nofunc(1))")
+	if err == nil || err.Error() != "runtime error: 1:15: undefined function `nofunc`" {
+		t.Fatal(err)
+	}
+	value, err := interpreter.Eval(`
+var i = 1
+return i`)
+	if err != nil || value != ast.Int(1) {
+		t.Fatal(value, err)
+	}
+}
+
+func TestInterpreterMap(t *testing.T) {
+	interpreter := New(Options{})
+	value, err := interpreter.Eval(`
+var m = {"a": 1, "b": 2}
+m["c"] = m["a"] + m["b"]
+m["a"]++
+delete(m, "b")
+return m
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.String() != `map["a":2 "c":3]` {
+		t.Fatalf("found %s", value)
+	}
+	var out map[string]int
+	if err := FromValue(value, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 || out["a"] != 2 || out["c"] != 3 {
+		t.Fatalf("%+v", out)
+	}
+	if _, err := interpreter.Eval(`var m = {[1]: 1}`); err == nil {
+		t.Fatal("expect invalid map key error")
+	}
+}
+
+func TestInterpreterFloat(t *testing.T) {
+	interpreter := New(Options{})
+	value, err := interpreter.Eval(`
+var r = 2
+var area = 3.14 * r * r
+return [area, 7 / 2, 7 / 2.0, 1e-3, int(2.9), float(1), 1 < 1.5]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []interface{}
+	if err := FromValue(value, &out); err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{12.56, int64(3), 3.5, 0.001, int64(2), float64(1), true}
+	if reflect.DeepEqual(out, expect) == false {
+		t.Fatalf("%+v", out)
+	}
+}
+
+func TestInterpreterForRange(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := New(Options{Stdout: &stdout})
+	_, err := interpreter.Eval(`
+for i, v := range [10, 20, 30] {
+	if i == 1 {
+		continue
+	}
+	println(i, v)
+}
+for _, c := range "hé" {
+	println(c)
+}
+for k := range {"a": 1, "b": 2} {
+	if k == "b" {
+		break
+	}
+	println(k)
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "0 10\n2 30\nh\né\na\n"; stdout.String() != expect {
+		t.Fatalf("stdout `%s`", stdout.String())
+	}
+}
+
+func TestInterpreterLabeledLoop(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := New(Options{Stdout: &stdout})
+	value, err := interpreter.Eval(`
+var count = 0
+outer: for i := 0; i < 3; i++ {
+	for j := 0; j < 3; j++ {
+		if j == 1 {
+			continue outer
+		}
+		if i == 2 {
+			break outer
+		}
+		count++
+	}
+}
+return count
+`)
+	if err != nil || value != ast.Int(2) {
+		t.Fatal(value, err)
+	}
+	// stack frames of loop are released by break
+	if _, err := interpreter.Eval(`
+for i := 0; i < 3; i++ {
+	var inner = i
+	break
+}
+println(inner)
+`); err == nil || stdout.Len() != 0 {
+		t.Fatalf("expect `inner` no defined,found `%s`", stdout.String())
+	}
+}
+
+func TestInterpreterTryCatch(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := New(Options{Stdout: &stdout})
+	interpreter.RegisterFunc("fail", func(arguments ...Value) (Value, error) {
+		return nil, errors.New("host failed")
+	})
+	value, err := interpreter.Eval(`
+var arr = [1, 2]
+for i := 1; i < 3; i++ {
+	try {
+		var x = arr[i]
+		println(x)
+	} catch e {
+		println("caught", e.message)
+	}
+}
+try {
+	fail()
+} catch e {
+	println(e)
+}
+try {
+	panic("inner")
+} catch e {
+	try {
+		panic(e)
+	} catch again {
+		return again.message
+	}
+}
+`)
+	if err != nil || value != ast.String("inner") {
+		t.Fatal(value, err)
+	}
+	if stdout.String() != "2\ncaught index 2 out of range [0:2]\nhost failed\n" {
+		t.Fatalf("unexpected output %q", stdout.String())
+	}
+	if _, err := interpreter.Eval(`panic("boom", 1)`); err == nil || err.Error() != "runtime error: boom 1" {
+		t.Fatalf("expect runtime error,found %v", err)
+	}
+	if _, err := interpreter.Eval(`
+try {
+	fail()
+} catch e {
+	println(e)
+}
+fail()
+`); err == nil || err.Error() != "host failed" {
+		t.Fatalf("expect host error,found %v", err)
+	}
+}
+
+func TestInterpreterStackOverflow(t *testing.T) {
+	interpreter := New(Options{})
+	value, err := interpreter.Eval(`
+func f(n) {
+	return f(n + 1)
+}
+try {
+	f(0)
+} catch e {
+	return e.message
+}
+`)
+	if err != nil || value != ast.String("stack overflow") {
+		t.Fatal(value, err)
+	}
+	if _, err := interpreter.Eval("f(0)"); err == nil || err.Error() != "runtime error: stack overflow" {
+		t.Fatalf("expect stack overflow,found %v", err)
+	}
+	// calls of the failed script are no counted
+	value, err = interpreter.Eval(`
+func g(n) {
+	if n == 0 {
+		return 0
+	}
+	return g(n - 1) + 1
+}
+return g(9000)
+`)
+	if err != nil || value != ast.Int(9000) {
+		t.Fatal(value, err)
+	}
+}
+
+func TestInterpreterImport(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := New(Options{Stdout: &stdout, Path: []string{"lib"}})
+	for i := 0; i < 2; i++ {
+		value, err := interpreter.Eval(`
+import "list"
+var l = list.List{}
+l.init()
+l.insert(1)
+l.insert(2)
+return l.len`)
+		if err != nil || value != ast.Int(2) {
+			t.Fatal(value, err)
+		}
+	}
+	if _, err := interpreter.Eval(`import "none"`); err == nil {
+		t.Fatal("expect module not found error")
+	}
+}
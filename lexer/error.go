@@ -0,0 +1,59 @@
+package lexer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Position is a location in source code, Line and Column start from 1
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (p Position) String() string {
+	pos := strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+	if p.File != "" {
+		return p.File + ":" + pos
+	}
+	return pos
+}
+
+// Error is a lexical error, Snippet is the source line of the error
+type Error struct {
+	Position
+	Msg     string
+	Snippet string
+}
+
+func (e *Error) Error() string {
+	return FormatError(e.Position, e.Msg, e.Snippet)
+}
+
+// FormatError formats msg with the position and a caret marking the column
+//
+//	main.qp:2:9: unknown token `@`
+//		var a = @
+//		        ^
+func FormatError(pos Position, msg string, snippet string) string {
+	var builder strings.Builder
+	builder.WriteString(pos.String())
+	builder.WriteString(": ")
+	builder.WriteString(msg)
+	if snippet == "" {
+		return builder.String()
+	}
+	builder.WriteString("\n\t")
+	builder.WriteString(snippet)
+	builder.WriteString("\n\t")
+	for index := 0; index < pos.Column-1 && index < len(snippet); index++ {
+		if snippet[index] == '\t' {
+			builder.WriteByte('\t')
+		} else {
+			builder.WriteByte(' ')
+		}
+	}
+	builder.WriteByte('^')
+	return builder.String()
+}
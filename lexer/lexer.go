@@ -3,15 +3,19 @@ package lexer
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"io"
-	"log"
 )
 
 type Lexer struct {
-	reader *bufio.Reader
-	line   int
-	token  Token
-	err    error
+	reader  *bufio.Reader
+	file    string
+	line    int
+	column  int
+	lineBuf []byte // bytes of current line had read
+	token   Token
+	err     error
+	errors  []error
 }
 
 func (l *Lexer) Finish() bool {
@@ -42,9 +46,53 @@ func (l *Lexer) Get() (byte, error) {
 		l.err = err
 		return 0, err
 	}
+	if c == '\n' {
+		l.line++
+		l.column = 0
+		l.lineBuf = l.lineBuf[:0]
+	} else {
+		l.column++
+		l.lineBuf = append(l.lineBuf, c)
+	}
 	return c, nil
 }
 
+// SetFile set the file name used by error position
+func (l *Lexer) SetFile(file string) {
+	l.file = file
+}
+
+// Errors return the lexical errors had found,the bad input is skipped
+func (l *Lexer) Errors() []error {
+	return l.errors
+}
+
+func (l *Lexer) errorf(line, column int, format string, args ...interface{}) {
+	l.errors = append(l.errors, &Error{
+		Position: Position{
+			File:   l.file,
+			Line:   line,
+			Column: column,
+		},
+		Msg:     fmt.Sprintf(format, args...),
+		Snippet: l.currentLine(),
+	})
+}
+
+// currentLine return the source line under reading without consume it
+func (l *Lexer) currentLine() string {
+	line := string(l.lineBuf)
+	for size := 64; ; size *= 2 {
+		ahead, err := l.reader.Peek(size)
+		if index := bytes.IndexByte(ahead, '\n'); index >= 0 {
+			return line + string(ahead[:index])
+		}
+		if err != nil {
+			return line + string(ahead)
+		}
+	}
+}
+
 func (l *Lexer) Peek() Token {
 	if l.token.Typ != EOFType {
 		return l.token
@@ -52,16 +100,14 @@ func (l *Lexer) Peek() Token {
 	for {
 		c, err := l.Get()
 		if err != nil {
-			return EmptyToken
+			return Token{Typ: EOFType, Line: l.line, Column: l.column + 1}
 		}
+		line, column := l.line, l.column
 		var token Token
 		switch {
 		case IsSpace(c):
-			if c == '\n' {
-				l.line++
-			}
 			continue
-		case IsLetter(c):
+		case IsLetter(c) || c == '_':
 			token = l.parseLabel(c)
 		case c == '+':
 			if a, _ := l.ahead(); a == '+' {
@@ -105,7 +151,7 @@ func (l *Lexer) Peek() Token {
 				_, _ = l.Get()
 				token = NoEqualToken
 			} else {
-				log.Panicf("unknown token`%s`", string(c))
+				token = NoToken
 			}
 		case c == '-':
 			token = SubOperatorToken
@@ -135,27 +181,34 @@ func (l *Lexer) Peek() Token {
 			if ahead, _ := l.ahead(); ahead == '/' { //
 				_, _ = l.Get()
 				token = Token{
-					Typ:  CommentType,
-					Val:  l.readline(),
-					Line: l.line,
+					Typ: CommentType,
+					Val: l.readline(),
 				}
+			} else {
+				token = DivToken
 			}
 		case c == '%':
 			token = ModToken
 		case c == '|':
-			if c, _ := l.ahead(); c == '|' {
-				l.Get()
-				token = OrToken
+			if c, _ := l.ahead(); c != '|' {
+				l.errorf(line, column, "unknown token `|`")
+				continue
 			}
+			_, _ = l.Get()
+			token = OrToken
 		case c == '&':
-			if c, _ := l.ahead(); c == '&' {
-				l.Get()
-				token = AndToken
+			if c, _ := l.ahead(); c != '&' {
+				l.errorf(line, column, "unknown token `&`")
+				continue
 			}
+			_, _ = l.Get()
+			token = AndToken
 		default:
-			log.Panicln(string(c), l.line)
+			l.errorf(line, column, "unknown token `%s`", string(c))
+			continue
 		}
-		token.Line = l.line
+		token.Line = line
+		token.Column = column
 		l.token = token
 		return token
 	}
@@ -163,18 +216,17 @@ func (l *Lexer) Peek() Token {
 
 func (l *Lexer) parseString(multiline bool) Token {
 	var buffer bytes.Buffer
+	line, column := l.line, l.column
 	for {
 		c, err := l.Get()
 		if err != nil {
 			l.err = err
+			l.errorf(line, column, "string literal not terminated")
 			break
 		}
-		if c == '\n' {
-			if multiline == false {
-				log.Panic("parse string failed", string(c))
-			} else {
-				l.line++
-			}
+		if c == '\n' && multiline == false {
+			l.errorf(line, column, "string literal not terminated")
+			break
 		}
 		if c == '\\' {
 			c, err = l.ahead()
@@ -183,6 +235,7 @@ func (l *Lexer) parseString(multiline bool) Token {
 				break
 			}
 			if c == '"' {
+				_, _ = l.Get()
 				buffer.WriteByte('"')
 				continue
 			}
@@ -196,36 +249,53 @@ func (l *Lexer) parseString(multiline bool) Token {
 		buffer.WriteByte(c)
 	}
 	return Token{
-		Typ:  StringType,
-		Val:  buffer.String(),
-		Line: 0,
+		Typ: StringType,
+		Val: buffer.String(),
 	}
 }
 
+// parseNumToken parse int 123 or float 1.5,1e-3,1.5E+3
 func (l *Lexer) parseNumToken(c byte) Token {
 	var buf bytes.Buffer
+	var typ = IntType
 	buf.WriteByte(c)
-	for {
-		c, err := l.reader.ReadByte()
-		if err != nil {
-			l.err = err
-			break
-		}
-		if IsDigit(c) {
+	l.readDigits(&buf)
+	if next, _ := l.reader.Peek(2); len(next) == 2 && next[0] == '.' && IsDigit(next[1]) {
+		typ = FloatType
+		c, _ = l.Get()
+		buf.WriteByte(c)
+		l.readDigits(&buf)
+	}
+	if next, _ := l.reader.Peek(3); len(next) >= 2 && (next[0] == 'e' || next[0] == 'E') {
+		if IsDigit(next[1]) ||
+			len(next) == 3 && (next[1] == '+' || next[1] == '-') && IsDigit(next[2]) {
+			typ = FloatType
+			c, _ = l.Get()
 			buf.WriteByte(c)
-		} else {
-			if err := l.reader.UnreadByte(); err != nil {
-				panic(err)
+			if c, _ = l.ahead(); c == '+' || c == '-' {
+				_, _ = l.Get()
+				buf.WriteByte(c)
 			}
-			break
+			l.readDigits(&buf)
 		}
 	}
 	return Token{
-		Typ: IntType,
+		Typ: typ,
 		Val: buf.String(),
 	}
 }
 
+func (l *Lexer) readDigits(buf *bytes.Buffer) {
+	for {
+		c, err := l.ahead()
+		if err != nil || IsDigit(c) == false {
+			break
+		}
+		_, _ = l.Get()
+		buf.WriteByte(c)
+	}
+}
+
 func (l *Lexer) Next() {
 	l.token = EmptyToken
 }
@@ -234,19 +304,12 @@ func (l *Lexer) parseLabel(c byte) Token {
 	var buf bytes.Buffer
 	buf.WriteByte(c)
 	for {
-		c, err := l.reader.ReadByte()
-		if err != nil {
-			l.err = err
-			break
-		}
-		if IsLetter(c) || IsDigit(c) || c == '_' {
-			buf.WriteByte(c)
-		} else {
-			if err := l.reader.UnreadByte(); err != nil {
-				panic(err)
-			}
+		c, err := l.ahead()
+		if err != nil || (IsLetter(c) || IsDigit(c) || c == '_') == false {
 			break
 		}
+		_, _ = l.Get()
+		buf.WriteByte(c)
 	}
 	for _, keyword := range Keywords {
 		if keyword == buf.String() {
@@ -290,5 +353,6 @@ func New(reader io.Reader) *Lexer {
 	return &Lexer{
 		token:  EmptyToken,
 		reader: bufio.NewReader(reader),
+		line:   1,
 	}
 }
@@ -17,8 +17,12 @@ func (t Type) String() string {
 		return "-"
 	case MulOpType:
 		return "*"
+	case ModOpType:
+		return "%"
 	case IntType:
 		return "int"
+	case FloatType:
+		return "float"
 	case LeftParenthesisType:
 		return "("
 	case RightParenthesisType:
@@ -33,6 +37,16 @@ func (t Type) String() string {
 		return "for"
 	case BreakType:
 		return "break"
+	case ContinueType:
+		return "continue"
+	case RangeType:
+		return "range"
+	case TryType:
+		return "try"
+	case CatchType:
+		return "catch"
+	case ImportType:
+		return "import"
 	case ReturnType:
 		return "return"
 	case LeftBraceType:
@@ -71,6 +85,8 @@ func (t Type) String() string {
 		return "call"
 	case SemicolonType:
 		return ";"
+	case ColonType:
+		return ":"
 	case AssignStatementType:
 		return "assignStatement"
 	case FuncType:
@@ -123,6 +139,8 @@ func (t Type) String() string {
 		return "DurationObjectType"
 	case TimeObjectType:
 		return "TimeObjectType"
+	case BoolObjectType:
+		return "bool"
 	case BuiltInFunctionType:
 		return "builtInFunction"
 	case NewLineType:
@@ -135,6 +153,16 @@ func (t Type) String() string {
 		return "&&"
 	case VarInitType:
 		return ":="
+	case InterfaceType:
+		return "interface"
+	case IsType:
+		return "is"
+	case SwitchType:
+		return "switch"
+	case CaseType:
+		return "case"
+	case DefaultType:
+		return "default"
 	default:
 		panic("unknown token type " + strconv.Itoa(int(t)))
 	}
@@ -179,6 +207,11 @@ const (
 	FuncType                          // func
 	ReturnType                        // return
 	BreakType                         // break
+	ContinueType                      // continue
+	RangeType                         // range
+	TryType                           // try
+	CatchType                         // catch
+	ImportType                        // import
 	ForType                           // for
 	ElseifType                        // else if
 	VarType                           // var
@@ -186,6 +219,7 @@ const (
 	VarAssignType                     // var x =
 	VarInitType                       // :=
 	IntType                           // int
+	FloatType                         // float
 	TypeType                          // type
 	MapObjectType                     // map {}
 	ArrayObjectType                   // array []
@@ -206,14 +240,24 @@ const (
 	FuncCallQueueStatementType        // FuncCallQueueStatement
 	DurationObjectType                // DurationObjectType
 	TimeObjectType                    // DurationObjectType
+	ErrorObjectType                   // error caught by catch
+	TupleObjectType                   // values of `return a, b`
+	MultiAssignStatementType          // a, b = f()
+	ModuleMemberType                  // module.member
 	BuiltInFunctionType               // built in function
 	CreateObjectStatementType         // createObjectStatement
+	InterfaceType                     // interface
+	IsType                            // is
+	SwitchType                        // switch
+	CaseType                          // case
+	DefaultType                       // default
 )
 
 type Token struct {
-	Typ  Type
-	Val  string
-	Line int
+	Typ    Type
+	Val    string
+	Line   int
+	Column int
 }
 
 func (t Token) String() string {
@@ -251,22 +295,34 @@ var (
 	VarInitToken          = Token{Typ: VarInitType}
 	ModToken              = Token{Typ: ModOpType}
 	DivToken              = Token{Typ: DivOpType}
+	NoToken               = Token{Typ: NoType}
 )
 
 var Keywords = []string{
-	"if", "else", "func", "return", "break", "for", "var", "type", "nil", "true", "false",
+	"if", "else", "func", "return", "break", "continue", "for", "range", "try", "catch", "import", "var", "type", "nil", "true", "false",
+	"interface", "is", "switch", "case", "default",
 }
 
 var KeywordType = map[string]Type{
-	"if":     IfType,
-	"else":   ElseType,
-	"func":   FuncType,
-	"return": ReturnType,
-	"break":  BreakType,
-	"for":    ForType,
-	"var":    VarType,
-	"type":   TypeType,
-	"nil":    NilType,
-	"true":   TrueType,
-	"false":  FalseType,
+	"if":        IfType,
+	"else":      ElseType,
+	"func":      FuncType,
+	"return":    ReturnType,
+	"break":     BreakType,
+	"continue":  ContinueType,
+	"range":     RangeType,
+	"try":       TryType,
+	"catch":     CatchType,
+	"import":    ImportType,
+	"for":       ForType,
+	"var":       VarType,
+	"type":      TypeType,
+	"nil":       NilType,
+	"true":      TrueType,
+	"false":     FalseType,
+	"interface": InterfaceType,
+	"is":        IsType,
+	"switch":    SwitchType,
+	"case":      CaseType,
+	"default":   DefaultType,
 }
@@ -0,0 +1,282 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/parser"
+	"gitlab.com/akzj/qp/runtime"
+	stackmachine "gitlab.com/akzj/qp/stack-machine"
+	"gitlab.com/akzj/qp/types"
+)
+
+// document is source opened by client,it is parsed when changed
+type document struct {
+	uri         string
+	file        string
+	lines       []string
+	definitions []parser.Definition
+	errs        []error
+}
+
+func newDocument(uri string, text string, path []string) *document {
+	d := &document{uri: uri, file: uriToFile(uri), lines: strings.Split(text, "\n")}
+	p := parser.New(text).SetFile(d.file).SetImporter(parser.NewImporter(path))
+	statements, errs := p.ParseWithErrors()
+	if d.errs = errs; len(errs) == 0 {
+		d.errs = types.Check(p, statements)
+	}
+	for _, definition := range p.Definitions() {
+		if definition.Position.File == d.file {
+			d.definitions = append(d.definitions, definition)
+		}
+	}
+	return d
+}
+
+func uriToFile(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		return filepath.FromSlash(u.Path)
+	}
+	return uri
+}
+
+// diagnostics return syntax and type errors,errors of other files are shown at
+// the begin of document
+func (d *document) diagnostics() []Diagnostic {
+	diagnostics := []Diagnostic{}
+	for _, err := range d.errs {
+		diagnostic := Diagnostic{Severity: severityError, Source: "qp", Message: err.Error()}
+		if err, ok := err.(*parser.Error); ok {
+			diagnostic.Message = err.Msg
+			if err.File == d.file && err.Line > 0 {
+				start := d.position(err.Position)
+				diagnostic.Range = Range{Start: start, End: Position{Line: start.Line, Character: start.Character + 1}}
+			}
+		}
+		diagnostics = append(diagnostics, diagnostic)
+	}
+	return diagnostics
+}
+
+// position convert one based line and byte column to LSP position
+func (d *document) position(pos lexer.Position) Position {
+	line := pos.Line - 1
+	if line < 0 || line >= len(d.lines) {
+		return Position{Line: line}
+	}
+	column := pos.Column - 1
+	if column > len(d.lines[line]) {
+		column = len(d.lines[line])
+	}
+	return Position{Line: line, Character: utf16Len(d.lines[line][:column])}
+}
+
+// offset return byte offset of LSP position in line
+func (d *document) offset(pos Position) (string, int) {
+	if pos.Line < 0 || pos.Line >= len(d.lines) {
+		return "", 0
+	}
+	line := d.lines[pos.Line]
+	var units int
+	for offset, r := range line {
+		if units >= pos.Character {
+			return line, offset
+		}
+		units += len(utf16.Encode([]rune{r}))
+	}
+	return line, len(line)
+}
+
+func utf16Len(s string) int {
+	var n int
+	for _, r := range s {
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
+
+func isIdentifier(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= utf8.RuneSelf
+}
+
+// word return identifier at pos and the identifier before `.` of it,end
+// is false for word only before pos,for completion
+func (d *document) word(pos Position, end bool) (qualifier, word string) {
+	line, offset := d.offset(pos)
+	begin := offset
+	for begin > 0 && isIdentifier(line[begin-1]) {
+		begin--
+	}
+	stop := offset
+	for end && stop < len(line) && isIdentifier(line[stop]) {
+		stop++
+	}
+	word = line[begin:stop]
+	if begin > 0 && line[begin-1] == '.' {
+		index := begin - 1
+		for index > 0 && isIdentifier(line[index-1]) {
+			index--
+		}
+		qualifier = line[index : begin-1]
+	}
+	return qualifier, word
+}
+
+// lookup return definition of identifier,`qualifier.word` is method when
+// qualifier is type,or any method named word
+func (d *document) lookup(qualifier, word string) (parser.Definition, bool) {
+	if qualifier != "" {
+		for _, definition := range d.definitions {
+			if definition.Kind == parser.MethodDefinition && definition.Name == qualifier+"."+word {
+				return definition, true
+			}
+		}
+		for _, definition := range d.definitions {
+			if definition.Kind == parser.MethodDefinition && strings.HasSuffix(definition.Name, "."+word) {
+				return definition, true
+			}
+		}
+		return parser.Definition{}, false
+	}
+	for _, definition := range d.definitions {
+		if definition.Kind != parser.MethodDefinition && definition.Name == word {
+			return definition, true
+		}
+	}
+	return parser.Definition{}, false
+}
+
+// nameRange return range of name of definition
+func (d *document) nameRange(definition parser.Definition) Range {
+	start := d.position(definition.Position)
+	name := definition.Name
+	if index := strings.Index(name, "."); index != -1 {
+		name = name[:index]
+	}
+	return Range{Start: start, End: Position{Line: start.Line, Character: start.Character + utf16Len(name)}}
+}
+
+func (d *document) symbols() []DocumentSymbol {
+	symbols := []DocumentSymbol{}
+	for _, definition := range d.definitions {
+		kind := symbolFunction
+		switch definition.Kind {
+		case parser.MethodDefinition:
+			kind = symbolMethod
+		case parser.TypeDefinition:
+			kind = symbolClass
+		case parser.InterfaceDefinition:
+			kind = symbolInterface
+		}
+		r := d.nameRange(definition)
+		symbols = append(symbols, DocumentSymbol{
+			Name:           definition.Name,
+			Detail:         definition.Signature(),
+			Kind:           kind,
+			Range:          r,
+			SelectionRange: r,
+		})
+	}
+	return symbols
+}
+
+// builtins return names of functions built in tree engine and stack machine
+func builtins() []string {
+	var names []string
+	seen := map[string]bool{}
+	for name := range runtime.Functions {
+		seen[name] = true
+		names = append(names, name)
+	}
+	for _, function := range stackmachine.BuiltInFunctions {
+		if seen[function.Name] == false {
+			seen[function.Name] = true
+			names = append(names, function.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isBuiltin(name string) bool {
+	for _, it := range builtins() {
+		if it == name {
+			return true
+		}
+	}
+	return false
+}
+
+// completion return items for identifier before pos,fields and methods
+// are completed after `.`
+func (d *document) completion(pos Position) []CompletionItem {
+	line, offset := d.offset(pos)
+	qualifier, prefix := d.word(pos, false)
+	begin := offset - len(prefix)
+	items := []CompletionItem{}
+	seen := map[string]bool{}
+	add := func(item CompletionItem) {
+		if seen[item.Label] || strings.HasPrefix(item.Label, prefix) == false {
+			return
+		}
+		seen[item.Label] = true
+		items = append(items, item)
+	}
+	if begin > 0 && line[begin-1] == '.' {
+		var typeName string
+		if definition, ok := d.lookup("", qualifier); ok && definition.Kind == parser.TypeDefinition {
+			typeName = definition.Name
+		}
+		for _, definition := range d.definitions {
+			switch definition.Kind {
+			case parser.MethodDefinition:
+				index := strings.Index(definition.Name, ".")
+				if typeName == "" || definition.Name[:index] == typeName {
+					add(CompletionItem{Label: definition.Name[index+1:], Kind: completionMethod, Detail: definition.Signature()})
+				}
+			case parser.TypeDefinition:
+				if typeName == "" || definition.Name == typeName {
+					for _, field := range definition.Fields {
+						add(CompletionItem{Label: field, Kind: completionField, Detail: definition.Name + "." + field})
+					}
+				}
+			}
+		}
+		if typeName == "" {
+			for _, functions := range []map[string]*runtime.Object{runtime.ArrayFunctions, runtime.StringFunctions} {
+				var names []string
+				for name := range functions {
+					names = append(names, name)
+				}
+				sort.Strings(names)
+				for _, name := range names {
+					add(CompletionItem{Label: name, Kind: completionMethod})
+				}
+			}
+		}
+		return items
+	}
+	for _, definition := range d.definitions {
+		switch definition.Kind {
+		case parser.FunctionDefinition:
+			add(CompletionItem{Label: definition.Name, Kind: completionFunction, Detail: definition.Signature()})
+		case parser.TypeDefinition:
+			add(CompletionItem{Label: definition.Name, Kind: completionClass, Detail: definition.Signature()})
+		case parser.InterfaceDefinition:
+			add(CompletionItem{Label: definition.Name, Kind: completionInterface, Detail: definition.Signature()})
+		}
+	}
+	for _, name := range builtins() {
+		add(CompletionItem{Label: name, Kind: completionFunction, Detail: "built in function"})
+	}
+	for _, keyword := range lexer.Keywords {
+		add(CompletionItem{Label: keyword, Kind: completionKeyword})
+	}
+	return items
+}
@@ -0,0 +1,191 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gitlab.com/akzj/qp/internal/framing"
+)
+
+// Server is language server of qp,messages are read from in and written
+// to out with the base protocol of package framing
+type Server struct {
+	in        *bufio.Reader
+	out       io.Writer
+	path      []string // module search path
+	documents map[string]*document
+}
+
+// NewServer make Server,modules imported by documents are searched in path
+func NewServer(in io.Reader, out io.Writer, path []string) *Server {
+	return &Server{
+		in:        bufio.NewReader(in),
+		out:       out,
+		path:      path,
+		documents: map[string]*document{},
+	}
+}
+
+// Serve handle messages until exit notification or in closed
+func (s *Server) Serve() error {
+	for {
+		content, err := framing.ReadMessage(s.in)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		var request message
+		if err := json.Unmarshal(content, &request); err != nil {
+			return fmt.Errorf("invalid message: %s", err.Error())
+		}
+		if request.Method == "exit" {
+			return nil
+		}
+		result, failure := s.handle(request)
+		if request.ID == nil {
+			continue
+		}
+		response := message{JSONRPC: "2.0", ID: request.ID, Result: result}
+		if failure != nil {
+			response.Result = nil
+			response.Error = failure
+		} else if result == nil {
+			response.Result = json.RawMessage("null")
+		}
+		if err := framing.WriteMessage(s.out, response); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(request message) (interface{}, *responseError) {
+	switch request.Method {
+	case "initialize":
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":       1, // full
+				"definitionProvider":     true,
+				"hoverProvider":          true,
+				"documentSymbolProvider": true,
+				"completionProvider": map[string]interface{}{
+					"triggerCharacters": []string{"."},
+				},
+			},
+			"serverInfo": map[string]interface{}{"name": "qp"},
+		}, nil
+	case "initialized", "shutdown", "$/cancelRequest":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, paramsError(err)
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, paramsError(err)
+		}
+		if count := len(params.ContentChanges); count != 0 {
+			s.update(params.TextDocument.URI, params.ContentChanges[count-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseParams
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, paramsError(err)
+		}
+		delete(s.documents, params.TextDocument.URI)
+		s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+		return nil, nil
+	case "textDocument/definition":
+		return s.positionRequest(request, s.definition)
+	case "textDocument/hover":
+		return s.positionRequest(request, s.hover)
+	case "textDocument/completion":
+		return s.positionRequest(request, func(d *document, pos Position) interface{} {
+			return d.completion(pos)
+		})
+	case "textDocument/documentSymbol":
+		var params struct {
+			TextDocument textDocumentIdentifier `json:"textDocument"`
+		}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, paramsError(err)
+		}
+		if d, ok := s.documents[params.TextDocument.URI]; ok {
+			return d.symbols(), nil
+		}
+		return []DocumentSymbol{}, nil
+	}
+	if request.ID == nil {
+		// notification no supported is ignored
+		return nil, nil
+	}
+	return nil, &responseError{Code: methodNotFound, Message: "method `" + request.Method + "` no supported"}
+}
+
+func paramsError(err error) *responseError {
+	return &responseError{Code: invalidParams, Message: err.Error()}
+}
+
+// positionRequest handle request of position in document,the result is
+// null when document no opened
+func (s *Server) positionRequest(request message,
+	fn func(d *document, pos Position) interface{}) (interface{}, *responseError) {
+	var params textDocumentPositionParams
+	if err := json.Unmarshal(request.Params, &params); err != nil {
+		return nil, paramsError(err)
+	}
+	d, ok := s.documents[params.TextDocument.URI]
+	if ok == false {
+		return nil, nil
+	}
+	return fn(d, params.Position), nil
+}
+
+// update parse document and publish its diagnostics
+func (s *Server) update(uri string, text string) {
+	d := newDocument(uri, text, s.path)
+	s.documents[uri] = d
+	s.notify("textDocument/publishDiagnostics", publishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: d.diagnostics(),
+	})
+}
+
+func (s *Server) definition(d *document, pos Position) interface{} {
+	definition, ok := d.lookup(d.word(pos, true))
+	if ok == false {
+		return nil
+	}
+	return Location{URI: d.uri, Range: d.nameRange(definition)}
+}
+
+func (s *Server) hover(d *document, pos Position) interface{} {
+	qualifier, word := d.word(pos, true)
+	if word == "" {
+		return nil
+	}
+	var signature string
+	if definition, ok := d.lookup(qualifier, word); ok {
+		signature = definition.Signature()
+	} else if qualifier == "" && isBuiltin(word) {
+		signature = "func " + word + "(...) // built in"
+	} else {
+		return nil
+	}
+	return Hover{Contents: markupContent{Kind: "markdown", Value: "```qp\n" + signature + "\n```"}}
+}
+
+func (s *Server) notify(method string, params interface{}) {
+	data, _ := json.Marshal(params)
+	_ = framing.WriteMessage(s.out, message{JSONRPC: "2.0", Method: method, Params: data})
+}
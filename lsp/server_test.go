@@ -0,0 +1,193 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp/internal/framing"
+)
+
+// client is scripted LSP client of Server
+type client struct {
+	t           *testing.T
+	in          io.WriteCloser
+	out         *bufio.Reader
+	id          int
+	diagnostics map[string][]Diagnostic
+}
+
+func newClient(t *testing.T) *client {
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	go func() {
+		_ = NewServer(serverIn, serverOut, nil).Serve()
+		serverOut.Close()
+	}()
+	return &client{t: t, in: clientOut, out: bufio.NewReader(clientIn), diagnostics: map[string][]Diagnostic{}}
+}
+
+func (c *client) send(message map[string]interface{}) {
+	c.t.Helper()
+	message["jsonrpc"] = "2.0"
+	if err := framing.WriteMessage(c.in, message); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// notify send notification,diagnostics published are read
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+	c.send(map[string]interface{}{"method": method, "params": params})
+	if method == "textDocument/didOpen" || method == "textDocument/didChange" {
+		c.read()
+	}
+}
+
+// read read message,diagnostics are recorded
+func (c *client) read() (id int, result json.RawMessage) {
+	c.t.Helper()
+	content, err := framing.ReadMessage(c.out)
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	var m struct {
+		ID     int
+		Method string
+		Params publishDiagnosticsParams
+		Result json.RawMessage
+		Error  *responseError
+	}
+	if err := json.Unmarshal(content, &m); err != nil {
+		c.t.Fatal(err)
+	}
+	if m.Error != nil {
+		c.t.Fatal(m.Error.Message)
+	}
+	if m.Method == "textDocument/publishDiagnostics" {
+		c.diagnostics[m.Params.URI] = m.Params.Diagnostics
+	}
+	return m.ID, m.Result
+}
+
+func (c *client) request(method string, params interface{}, result interface{}) {
+	c.t.Helper()
+	c.id++
+	c.send(map[string]interface{}{"id": c.id, "method": method, "params": params})
+	for {
+		if id, data := c.read(); id == c.id {
+			if err := json.Unmarshal(data, result); err != nil {
+				c.t.Fatal(err)
+			}
+			return
+		}
+	}
+}
+
+func (c *client) at(uri string, line, character int) map[string]interface{} {
+	return map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+		"position":     map[string]interface{}{"line": line, "character": character},
+	}
+}
+
+const source = `type List{
+	head: nil
+	size: 0
+}
+func List.insert(value){
+	this.size++
+}
+func newList(){
+	return List{}
+}
+var list = newList()
+list.insert(1)
+println(list.size)
+`
+
+func TestServer(t *testing.T) {
+	uri := "file:///tmp/qp-lsp/main.qp"
+	c := newClient(t)
+	var initialize struct {
+		Capabilities map[string]interface{}
+	}
+	c.request("initialize", map[string]interface{}{}, &initialize)
+	if initialize.Capabilities["definitionProvider"] != true {
+		t.Fatalf("unexpected capabilities %+v", initialize.Capabilities)
+	}
+	c.notify("initialized", map[string]interface{}{})
+	c.notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri, "languageId": "qp", "version": 1, "text": source},
+	})
+	if len(c.diagnostics[uri]) != 0 {
+		t.Fatalf("unexpected diagnostics %+v", c.diagnostics[uri])
+	}
+
+	var location Location
+	c.request("textDocument/definition", c.at(uri, 10, 12), &location)
+	if location.Range.Start != (Position{Line: 7, Character: 5}) {
+		t.Fatalf("definition of newList: %+v", location)
+	}
+	c.request("textDocument/definition", c.at(uri, 11, 7), &location)
+	if location.Range.Start != (Position{Line: 4, Character: 5}) {
+		t.Fatalf("definition of insert: %+v", location)
+	}
+	c.request("textDocument/definition", c.at(uri, 8, 9), &location)
+	if location.Range.Start != (Position{Line: 0, Character: 5}) {
+		t.Fatalf("definition of List: %+v", location)
+	}
+
+	var hover Hover
+	c.request("textDocument/hover", c.at(uri, 11, 8), &hover)
+	if strings.Contains(hover.Contents.Value, "func List.insert(value)") == false {
+		t.Fatalf("unexpected hover %+v", hover)
+	}
+	c.request("textDocument/hover", c.at(uri, 12, 2), &hover)
+	if strings.Contains(hover.Contents.Value, "println") == false {
+		t.Fatalf("unexpected hover of builtin %+v", hover)
+	}
+
+	var symbols []DocumentSymbol
+	c.request("textDocument/documentSymbol", map[string]interface{}{
+		"textDocument": map[string]interface{}{"uri": uri},
+	}, &symbols)
+	var names []string
+	for _, symbol := range symbols {
+		names = append(names, symbol.Name)
+	}
+	if strings.Join(names, " ") != "List List.insert newList" {
+		t.Fatalf("unexpected symbols %v", names)
+	}
+
+	labels := func(items []CompletionItem) map[string]int {
+		kinds := map[string]int{}
+		for _, item := range items {
+			kinds[item.Label] = item.Kind
+		}
+		return kinds
+	}
+	var items []CompletionItem
+	c.request("textDocument/completion", c.at(uri, 12, 3), &items)
+	if kinds := labels(items); kinds["println"] != completionFunction || kinds["print"] != completionFunction || len(kinds) != 2 {
+		t.Fatalf("unexpected completion of `pri` %+v", items)
+	}
+	c.request("textDocument/completion", c.at(uri, 12, 13), &items)
+	if kinds := labels(items); kinds["size"] != completionField || kinds["insert"] != completionMethod {
+		t.Fatalf("unexpected completion of `list.` %+v", items)
+	}
+
+	c.notify("textDocument/didChange", map[string]interface{}{
+		"textDocument":   map[string]interface{}{"uri": uri, "version": 2},
+		"contentChanges": []map[string]interface{}{{"text": "var a = 1\nfunc f(){\n\treturn )\n}\n"}},
+	})
+	diagnostics := c.diagnostics[uri]
+	if len(diagnostics) == 0 || diagnostics[0].Range.Start != (Position{Line: 2, Character: 8}) {
+		t.Fatalf("unexpected diagnostics %+v", diagnostics)
+	}
+	var result interface{}
+	c.request("shutdown", nil, &result)
+	c.notify("exit", nil)
+}
@@ -0,0 +1,409 @@
+package qp
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/builtin"
+	"gitlab.com/akzj/qp/internal/marshal"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+var valueType = reflect.TypeOf((*Value)(nil)).Elem()
+
+// ToValue convert Go value to qp value
+//
+//	int,uint kinds    -> ast.Int
+//	float kinds       -> ast.Float
+//	bool              -> ast.Bool
+//	string            -> ast.String
+//	slice,array       -> *ast.Array
+//	map               -> *ast.Map,key must be string,int or bool kind
+//	struct            -> *ast.TypeObject,fields are named by `qp` tag or field name
+//	time.Time         -> ast.TimeObject
+//	time.Duration     -> ast.DurationObject
+//	func              -> built in function,a non nil error result aborts the script
+//	nil,nil pointer   -> ast.NilObject
+//
+// value which refers to itself returns error
+func ToValue(value interface{}) (Value, error) {
+	if value == nil {
+		return ast.NilObj, nil
+	}
+	if value, ok := value.(Value); ok {
+		return value, nil
+	}
+	return toValue(reflect.ValueOf(value), marshal.Visiting{})
+}
+
+func toValue(value reflect.Value, visiting marshal.Visiting) (Value, error) {
+	if value.IsValid() == false {
+		return ast.NilObj, nil
+	}
+	if value.Type().Implements(valueType) && value.CanInterface() {
+		if value.Kind() == reflect.Ptr && value.IsNil() {
+			return ast.NilObj, nil
+		}
+		return value.Interface().(Value), nil
+	}
+	switch value.Type() {
+	case marshal.TimeType:
+		return ast.TimeObject(value.Interface().(time.Time)), nil
+	case marshal.DurationType:
+		return ast.DurationObject(value.Int()), nil
+	}
+	switch value.Kind() {
+	case reflect.Bool:
+		return ast.Bool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ast.Int(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if value.Uint() > math.MaxInt64 {
+			return nil, fmt.Errorf("%d overflows int", value.Uint())
+		}
+		return ast.Int(value.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return ast.Float(value.Float()), nil
+	case reflect.String:
+		return ast.String(value.String()), nil
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return ast.NilObj, nil
+		}
+		if visiting.Enter(value) == false {
+			return nil, cycleError(value.Type())
+		}
+		defer visiting.Leave(value)
+		array := &ast.Array{Data: make([]runtime.Invokable, 0, value.Len())}
+		for i := 0; i < value.Len(); i++ {
+			element, err := toValue(value.Index(i), visiting)
+			if err != nil {
+				return nil, err
+			}
+			array.Data = append(array.Data, element)
+		}
+		return array, nil
+	case reflect.Map:
+		if value.IsNil() {
+			return ast.NilObj, nil
+		}
+		if marshal.IsMapKey(value.Type().Key()) == false {
+			return nil, fmt.Errorf("invalid map key type `%s`", value.Type().Key())
+		}
+		if visiting.Enter(value) == false {
+			return nil, cycleError(value.Type())
+		}
+		defer visiting.Leave(value)
+		object := ast.NewMap()
+		for _, key := range marshal.SortedKeys(value) {
+			mapKey, err := toValue(key, visiting)
+			if err != nil {
+				return nil, err
+			}
+			element, err := toValue(value.MapIndex(key), visiting)
+			if err != nil {
+				return nil, err
+			}
+			object.Alloc(mapKey).Pointer = element
+		}
+		return object, nil
+	case reflect.Struct:
+		object := &ast.TypeObject{Label: value.Type().Name()}
+		for _, field := range marshal.StructFields(value.Type()) {
+			element, err := toValue(value.FieldByIndex(field.Index), visiting)
+			if err != nil {
+				return nil, fmt.Errorf("field `%s`: %w", field.Name, err)
+			}
+			object.AllocObject(field.Name).Pointer = element
+		}
+		return object, nil
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return ast.NilObj, nil
+		}
+		if visiting.Enter(value) == false {
+			return nil, cycleError(value.Type())
+		}
+		defer visiting.Leave(value)
+		return toValue(value.Elem(), visiting)
+	case reflect.Func:
+		if value.IsNil() {
+			return ast.NilObj, nil
+		}
+		return wrapFunc(value)
+	}
+	return nil, fmt.Errorf("unsupported type `%s`", value.Type())
+}
+
+func wrapFunc(fn reflect.Value) (Value, error) {
+	function := marshal.NewFunc(fn)
+	return builtin.NewFunction(function.Name(), func(arguments ...runtime.Invokable) runtime.Invokable {
+		out, err := function.Call(len(arguments), func(index int, dst reflect.Value) error {
+			return fromValue(arguments[index], dst, marshal.Visiting{})
+		})
+		if err != nil {
+			panic(hostError{err: err})
+		}
+		if len(out) == 0 {
+			return ast.NilObj
+		}
+		result, err := toValue(out[0], marshal.Visiting{})
+		if err != nil {
+			panic(err)
+		}
+		return result
+	}).Pointer, nil
+}
+
+// FromValue store qp value to the Go value dst points to,
+// it is the reverse of ToValue.Decoding to interface{} makes
+// int64,float64,bool,string,[]interface{},map[string]interface{},time.Time,
+// time.Duration or nil,a map with non string keys makes map[interface{}]interface{}.
+// value which refers to itself returns error
+func FromValue(value Value, dst interface{}) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return fmt.Errorf("dst require non nil pointer")
+	}
+	return fromValue(value, ptr.Elem(), marshal.Visiting{})
+}
+
+func fromValue(value Value, dst reflect.Value, visiting marshal.Visiting) error {
+	value = unwrap(value)
+	if value == nil {
+		value = ast.NilObj
+	}
+	if dst.Type().Implements(valueType) && reflect.TypeOf(value).AssignableTo(dst.Type()) {
+		dst.Set(reflect.ValueOf(value))
+		return nil
+	}
+	if _, ok := value.(ast.NilObject); ok {
+		switch dst.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		return typeError(value, dst.Type())
+	}
+	switch dst.Type() {
+	case marshal.TimeType:
+		if val, ok := value.(ast.TimeObject); ok {
+			dst.Set(reflect.ValueOf(time.Time(val)))
+			return nil
+		}
+		return typeError(value, dst.Type())
+	case marshal.DurationType:
+		switch val := value.(type) {
+		case ast.DurationObject:
+			dst.SetInt(int64(val))
+		case ast.Int:
+			dst.SetInt(int64(val))
+		default:
+			return typeError(value, dst.Type())
+		}
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return typeError(value, dst.Type())
+		}
+		val, err := toInterface(value, visiting)
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+		} else {
+			dst.Set(reflect.ValueOf(val))
+		}
+	case reflect.Bool:
+		val, ok := value.(ast.Bool)
+		if ok == false {
+			return typeError(value, dst.Type())
+		}
+		dst.SetBool(bool(val))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		val, ok := value.(ast.Int)
+		if ok == false {
+			return typeError(value, dst.Type())
+		}
+		if dst.OverflowInt(int64(val)) {
+			return fmt.Errorf("%d overflows `%s`", val, dst.Type())
+		}
+		dst.SetInt(int64(val))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		val, ok := value.(ast.Int)
+		if ok == false {
+			return typeError(value, dst.Type())
+		}
+		if val < 0 || dst.OverflowUint(uint64(val)) {
+			return fmt.Errorf("%d overflows `%s`", val, dst.Type())
+		}
+		dst.SetUint(uint64(val))
+	case reflect.Float32, reflect.Float64:
+		switch val := value.(type) {
+		case ast.Float:
+			dst.SetFloat(float64(val))
+		case ast.Int:
+			dst.SetFloat(float64(val))
+		default:
+			return typeError(value, dst.Type())
+		}
+	case reflect.String:
+		val, ok := value.(ast.String)
+		if ok == false {
+			return typeError(value, dst.Type())
+		}
+		dst.SetString(string(val))
+	case reflect.Slice, reflect.Array:
+		array, ok := value.(*ast.Array)
+		if ok == false {
+			return typeError(value, dst.Type())
+		}
+		if visiting.Enter(reflect.ValueOf(array)) == false {
+			return cycleError(dst.Type())
+		}
+		defer visiting.Leave(reflect.ValueOf(array))
+		if dst.Kind() == reflect.Slice {
+			dst.Set(reflect.MakeSlice(dst.Type(), len(array.Data), len(array.Data)))
+		} else if dst.Len() != len(array.Data) {
+			return fmt.Errorf("array length %d no match `%s`", len(array.Data), dst.Type())
+		}
+		for index, element := range array.Data {
+			if err := fromValue(element, dst.Index(index), visiting); err != nil {
+				return fmt.Errorf("index %d: %w", index, err)
+			}
+		}
+	case reflect.Map:
+		if visiting.Enter(reflect.ValueOf(value)) == false {
+			return cycleError(dst.Type())
+		}
+		defer visiting.Leave(reflect.ValueOf(value))
+		if object, ok := value.(*ast.Map); ok {
+			dst.Set(reflect.MakeMap(dst.Type()))
+			for _, key := range object.Keys() {
+				mapKey := reflect.New(dst.Type().Key()).Elem()
+				if err := fromValue(key, mapKey, visiting); err != nil {
+					return fmt.Errorf("key %s: %w", key.String(), err)
+				}
+				element := reflect.New(dst.Type().Elem()).Elem()
+				if err := fromValue(object.Get(key), element, visiting); err != nil {
+					return fmt.Errorf("key %s: %w", key.String(), err)
+				}
+				dst.SetMapIndex(mapKey, element)
+			}
+			return nil
+		}
+		object, ok := value.(*ast.TypeObject)
+		if ok == false || dst.Type().Key().Kind() != reflect.String {
+			return typeError(value, dst.Type())
+		}
+		dst.Set(reflect.MakeMap(dst.Type()))
+		for name, field := range fields(object) {
+			element := reflect.New(dst.Type().Elem()).Elem()
+			if err := fromValue(field, element, visiting); err != nil {
+				return fmt.Errorf("field `%s`: %w", name, err)
+			}
+			dst.SetMapIndex(reflect.ValueOf(name).Convert(dst.Type().Key()), element)
+		}
+	case reflect.Struct:
+		object, ok := value.(*ast.TypeObject)
+		if ok == false {
+			return typeError(value, dst.Type())
+		}
+		if visiting.Enter(reflect.ValueOf(object)) == false {
+			return cycleError(dst.Type())
+		}
+		defer visiting.Leave(reflect.ValueOf(object))
+		objects := fields(object)
+		for _, field := range marshal.StructFields(dst.Type()) {
+			if element, ok := objects[field.Name]; ok {
+				if err := fromValue(element, dst.FieldByIndex(field.Index), visiting); err != nil {
+					return fmt.Errorf("field `%s`: %w", field.Name, err)
+				}
+			}
+		}
+	case reflect.Ptr:
+		element := reflect.New(dst.Type().Elem())
+		if err := fromValue(value, element.Elem(), visiting); err != nil {
+			return err
+		}
+		dst.Set(element)
+	default:
+		return typeError(value, dst.Type())
+	}
+	return nil
+}
+
+// fields return data fields of object,methods are skipped
+func fields(object *ast.TypeObject) map[string]Value {
+	values := map[string]Value{}
+	for name, field := range object.GetObjects() {
+		if _, ok := field.Pointer.(ast.Function); ok {
+			continue
+		}
+		values[name] = field.Pointer
+	}
+	return values
+}
+
+func toInterface(value Value, visiting marshal.Visiting) (interface{}, error) {
+	switch val := value.(type) {
+	case ast.Int:
+		return int64(val), nil
+	case ast.Float:
+		return float64(val), nil
+	case ast.Bool:
+		return bool(val), nil
+	case ast.String:
+		return string(val), nil
+	case ast.TimeObject:
+		return time.Time(val), nil
+	case ast.DurationObject:
+		return time.Duration(val), nil
+	case ast.NilObject:
+		return nil, nil
+	case *ast.Array:
+		var values []interface{}
+		if err := fromValue(val, reflect.ValueOf(&values).Elem(), visiting); err != nil {
+			return nil, err
+		}
+		return values, nil
+	case *ast.Map:
+		for _, key := range val.Keys() {
+			if _, ok := key.(ast.String); ok == false {
+				var values map[interface{}]interface{}
+				if err := fromValue(val, reflect.ValueOf(&values).Elem(), visiting); err != nil {
+					return nil, err
+				}
+				return values, nil
+			}
+		}
+		var values map[string]interface{}
+		if err := fromValue(val, reflect.ValueOf(&values).Elem(), visiting); err != nil {
+			return nil, err
+		}
+		return values, nil
+	case *ast.TypeObject:
+		var values map[string]interface{}
+		if err := fromValue(val, reflect.ValueOf(&values).Elem(), visiting); err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported value `%s`", reflect.TypeOf(value))
+}
+
+// cycleError is returned when value converted to or from typ refers to
+// itself
+func cycleError(typ reflect.Type) error {
+	return fmt.Errorf("cycle in value of `%s`", typ)
+}
+
+func typeError(value Value, typ reflect.Type) error {
+	return fmt.Errorf("cannot store %s `%s` to `%s`", value.GetType().String(), value.String(), typ)
+}
@@ -0,0 +1,123 @@
+package qp
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/akzj/qp/ast"
+)
+
+type user struct {
+	Name    string
+	Age     int `qp:"age"`
+	Tags    []string
+	Created time.Time
+	Timeout time.Duration
+	Friend  *user
+	secret  string
+}
+
+func TestMarshalRoundTrip(t *testing.T) {
+	now := time.Now()
+	in := user{
+		Name:    "akzj",
+		Age:     18,
+		Tags:    []string{"a", "b"},
+		Created: now,
+		Timeout: time.Second,
+		Friend:  &user{Name: "jojo"},
+		secret:  "secret",
+	}
+	value, err := ToValue(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	object, ok := value.(*ast.TypeObject)
+	if ok == false || object.Label != "user" {
+		t.Fatalf("expect TypeObject user,found %s", value)
+	}
+	if object.GetObject("age").Pointer != ast.Int(18) {
+		t.Fatal("field `age` no match")
+	}
+	if object.GetObject("secret") != nil {
+		t.Fatal("unexported field converted")
+	}
+	var out user
+	if err := FromValue(value, &out); err != nil {
+		t.Fatal(err)
+	}
+	in.secret = ""
+	if reflect.DeepEqual(in, out) == false {
+		t.Fatalf("%+v\n%+v", in, out)
+	}
+
+	var generic interface{}
+	if err := FromValue(value, &generic); err != nil {
+		t.Fatal(err)
+	}
+	if generic.(map[string]interface{})["Tags"].([]interface{})[1] != "b" {
+		t.Fatalf("%+v", generic)
+	}
+
+	var small int8
+	if err := FromValue(ast.Int(1000), &small); err == nil {
+		t.Fatal("expect overflow error")
+	}
+}
+
+func TestMarshalFunc(t *testing.T) {
+	join, err := ToValue(func(sep string, items ...string) string {
+		return strings.Join(items, sep)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fail, _ := ToValue(func() error {
+		return errors.New("failed")
+	})
+	interpreter := New(Options{})
+	interpreter.SetGlobal("join", join)
+	interpreter.SetGlobal("fail", fail)
+	value, err := interpreter.Eval(`return join("-","a","b","c")`)
+	if err != nil || value != ast.String("a-b-c") {
+		t.Fatal(value, err)
+	}
+	if _, err := interpreter.Eval(`fail()`); err == nil || err.Error() != "failed" {
+		t.Fatal(err)
+	}
+}
+
+func TestMarshalCycle(t *testing.T) {
+	shared := &user{Name: "shared"}
+	if _, err := ToValue([]*user{shared, shared}); err != nil {
+		t.Fatal(err)
+	}
+	self := &user{Name: "self"}
+	self.Friend = self
+	if _, err := ToValue(self); err == nil || err.Error() != "field `Friend`: cycle in value of `*qp.user`" {
+		t.Fatal(err)
+	}
+	value, err := New(Options{}).Eval(`type Node {
+	next
+}
+var node = Node{}
+node.next = node
+return node
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Next interface{} `qp:"next"`
+	}
+	if err := FromValue(value, &out); err == nil || strings.Contains(err.Error(), "cycle") == false {
+		t.Fatal(err)
+	}
+	var generic interface{}
+	if err := FromValue(value, &generic); err == nil || strings.Contains(err.Error(), "cycle") == false {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,82 @@
+package parser
+
+import (
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+// isAnnotation check type annotation follow token in the same line
+func (p *Parser) isAnnotation(token lexer.Token) bool {
+	ahead := p.ahead(0)
+	return ahead.Typ == lexer.IDType && ahead.Line == token.Line
+}
+
+// isFieldEnd check field declared by token has no type and default: token
+// is followed by new line,`,`,`;` or `}`
+func (p *Parser) isFieldEnd(token lexer.Token) bool {
+	switch ahead := p.ahead(0); ahead.Typ {
+	case lexer.CommaType, lexer.SemicolonType, lexer.RightBraceType:
+		return true
+	default:
+		return ahead.Line != token.Line
+	}
+}
+
+/*
+typeName:
+
+	|ID
+	|ID.ID
+*/
+func (p *Parser) parseTypeName() string {
+	token := p.nextToken()
+	p.expectType(token, lexer.IDType)
+	if p.ahead(0).Typ == lexer.PeriodType && p.ahead(1).Typ == lexer.IDType {
+		p.nextToken()
+		return token.Val + "." + p.nextToken().Val
+	}
+	return token.Val
+}
+
+/*
+returnTypes:
+
+	|typeName
+	|(typeName,...)
+*/
+func (p *Parser) parseReturnTypes() []string {
+	if p.ahead(0).Typ == lexer.IDType {
+		return []string{p.parseTypeName()}
+	}
+	if p.ahead(0).Typ != lexer.LeftParenthesisType {
+		return nil
+	}
+	p.nextToken()
+	var types []string
+	for {
+		types = append(types, p.parseTypeName())
+		next := p.nextToken()
+		if next.Typ == lexer.RightParenthesisType {
+			return types
+		}
+		if next.Typ != lexer.CommaType {
+			p.errorAt(next, "expect `,` or `)`, found %s", describe(next))
+		}
+	}
+}
+
+// zeroValue return the initial value of var or field of type
+func zeroValue(typ string) runtime.Invokable {
+	switch typ {
+	case "int":
+		return ast.Int(0)
+	case "float":
+		return ast.Float(0)
+	case "string":
+		return ast.String("")
+	case "bool":
+		return ast.Bool(false)
+	}
+	return ast.NilObject{}
+}
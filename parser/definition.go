@@ -0,0 +1,49 @@
+package parser
+
+import (
+	"strings"
+
+	"gitlab.com/akzj/qp/lexer"
+)
+
+// DefinitionKind is the kind of Definition
+type DefinitionKind int
+
+const (
+	FunctionDefinition DefinitionKind = iota
+	MethodDefinition
+	TypeDefinition
+	InterfaceDefinition
+)
+
+// Definition is function,method or type declared in source,for tools
+// like language server
+type Definition struct {
+	Kind       DefinitionKind
+	Name       string         // `List.insert` for method
+	Position   lexer.Position // position of name
+	Parameters []string       // parameters of function,`this` is excluded
+	Fields     []string       // fields of type,methods of interface
+}
+
+// Signature return the declaration of definition without body
+func (d Definition) Signature() string {
+	if d.Kind == TypeDefinition {
+		return "type " + d.Name + "{" + strings.Join(d.Fields, ", ") + "}"
+	}
+	if d.Kind == InterfaceDefinition {
+		return "interface " + d.Name + "{" + strings.Join(d.Fields, "; ") + "}"
+	}
+	return "func " + d.Name + "(" + strings.Join(d.Parameters, ", ") + ")"
+}
+
+// Definitions return functions,methods and types declared in the sources
+// parsed,in order of declaration
+func (p *Parser) Definitions() []Definition {
+	return p.definitions
+}
+
+func (p *Parser) define(name lexer.Token, definition Definition) {
+	definition.Position = p.pos(name).Position
+	p.definitions = append(p.definitions, definition)
+}
@@ -0,0 +1,32 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestDefinitions(t *testing.T) {
+	p := New("type Point{\n\tx: 0\n\ty: 0\n}\nfunc Point.move(dx, dy){}\nfunc origin(){\n\treturn Point{}\n}\n").SetFile("def.qp")
+	if _, errs := p.ParseWithErrors(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	expect := []struct {
+		signature string
+		line      int
+		column    int
+	}{
+		{"type Point{x, y}", 1, 6},
+		{"func Point.move(dx, dy)", 5, 6},
+		{"func origin()", 6, 6},
+	}
+	definitions := p.Definitions()
+	if len(definitions) != len(expect) {
+		t.Fatalf("unexpected definitions %+v", definitions)
+	}
+	for index, it := range expect {
+		definition := definitions[index]
+		if definition.Signature() != it.signature || definition.Position.Line != it.line ||
+			definition.Position.Column != it.column || definition.Position.File != "def.qp" {
+			t.Fatalf("expect %s at %d:%d,found %+v", it.signature, it.line, it.column, definition)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/lexer"
+)
+
+// Error is a syntax error, Snippet is the source line of the error
+type Error struct {
+	lexer.Position
+	Msg     string
+	Snippet string
+}
+
+func (e *Error) Error() string {
+	return lexer.FormatError(e.Position, e.Msg, e.Snippet)
+}
+
+// parseState is the parser stack sizes at the begin of a statement,
+// restored when the statement failed
+type parseState struct {
+	history      int
+	status       int
+	pStack       int
+	closureCheck int
+}
+
+func (p *Parser) SetFile(file string) *Parser {
+	p.file = file
+	p.lexer.SetFile(file)
+	return p
+}
+
+// pos return the source position of token
+func (p *Parser) pos(token lexer.Token) ast.Pos {
+	return ast.Pos{Position: lexer.Position{File: p.file, Line: token.Line, Column: token.Column}}
+}
+
+func (p *Parser) newError(token lexer.Token, msg string) *Error {
+	if token.Line == 0 {
+		token = p.lastToken()
+	}
+	return p.PositionError(lexer.Position{File: p.file, Line: token.Line, Column: token.Column}, msg)
+}
+
+// PositionError make error at pos of source parsed,for checks after parsing
+func (p *Parser) PositionError(pos lexer.Position, msg string) *Error {
+	var snippet string
+	if pos.File == p.file && pos.Line > 0 && pos.Line <= len(p.lines) {
+		snippet = strings.TrimRight(p.lines[pos.Line-1], "\r")
+	}
+	return &Error{Position: pos, Msg: msg, Snippet: snippet}
+}
+
+// errorAt abort parsing with a syntax error at token
+func (p *Parser) errorAt(token lexer.Token, format string, args ...interface{}) {
+	panic(p.newError(token, fmt.Sprintf(format, args...)))
+}
+
+// report record err when error recovery is on,otherwise abort parsing
+func (p *Parser) report(err error) {
+	if p.recovery == false {
+		panic(err)
+	}
+	p.errs = append(p.errs, err)
+}
+
+func (p *Parser) lastToken() lexer.Token {
+	if len(p.hTokens) == 0 {
+		return p.ahead(0)
+	}
+	return p.hTokens[len(p.hTokens)-1]
+}
+
+func describe(token lexer.Token) string {
+	switch token.Typ {
+	case lexer.EOFType:
+		return "EOF"
+	case lexer.IDType:
+		return "identifier `" + token.Val + "`"
+	case lexer.IntType, lexer.FloatType:
+		return "number " + token.Val
+	case lexer.StringType:
+		return "string " + strconv.Quote(token.Val)
+	default:
+		return "`" + token.Typ.String() + "`"
+	}
+}
+
+// ParseWithErrors parse all statements, a syntax error is recorded and
+// parsing goes on from the next statement,so all errors of the source
+// are reported
+func (p *Parser) ParseWithErrors() (ast.Expressions, []error) {
+	p.recovery = true
+	statements := p.parse()
+	sort.SliceStable(p.errs, func(i, j int) bool {
+		left, right := errorPosition(p.errs[i]), errorPosition(p.errs[j])
+		if left.Line != right.Line {
+			return left.Line < right.Line
+		}
+		return left.Column < right.Column
+	})
+	return statements, p.errs
+}
+
+func errorPosition(err error) lexer.Position {
+	switch err := err.(type) {
+	case *Error:
+		return err.Position
+	case *lexer.Error:
+		return err.Position
+	}
+	return lexer.Position{}
+}
+
+func (p *Parser) saveState() parseState {
+	return parseState{
+		history:      len(p.hTokens),
+		status:       len(p.status),
+		pStack:       len(p.pStack),
+		closureCheck: len(p.closureCheck),
+	}
+}
+
+func (p *Parser) restoreState(state parseState) {
+	p.status = p.status[:state.status]
+	p.pStack = p.pStack[:state.pStack]
+	p.closureCheck = p.closureCheck[:state.closureCheck]
+}
+
+// statement parse a statement,when error recovery is on,the error is
+// recorded and the rest tokens of the statement are skipped
+func (p *Parser) statement() (statement ast.Expression) {
+	if p.recovery == false {
+		return p.ParseStatement()
+	}
+	state := p.saveState()
+	defer func() {
+		if r := recover(); r != nil {
+			// errors of imported module have their own position
+			err, ok := r.(error)
+			if ok == false || errorPosition(err).Line == 0 {
+				err = p.newError(p.lastToken(), fmt.Sprint(r))
+			}
+			p.errs = append(p.errs, err)
+			p.restoreState(state)
+			p.synchronize(state.history)
+			statement = ast.NopStatement{}
+		}
+	}()
+	return p.ParseStatement()
+}
+
+func isStatementBegin(token lexer.Token) bool {
+	switch token.Typ {
+	case lexer.IfType,
+		lexer.ForType,
+		lexer.VarType,
+		lexer.FuncType,
+		lexer.TypeType,
+		lexer.InterfaceType,
+		lexer.SwitchType,
+		lexer.ReturnType,
+		lexer.BreakType,
+		lexer.ContinueType,
+		lexer.TryType,
+		lexer.ImportType,
+		lexer.IDType:
+		return true
+	}
+	return false
+}
+
+// synchronize skip tokens to the begin of next statement, the braces
+// opened by the failed statement are skipped too
+func (p *Parser) synchronize(history int) {
+	if history > len(p.hTokens) {
+		history = len(p.hTokens)
+	}
+	var depth int
+	for _, token := range p.hTokens[history:] {
+		if token.Typ == lexer.LeftBraceType {
+			depth++
+		} else if token.Typ == lexer.RightBraceType {
+			depth--
+		}
+	}
+	// give back the `}` of the enclosing block
+	for depth < 0 && len(p.hTokens) > history && p.lastToken().Typ == lexer.RightBraceType {
+		p.putToken(p.lastToken())
+		depth++
+	}
+	if len(p.hTokens) == history {
+		//make progress
+		if ahead := p.ahead(0); ahead.Typ == lexer.EOFType || ahead.Typ == lexer.RightBraceType {
+			return
+		}
+		if p.nextToken().Typ == lexer.LeftBraceType {
+			depth++
+		}
+	}
+	line := p.lastToken().Line
+	for {
+		ahead := p.ahead(0)
+		switch {
+		case ahead.Typ == lexer.EOFType:
+			return
+		case ahead.Typ == lexer.RightBraceType:
+			if depth <= 0 {
+				return
+			}
+			depth--
+			p.nextToken()
+			if depth == 0 {
+				return
+			}
+			continue
+		case ahead.Typ == lexer.LeftBraceType:
+			depth++
+		case depth <= 0 && ahead.Line > line && isStatementBegin(ahead):
+			return
+		}
+		p.nextToken()
+	}
+}
@@ -0,0 +1,214 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/lexer"
+)
+
+func TestParseWithErrors(t *testing.T) {
+	data := `
+var a = 1
+var b = )
+func hello(a,b){
+	var c = a + b
+	if {
+		println(c)
+	}
+	c = a *
+}
+var d = 1 @
+for i := 0 i < 10; i++ {
+}
+println(a)
+`
+	statements, errs := New(data).SetFile("main.qp").ParseWithErrors()
+	if len(statements) == 0 {
+		t.Fatal("expect statements")
+	}
+	expects := []lexer.Position{
+		{File: "main.qp", Line: 3, Column: 9},
+		{File: "main.qp", Line: 6, Column: 5},
+		{File: "main.qp", Line: 10, Column: 1},
+		{File: "main.qp", Line: 11, Column: 11},
+		{File: "main.qp", Line: 12, Column: 12},
+	}
+	if len(errs) != len(expects) {
+		for _, err := range errs {
+			t.Log(err)
+		}
+		t.Fatalf("expect %d errors,found %d", len(expects), len(errs))
+	}
+	for index, err := range errs {
+		if pos := errorPosition(err); pos != expects[index] {
+			t.Errorf("error %d `%s` expect position %s", index, err, expects[index])
+		}
+	}
+	if _, ok := errs[3].(*lexer.Error); ok == false {
+		t.Errorf("expect lexer error %s", errs[3])
+	}
+	if msg := errs[0].Error(); strings.HasSuffix(msg, "\n\tvar b = )\n\t        ^") == false {
+		t.Errorf("error snippet no match\n%s", msg)
+	}
+}
+
+func TestParseRecoverDeclaration(t *testing.T) {
+	data := `var x = )
+interface P {
+	print()
+}
+var y = )
+switch v := x.(type) {
+case int:
+	println(v)
+}
+var z = )
+`
+	_, errs := New(data).SetFile("main.qp").ParseWithErrors()
+	expects := []lexer.Position{
+		{File: "main.qp", Line: 1, Column: 9},
+		{File: "main.qp", Line: 5, Column: 9},
+		{File: "main.qp", Line: 10, Column: 9},
+	}
+	if len(errs) != len(expects) {
+		for _, err := range errs {
+			t.Log(err)
+		}
+		t.Fatalf("expect %d errors,found %d", len(expects), len(errs))
+	}
+	for index, err := range errs {
+		if pos := errorPosition(err); pos != expects[index] {
+			t.Errorf("error %d `%s` expect position %s", index, err, expects[index])
+		}
+	}
+}
+
+func TestParseUnclosedBrace(t *testing.T) {
+	_, errs := New(`
+func hello(){
+	if a > 1 {
+		println(a)
+`).ParseWithErrors()
+	if len(errs) == 0 {
+		t.Fatal("expect error")
+	}
+	if strings.Contains(errs[0].Error(), "unexpected EOF") == false {
+		t.Fatal(errs[0])
+	}
+}
+
+func TestParsePanicError(t *testing.T) {
+	defer func() {
+		err, ok := recover().(*Error)
+		if ok == false {
+			t.Fatal("expect *Error")
+		}
+		if err.Line != 2 || err.Column != 1 {
+			t.Fatal(err)
+		}
+	}()
+	New("var a = 1\n}\n").Parse()
+}
+
+func TestParseLoopControl(t *testing.T) {
+	statements, errs := New(`
+for k, v := range [1, 2] {
+	if k == 0 {
+		continue
+	}
+	println(v)
+}
+continue
+`).ParseWithErrors()
+	if len(errs) != 1 || errorPosition(errs[0]).Line != 8 {
+		t.Fatalf("expect `continue` error at line 8,found %v", errs)
+	}
+	if _, ok := statements[0].(ast.ForExpression); ok == false {
+		t.Fatalf("expect ForExpression,found %T", statements[0])
+	}
+}
+
+func TestParseLoopLabel(t *testing.T) {
+	_, errs := New(`
+outer: for i := 0; i < 3; i++ {
+	break outer
+	continue inner
+	outer: for {
+	}
+}
+`).ParseWithErrors()
+	expects := []int{4, 5}
+	if len(errs) != len(expects) {
+		t.Fatalf("expect %d errors,found %v", len(expects), errs)
+	}
+	for index, err := range errs {
+		if line := errorPosition(err).Line; line != expects[index] {
+			t.Errorf("error `%s` expect line %d", err, expects[index])
+		}
+	}
+}
+
+func TestParseBreakInCase(t *testing.T) {
+	_, errs := New(`
+outer: for i := 0; i < 3; i++ {
+	switch i.(type) {
+	case int:
+		break
+	default:
+		for {
+			break
+		}
+		break outer
+	}
+}
+`).ParseWithErrors()
+	if len(errs) != 1 || errorPosition(errs[0]).Line != 5 {
+		t.Fatalf("expect `break` error at line 5,found %v", errs)
+	}
+}
+
+func TestParseTry(t *testing.T) {
+	statements, errs := New(`
+try {
+	println(1)
+} catch e {
+	println(e)
+}
+try {
+}
+println(2)
+`).ParseWithErrors()
+	if len(errs) != 1 || errorPosition(errs[0]).Line != 9 {
+		t.Fatalf("expect `catch` error at line 9,found %v", errs)
+	}
+	if try, ok := statements[0].(ast.TryStatement); ok == false || try.Label != "e" {
+		t.Fatalf("expect TryStatement,found %T", statements[0])
+	}
+}
+
+func TestParseMultiAssign(t *testing.T) {
+	statements, errs := New(`
+a, b := f()
+a, b.c = 1, 2
+return a, b
+a, b = 1
+`).ParseWithErrors()
+	if len(errs) != 1 || errorPosition(errs[0]).Line != 5 {
+		t.Fatalf("expect assignment mismatch at line 5,found %v", errs)
+	}
+	if assign, ok := statements[0].(ast.MultiAssignStatement); ok == false || assign.Define == false ||
+		len(assign.Lefts) != 2 {
+		t.Fatalf("expect MultiAssignStatement,found %T", statements[0])
+	}
+	if assign, ok := statements[1].(ast.MultiAssignStatement); ok == false || assign.Define ||
+		assign.String() != "a, b.c = 1, 2" {
+		t.Fatalf("expect MultiAssignStatement,found %v", statements[1])
+	}
+	if ret, ok := statements[2].(ast.ReturnStatement); ok == false {
+		t.Fatalf("expect ReturnStatement,found %T", statements[2])
+	} else if _, ok := ret.Exp.(ast.TupleExpression); ok == false {
+		t.Fatalf("expect TupleExpression,found %T", ret.Exp)
+	}
+}
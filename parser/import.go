@@ -0,0 +1,179 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+// ModuleExt is the file extension of module,`import "list"` load list.qp
+const ModuleExt = ".qp"
+
+// Importer load modules imported by scripts,a module is loaded once and
+// shared by all scripts importing it
+type Importer struct {
+	path    []string
+	modules map[string]*ast.Module // key is the absolute file of module
+	loading []string               // modules being loaded,for import cycle
+}
+
+// NewImporter make Importer searching modules in the directory of importing
+// script,then in path
+func NewImporter(path []string) *Importer {
+	return &Importer{
+		path:    path,
+		modules: map[string]*ast.Module{},
+	}
+}
+
+// DefaultPath return the search path of QPPATH
+func DefaultPath() []string {
+	return filepath.SplitList(os.Getenv("QPPATH"))
+}
+
+// SetImporter make parser load modules by importer,so modules are shared
+// between sources
+func (p *Parser) SetImporter(importer *Importer) *Parser {
+	p.importer = importer
+	return p
+}
+
+// SkipImports make parser accept `import` without loading the module,
+// members of modules are no checked.It is for tools needing syntax only
+func (p *Parser) SkipImports() *Parser {
+	p.skipImports = true
+	return p
+}
+
+// find return file of module name,the directory of importing script is
+// searched first
+func (importer *Importer) find(dir string, name string) (string, bool) {
+	for _, dir := range append([]string{dir}, importer.path...) {
+		file := filepath.Join(dir, filepath.FromSlash(name)+ModuleExt)
+		if info, err := os.Stat(file); err == nil && info.IsDir() == false {
+			return file, true
+		}
+	}
+	return "", false
+}
+
+/*
+importStatement:
+
+	|import "name"
+*/
+func (p *Parser) parseImportStatement(token lexer.Token) ast.Expression {
+	if p.getStatus() != GlobalStatus {
+		p.errorAt(token, "import must be at top level")
+	}
+	name := p.nextToken()
+	p.expectType(name, lexer.StringType)
+	alias := path.Base(name.Val)
+	module := p.importModule(name)
+	if imported, ok := p.modules[alias]; ok && imported != module {
+		p.errorAt(name, "module `%s` conflicts with imported `%s`", name.Val, imported.Name)
+	}
+	if p.modules == nil {
+		p.modules = map[string]*ast.Module{}
+	}
+	p.modules[alias] = module
+	return ast.ImportStatement{Pos: p.pos(token), Module: module}
+}
+
+// DisableImports make `import` a syntax error,scripts can not load files
+func (p *Parser) DisableImports() *Parser {
+	p.noImports = true
+	return p
+}
+
+// importModule parse the module or return the loaded one
+func (p *Parser) importModule(name lexer.Token) *ast.Module {
+	if p.noImports {
+		p.errorAt(name, "import of module `%s` is disabled", name.Val)
+	}
+	if p.skipImports {
+		return &ast.Module{Name: name.Val, VM: runtime.New()}
+	}
+	if p.importer == nil {
+		p.importer = NewImporter(DefaultPath())
+	}
+	importer := p.importer
+	dir := "."
+	if p.file != "" {
+		dir = filepath.Dir(p.file)
+	}
+	file, ok := importer.find(dir, name.Val)
+	if ok == false {
+		p.errorAt(name, "module `%s` not found", name.Val)
+	}
+	key, err := filepath.Abs(file)
+	if err != nil {
+		p.errorAt(name, "%s", err.Error())
+	}
+	if len(importer.loading) == 0 && p.file != "" {
+		// the main script
+		if main, err := filepath.Abs(p.file); err == nil {
+			importer.loading = append(importer.loading, main)
+			defer func() {
+				importer.loading = importer.loading[:0]
+			}()
+		}
+	}
+	for index, loading := range importer.loading {
+		if loading == key {
+			var cycle []string
+			for _, file := range append(importer.loading[index:], key) {
+				cycle = append(cycle, filepath.Base(file))
+			}
+			p.errorAt(name, "import cycle %s", strings.Join(cycle, " -> "))
+		}
+	}
+	if module, ok := importer.modules[key]; ok {
+		return module
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		p.errorAt(name, "%s", err.Error())
+	}
+
+	vm := runtime.New()
+	vm.Functions = p.vm.Functions
+	module := &ast.Module{Name: name.Val, File: file, VM: vm}
+	parser := NewWithVM(string(data), vm).SetFile(file).SetImporter(importer)
+	importer.loading = append(importer.loading, key)
+	statements, errs := parser.ParseWithErrors()
+	importer.loading = importer.loading[:len(importer.loading)-1]
+	if len(errs) != 0 {
+		for _, err := range errs[:len(errs)-1] {
+			p.report(err)
+		}
+		panic(errs[len(errs)-1])
+	}
+	module.Statements = statements
+	importer.modules[key] = module
+	return module
+}
+
+// parseModuleMember parse `.member` after name of module
+func (p *Parser) parseModuleMember(module *ast.Module) ast.ModuleMember {
+	p.expectType(p.nextToken(), lexer.PeriodType)
+	member := p.nextToken()
+	p.expectType(member, lexer.IDType)
+	if p.skipImports == false &&
+		module.VM.GetTypeObject(member.Val) == nil && module.VM.GlobalFunctions[member.Val] == nil {
+		p.errorAt(member, "undefined `%s.%s`", path.Base(module.Name), member.Val)
+	}
+	return ast.ModuleMember{Module: module, Label: member.Val}
+}
+
+// isModule check token is the name of imported module followed by `.`
+func (p *Parser) isModule(token lexer.Token) (*ast.Module, bool) {
+	module, ok := p.modules[token.Val]
+	return module, ok && p.ahead(0).Typ == lexer.PeriodType
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp/ast"
+)
+
+func writeModules(t *testing.T, dir string, modules map[string]string) {
+	for name, src := range modules {
+		if err := ioutil.WriteFile(filepath.Join(dir, name+ModuleExt), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir, lib := t.TempDir(), t.TempDir()
+	writeModules(t, dir, map[string]string{
+		"util": `
+import "shape"
+func area(w, h){
+	var rect = shape.Rect{w: w, h: h}
+	return rect.area()
+}`,
+	})
+	writeModules(t, lib, map[string]string{
+		"shape": `
+type Rect{}
+func Rect.area(){
+	return this.w * this.h
+}`,
+	})
+	importer := NewImporter([]string{lib})
+	statements, errs := New(`
+import "util"
+import "shape"
+println(util.area(2, 3), shape.Rect{w: 1, h: 1})
+`).SetFile(filepath.Join(dir, "main.qp")).SetImporter(importer).ParseWithErrors()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	util, ok := statements[0].(ast.ImportStatement)
+	if ok == false {
+		t.Fatalf("expect ImportStatement,found %T", statements[0])
+	}
+	shape := statements[1].(ast.ImportStatement)
+	if util.Module.Statements[0].(ast.ImportStatement).Module != shape.Module {
+		t.Fatal("module `shape` is loaded twice")
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	writeModules(t, dir, map[string]string{
+		"sub/ok": `func f(){}`,
+		"a":      `import "b"`,
+		"b":      `import "a"`,
+		"bad":    "func f(){\n\treturn )\n}",
+		"ok":     `func f(){}`,
+	})
+	cases := []struct {
+		src    string
+		expect string
+	}{
+		{`import "a"`, "import cycle a.qp -> b.qp -> a.qp"},
+		{`import "none"`, "module `none` not found"},
+		{`import "bad"`, "bad.qp:2:9"},
+		{"import \"ok\"\nok.g()", "undefined `ok.g`"},
+		{"import \"ok\"\nimport \"sub/ok\"", "module `sub/ok` conflicts with imported `ok`"},
+		{"func f(){\n\timport \"ok\"\n}", "import must be at top level"},
+	}
+	for _, c := range cases {
+		_, errs := New(c.src).SetFile(filepath.Join(dir, "main.qp")).ParseWithErrors()
+		if len(errs) == 0 || strings.Contains(errs[0].Error(), c.expect) == false {
+			t.Fatalf("%q: expect error %q,found %v", c.src, c.expect, errs)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/lexer"
+)
+
+// ParseMore parse src after the sources parsed by p,functions,types and
+// imported modules of them are visible to src,so the sources are parsed one
+// by one instead of reparsing all of them
+func (p *Parser) ParseMore(src string) (ast.Expressions, []error) {
+	p.lexer = lexer.New(bytes.NewReader([]byte(src)))
+	p.lexer.SetFile(p.file)
+	p.lines = strings.Split(src, "\n")
+	p.errs = nil
+	p.tokens = nil
+	p.hTokens = nil
+	p.pStack = nil
+	p.closureCheck = nil
+	p.status = []PStatus{GlobalStatus}
+	p.loopLabels = nil
+	return p.ParseWithErrors()
+}
+
+// Incomplete check src has `{`,`(` or `[` no closed,more lines are needed
+// to parse it
+func Incomplete(src string) bool {
+	var depth int
+	l := lexer.New(bytes.NewReader([]byte(src)))
+	for token := l.Peek(); token.Typ != lexer.EOFType; token = l.Peek() {
+		switch token.Typ {
+		case lexer.LeftBraceType, lexer.LeftParenthesisType, lexer.LeftBracketType:
+			depth++
+		case lexer.RightBraceType, lexer.RightParenthesisType, lexer.RightBracketType:
+			depth--
+		}
+		l.Next()
+	}
+	return depth > 0
+}
@@ -0,0 +1,44 @@
+package parser
+
+import (
+	"testing"
+
+	"gitlab.com/akzj/qp/ast"
+)
+
+func TestParseMore(t *testing.T) {
+	p := New("")
+	if _, errs := p.ParseMore("type User{}\nfunc add(a, b){\n\treturn a + b\n}"); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	statements, errs := p.ParseMore("var u = User{name: add(1, 2)}")
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if _, ok := statements[0].(ast.VarAssignStatement); ok == false {
+		t.Fatalf("expect VarAssignStatement,found %T", statements[0])
+	}
+	if _, errs := p.ParseMore("var a = 1 +"); len(errs) != 1 || errorPosition(errs[0]).Line != 1 {
+		t.Fatalf("expect error at line 1,found %v", errs)
+	}
+	if _, errs := p.ParseMore("func add(a){}"); len(errs) != 1 {
+		t.Fatalf("expect redeclared error,found %v", errs)
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	for src, expect := range map[string]bool{
+		"func f(){":          true,
+		"func f(){\n}":       false,
+		"println(1,":         true,
+		"var a = [1,\n2]":    false,
+		"var s = \"{\"":      false,
+		"if a {\n\tfor {\n}": true,
+		"println(1)":         false,
+		"}":                  false,
+	} {
+		if Incomplete(src) != expect {
+			t.Fatalf("Incomplete(%q) expect %v", src, expect)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+/*
+interfaceStatement:
+
+	|interface ID {}
+	|interface ID { ID(parameters) returnTypes \n ... }
+	|interface ID { ID \n ... } // interface embedded
+*/
+func (p *Parser) parseInterfaceStatement() *ast.InterfaceObject {
+	token := p.nextToken()
+	p.expectType(token, lexer.IDType)
+	p.expectType(p.nextToken(), lexer.LeftBraceType)
+	object := &ast.InterfaceObject{Pos: p.pos(token), VM: p.vm, Label: token.Val}
+	var members []string
+	for p.ahead(0).Typ != lexer.RightBraceType {
+		p.expectNoEOF()
+		name := p.nextToken()
+		p.expectType(name, lexer.IDType)
+		if p.ahead(0).Typ != lexer.LeftParenthesisType {
+			object.Embedded = append(object.Embedded, name.Val)
+			members = append(members, name.Val)
+		} else {
+			p.nextToken()
+			if object.Method(name.Val) != nil {
+				p.errorAt(name, "duplicate method `%s`", name.Val)
+			}
+			method := &ast.FuncExpression{Pos: p.pos(name), VM: p.vm, Label: name.Val}
+			method.Parameters, method.ParameterTypes = p.parseFuncParameters()
+			if p.ahead(0).Line == p.lastToken().Line {
+				method.Returns = p.parseReturnTypes()
+			}
+			object.Methods = append(object.Methods, method)
+			members = append(members, name.Val+"("+strings.Join(method.Parameters, ", ")+")")
+		}
+		if ahead := p.ahead(0); ahead.Typ == lexer.CommaType || ahead.Typ == lexer.SemicolonType {
+			p.nextToken()
+		} else if ahead.Typ != lexer.RightBraceType && ahead.Line == p.lastToken().Line {
+			p.errorAt(ahead, "expect new line or `;` after method `%s`", name.Val)
+		}
+	}
+	p.nextToken()
+	p.define(token, Definition{Kind: InterfaceDefinition, Name: object.Label, Fields: members})
+	p.interfaces = append(p.interfaces, object)
+	return object
+}
+
+/*
+typeRef:
+
+	|nil
+	|func
+	|typeName
+*/
+func (p *Parser) parseTypeRef() ast.TypeName {
+	token := p.nextToken()
+	switch token.Typ {
+	case lexer.NilType:
+		return ast.TypeName{Label: "nil", VM: p.vm}
+	case lexer.FuncType:
+		return ast.TypeName{Label: "func", VM: p.vm}
+	}
+	p.expectType(token, lexer.IDType)
+	if module, ok := p.isModule(token); ok {
+		p.nextToken()
+		member := p.nextToken()
+		p.expectType(member, lexer.IDType)
+		if p.skipImports == false && module.VM.GetTypeObject(member.Val) == nil {
+			p.errorAt(member, "undefined type `%s.%s`", token.Val, member.Val)
+		}
+		return ast.TypeName{Label: member.Val, VM: module.VM, Module: module}
+	}
+	return ast.TypeName{Label: token.Val, VM: p.vm}
+}
+
+// isTypeName check name is type declared,not interface
+func (p *Parser) isTypeName(name string) bool {
+	object := p.vm.GetTypeObject(name)
+	if object == nil {
+		return false
+	}
+	_, ok := object.Pointer.(*ast.TypeObject)
+	return ok
+}
+
+// embeddedTemplate return field of type embedded,the value is created
+// with default fields of the type
+func (p *Parser) embeddedTemplate(token lexer.Token) ast.TypeObjectPropTemplate {
+	return ast.TypeObjectPropTemplate{
+		Name:     token.Val,
+		Type:     token.Val,
+		Embedded: true,
+		Exp: ast.ObjectInitStatement{
+			VM:  p.vm,
+			Exp: ast.GetVarStatement{Pos: p.pos(token), VM: p.vm, Label: token.Val},
+		},
+	}
+}
+
+// switchValue is the hidden variable of value of type switch without
+// binding
+const switchValue = "__switch__"
+
+/*
+switchStatement:
+
+	|switch x.(type) { case T: ... case T1, T2: ... default: ... }
+	|switch v := x.(type) { ... }
+
+it is `if v is T {} else if v is T1, T2 {} else {}` in a block
+*/
+func (p *Parser) parseSwitchStatement(token lexer.Token) ast.Expression {
+	var name lexer.Token
+	if p.ahead(0).Typ == lexer.IDType && p.ahead(1).Typ == lexer.VarInitType {
+		name = p.nextToken()
+		p.nextToken()
+	}
+	// parse x before `.(type)`
+	end := -1
+	for index := 0; index+3 < len(p.tokens); index++ {
+		if p.tokens[index].Typ == lexer.LeftBraceType && p.tokens[index].Line != token.Line {
+			break
+		}
+		if p.tokens[index].Typ == lexer.PeriodType && p.tokens[index+1].Typ == lexer.LeftParenthesisType &&
+			p.tokens[index+2].Typ == lexer.TypeType && p.tokens[index+3].Typ == lexer.RightParenthesisType {
+			end = index
+			break
+		}
+	}
+	if end == -1 {
+		p.errorAt(token, "expect `x.(type)` after `switch`, only type switch is supported")
+	}
+	if end == 0 {
+		p.errorAt(p.ahead(0), "missing value of type switch")
+	}
+	value := p.parseFactorUntil(end)
+	for index := 0; index < 4; index++ {
+		p.nextToken() // .(type)
+	}
+	p.expectType(p.nextToken(), lexer.LeftBraceType)
+
+	block := ast.IfExpression{Pos: p.pos(token), VM: p.vm, Check: ast.Bool(true)}
+	subject, ok := value.(ast.GetVarStatement)
+	if name.Typ == lexer.IDType || ok == false {
+		if name.Typ != lexer.IDType {
+			name = lexer.Token{Typ: lexer.IDType, Val: switchValue, Line: token.Line, Column: token.Column}
+		}
+		p.closureCheckAddVar(name.Val)
+		block.Statements = append(block.Statements, ast.VarInitExpression{
+			Pos:  p.pos(name),
+			Ctx:  p.vm,
+			Name: name.Val,
+			Exp:  value,
+		})
+		subject = ast.GetVarStatement{Pos: p.pos(name), VM: p.vm, Label: name.Val}
+	}
+
+	var cases []ast.IfExpression
+	var defaults ast.Expressions
+	var hasDefault bool
+	for {
+		next := p.nextToken()
+		switch next.Typ {
+		case lexer.CaseType:
+			check := ast.IsExpression{Pos: p.pos(next), Exp: subject}
+			for {
+				check.Types = append(check.Types, p.parseTypeRef())
+				if p.ahead(0).Typ != lexer.CommaType {
+					break
+				}
+				p.nextToken()
+			}
+			p.expectType(p.nextToken(), lexer.ColonType)
+			cases = append(cases, ast.IfExpression{
+				Pos:        p.pos(next),
+				VM:         p.vm,
+				Check:      check,
+				Statements: p.parseCaseStatements(),
+			})
+		case lexer.DefaultType:
+			if hasDefault {
+				p.errorAt(next, "multiple defaults in switch")
+			}
+			hasDefault = true
+			p.expectType(p.nextToken(), lexer.ColonType)
+			defaults = p.parseCaseStatements()
+		case lexer.RightBraceType:
+			if len(cases) == 0 {
+				block.Statements = append(block.Statements, defaults...)
+				return block
+			}
+			first := cases[0]
+			first.ElseIf = cases[1:]
+			first.Else = defaults
+			block.Statements = append(block.Statements, first)
+			return block
+		default:
+			p.errorAt(next, "expect `case`, `default` or `}`, found %s", describe(next))
+		}
+	}
+}
+
+// parseFactorUntil parse expression of the first end tokens,the tokens
+// after them are kept even if parsing failed
+func (p *Parser) parseFactorUntil(end int) runtime.Invokable {
+	rest := p.tokens[end:]
+	p.tokens = append(p.tokens[:end:end], lexer.Token{Typ: lexer.EOFType, Line: rest[0].Line, Column: rest[0].Column})
+	defer func() {
+		p.tokens = rest
+	}()
+	exp := p.parseFactor(0)
+	if len(p.tokens) != 0 {
+		p.errorAt(p.ahead(0), "unexpected %s", describe(p.ahead(0)))
+	}
+	return exp
+}
+
+// parseCaseStatements parse statements of case until next case,default
+// or `}`
+func (p *Parser) parseCaseStatements() ast.Expressions {
+	p.pushStatus(CaseStatus)
+	defer func() {
+		p.assertTrue(p.popStatus() == CaseStatus)
+	}()
+	var statements ast.Expressions
+	for {
+		switch p.ahead(0).Typ {
+		case lexer.CaseType, lexer.DefaultType, lexer.RightBraceType:
+			return statements
+		}
+		p.expectNoEOF()
+		statements = append(statements, p.statement())
+	}
+}
+
+// resolveDeclarations resolve interfaces embedded by interfaces and promote
+// methods of types embedded,after all types and methods are declared
+func (p *Parser) resolveDeclarations() {
+	resolved := map[*ast.InterfaceObject]bool{}
+	for _, object := range p.interfaces {
+		p.resolveInterface(object, resolved, nil)
+	}
+	for _, object := range p.embedding {
+		p.promoteMethods(object)
+	}
+	p.interfaces, p.embedding = nil, nil
+}
+
+// resolveInterface copy methods of interfaces embedded to object
+func (p *Parser) resolveInterface(object *ast.InterfaceObject, resolved map[*ast.InterfaceObject]bool,
+	resolving []*ast.InterfaceObject) {
+	if resolved[object] || len(object.Embedded) == 0 {
+		return
+	}
+	for _, it := range resolving {
+		if it == object {
+			p.report(p.PositionError(object.Position, "invalid recursive interface `"+object.Label+"`"))
+			return
+		}
+	}
+	resolving = append(resolving, object)
+	for _, name := range object.Embedded {
+		var embedded *ast.InterfaceObject
+		if it := object.VM.GetTypeObject(name); it != nil {
+			embedded, _ = it.Pointer.(*ast.InterfaceObject)
+		}
+		if embedded == nil {
+			p.report(p.PositionError(object.Position, "undefined interface `"+name+"` embedded by `"+object.Label+"`"))
+			continue
+		}
+		p.resolveInterface(embedded, resolved, resolving)
+		for _, method := range embedded.Methods {
+			if object.Method(method.Label) == nil {
+				object.Methods = append(object.Methods, method)
+			}
+		}
+	}
+	resolved[object] = true
+}
+
+// promoteMethods add methods of types embedded to object,which are no
+// declared by object.`func Admin.print()` calls `this.User.print()` for
+// `type Admin { User }`.A method provided by more than one type embedded
+// is reported
+func (p *Parser) promoteMethods(object *ast.TypeObject) {
+	own := map[string]bool{}
+	for name := range object.GetObjects() {
+		own[name] = true
+	}
+	providers := map[string][]string{}
+	methods := map[string]*ast.FuncExpression{}
+	for _, template := range object.TypeObjectPropTemplates {
+		own[template.Name] = true
+	}
+	for _, template := range object.TypeObjectPropTemplates {
+		if template.Embedded == false {
+			continue
+		}
+		embedded := p.vm.GetTypeObject(template.Name).Pointer.(*ast.TypeObject)
+		for name, method := range embedded.GetObjects() {
+			function, ok := method.Pointer.(*ast.FuncExpression)
+			// constructor of type embedded is called when it is created
+			if ok == false || own[name] || name == ast.ConstructorName {
+				continue
+			}
+			providers[name] = append(providers[name], template.Name)
+			methods[name] = function
+		}
+	}
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if len(providers[name]) > 1 {
+			p.report(p.PositionError(object.Position, "ambiguous method `"+name+"` of type `"+object.Label+
+				"` promoted from "+strings.Join(providers[name], " and ")))
+			continue
+		}
+		object.AddObject(name, &runtime.Object{
+			Pointer: p.promotedMethod(object, providers[name][0], methods[name]),
+			Label:   object.Label + "." + name,
+		})
+	}
+}
+
+// promotedMethod return `func Type.name(parameters) { return this.Embedded.name(parameters) }`
+func (p *Parser) promotedMethod(object *ast.TypeObject, embedded string, method *ast.FuncExpression) *ast.FuncExpression {
+	this := ast.GetVarStatement{Pos: object.Pos, VM: p.vm, Label: "this"}
+	receiver := ast.PeriodStatement{VM: p.vm, Exp: this, Val: embedded}
+	call := &ast.CallStatement{
+		Pos:      object.Pos,
+		Function: ast.PeriodStatement{VM: p.vm, Exp: receiver, Val: method.Labels[1], Bind: true},
+	}
+	for _, parameter := range method.Parameters[1:] {
+		call.Arguments = append(call.Arguments, ast.GetVarStatement{Pos: object.Pos, VM: p.vm, Label: parameter})
+	}
+	function := &ast.FuncExpression{
+		Pos:        object.Pos,
+		Labels:     []string{object.Label, method.Labels[1]},
+		Parameters: method.Parameters,
+		Statements: ast.Expressions{ast.ReturnStatement{Pos: object.Pos, Exp: call}},
+		VM:         p.vm,
+		Returns:    method.Returns,
+	}
+	if method.ParameterTypes != nil {
+		function.ParameterTypes = append([]string{object.Label}, method.ParameterTypes[1:]...)
+	}
+	return function
+}
@@ -76,11 +76,23 @@ BoolExpression:Factor BoolOperator Factor
 type Parser struct {
 	vm           *runtime.VMRuntime
 	lexer        *lexer.Lexer
+	file         string
+	lines        []string // source lines for error snippet
+	errs         []error
+	recovery     bool // record syntax errors and go on parsing
 	tokens       []lexer.Token
 	hTokens      []lexer.Token
 	pStack       []int //parenthesis stack
 	closureCheck []*ClosureCheck
 	status       []PStatus
+	loopLabels   map[int]string // label of for loop,key is index of ForStatus
+	importer     *Importer
+	skipImports  bool                   // see SkipImports
+	noImports    bool                   // see DisableImports
+	modules      map[string]*ast.Module // imported modules,key is the name
+	definitions  []Definition
+	interfaces   []*ast.InterfaceObject // interfaces declared,embedded ones are resolved after parsing
+	embedding    []*ast.TypeObject      // types embedding types,methods are promoted after parsing
 }
 
 type PStatus int
@@ -91,6 +103,8 @@ const (
 	ElseStatus     = 2
 	ForStatus      = 3
 	FunctionStatus = 4
+	BlockStatus    = 5 // statements of if,for block
+	CaseStatus     = 6 // statements of case of switch
 )
 
 func precedence(tokenType lexer.Type) int {
@@ -104,7 +118,8 @@ func precedence(tokenType lexer.Type) int {
 		lexer.GreaterType,
 		lexer.GreaterEqualType,
 		lexer.NoEqualType,
-		lexer.EqualType:
+		lexer.EqualType,
+		lexer.IsType:
 		return 8
 	case lexer.AndType:
 		return 7
@@ -119,10 +134,19 @@ func New(buffer string) *Parser {
 	return &Parser{
 		status: []PStatus{GlobalStatus},
 		lexer:  lexer.New(bytes.NewReader([]byte(buffer))),
+		lines:  strings.Split(buffer, "\n"),
 		vm:     runtime.New(),
 	}
 }
 
+// NewWithVM make Parser bind statements to vm,so state of vm is kept
+// between sources
+func NewWithVM(buffer string, vm *runtime.VMRuntime) *Parser {
+	p := New(buffer)
+	p.vm = vm
+	return p
+}
+
 func Parse(data string) ast.Expressions {
 	return New(data).Parse()
 }
@@ -159,16 +183,29 @@ func (p *Parser) initTokens() *Parser {
 	return p
 }
 
+// Parse parse all statements,it panics with *Error when syntax error found
 func (p *Parser) Parse() ast.Expressions {
+	return p.parse()
+}
+
+func (p *Parser) parse() ast.Expressions {
 	p.initTokens()
+	for _, err := range p.lexer.Errors() {
+		p.report(err)
+	}
 	var statements ast.Expressions
 	for {
-		if statement := p.ParseStatement(); statement != nil {
+		if statement := p.statement(); statement != nil {
 			statements = append(statements, statement)
 		}
 		if p.ahead(0).Typ == lexer.EOFType {
+			p.resolveDeclarations()
 			return statements
 		}
+		if token := p.ahead(0); token.Typ == lexer.RightBraceType {
+			p.nextToken()
+			p.report(p.newError(token, "unexpected `}`"))
+		}
 	}
 }
 
@@ -185,10 +222,13 @@ func (p *Parser) Parse() ast.Expressions {
 */
 func (p *Parser) ParseIDPrefixExpression(token lexer.Token) ast.Expression {
 	var exp runtime.Invokable = ast.GetVarStatement{
+		Pos:   p.pos(token),
 		VM:    p.vm,
 		Label: token.Val,
 	}
-	var parentExp runtime.Invokable
+	if module, ok := p.isModule(token); ok {
+		exp = p.parseModuleMember(module)
+	}
 	for {
 		next := p.nextToken()
 		switch next.Typ {
@@ -200,25 +240,36 @@ func (p *Parser) ParseIDPrefixExpression(token lexer.Token) ast.Expression {
 			return p.parseVarInitStatement()
 		case lexer.IncType:
 			return ast.IncFieldStatement{
-				Exp: exp,
+				Pos: p.pos(token),
+				Exp: target(exp),
 			}
 		case lexer.ColonType:
-			return ast.AssignStatement{Left: exp, Exp: p.parseFactor(0)}
+			return ast.AssignStatement{Pos: p.pos(token), Left: target(exp), Exp: p.parseFactor(0)}
+		case lexer.CommaType:
+			if _, ok := exp.(ast.GetVarStatement); ok && p.isVarInitList() {
+				p.putToken(next)
+				p.putToken(token)
+				return p.parseVarInitStatement()
+			}
+			return p.parseMultiAssignStatement(token, exp)
 		case lexer.PeriodType:
 			token := p.nextToken()
 			p.expectType(token, lexer.IDType)
-			parentExp = exp
 			exp = ast.PeriodStatement{
-				Val: token.Val,
-				Exp: exp,
+				VM:   p.vm,
+				Val:  token.Val,
+				Exp:  exp,
+				Bind: true,
 			}
 		case lexer.LeftParenthesisType:
-			exp = p.parseCallStatement(parentExp, exp)
+			exp = p.parseCallStatement(exp)
 			if p.ahead(0).Typ != lexer.LeftParenthesisType {
 				return exp
 			}
+		case lexer.LeftBracketType:
+			exp = p.parseBracketStatement(exp)
 		default:
-			log.Panic("unexpect token ", next)
+			p.errorAt(next, "unexpected %s", describe(next))
 		}
 	}
 }
@@ -231,25 +282,50 @@ func (p *Parser) ParseStatement() ast.Expression {
 		switch token.Typ {
 		case lexer.TypeType:
 			typeObject := p.parseTypeStatement()
+			if p.vm.GetTypeObject(typeObject.Label) != nil {
+				p.errorAt(token, "type `%s` redeclared", typeObject.Label)
+			}
 			p.vm.AddStructObject(&runtime.Object{
 				Pointer: typeObject,
 				Label:   typeObject.Label,
 			})
+		case lexer.InterfaceType:
+			interfaceObject := p.parseInterfaceStatement()
+			if p.vm.GetTypeObject(interfaceObject.Label) != nil {
+				p.errorAt(token, "type `%s` redeclared", interfaceObject.Label)
+			}
+			p.vm.AddStructObject(&runtime.Object{
+				Pointer: interfaceObject,
+				Label:   interfaceObject.Label,
+			})
+		case lexer.SwitchType:
+			return p.parseSwitchStatement(token)
+		case lexer.CaseType, lexer.DefaultType:
+			if p.getStatus() != CaseStatus {
+				p.errorAt(token, "`%s` is not in `switch` statement", token.Typ.String())
+			}
+			p.putToken(token)
+			if statement == nil {
+				return ast.NopStatement{}
+			}
+			return statement
 		case lexer.FuncType:
 			//function
-			if p.ahead(0).Typ == lexer.IDType {
-				p.addUserFunction(p.parseFuncStatement())
+			if name := p.ahead(0); name.Typ == lexer.IDType {
+				p.addUserFunction(name, p.parseFuncStatement())
 			} else if p.ahead(0).Typ == lexer.LeftParenthesisType { //func(){} lambda
 				funcStatement := p.parseLambdaStatement()
 				//function Call
 				for p.ahead(0).Typ == lexer.LeftParenthesisType {
 					p.nextToken()
-					funcStatement = p.parseCallStatement(nil, funcStatement)
+					funcStatement = p.parseCallStatement(funcStatement)
 				}
 				return funcStatement
 			}
 		case lexer.VarType:
 			return p.parseVarStatement()
+		case lexer.ImportType:
+			return p.parseImportStatement(token)
 		case lexer.IfType:
 			return p.parseIfStatement(false)
 		case lexer.EOFType:
@@ -262,6 +338,9 @@ func (p *Parser) ParseStatement() ast.Expression {
 		case lexer.SemicolonType:
 			continue
 		case lexer.IDType:
+			if p.ahead(0).Typ == lexer.ColonType && p.ahead(1).Typ == lexer.ForType {
+				return p.parseLabeledStatement(token)
+			}
 			return p.ParseIDPrefixExpression(token)
 		case lexer.ReturnType:
 			return p.parseReturn()
@@ -272,11 +351,15 @@ func (p *Parser) ParseStatement() ast.Expression {
 			}
 			return statement
 		case lexer.ForType:
-			return p.parseForStatement()
+			return p.parseForStatement("")
 		case lexer.BreakType:
-			return p.parseBreakStatement()
+			return p.parseBreakStatement(token)
+		case lexer.ContinueType:
+			return p.parseContinueStatement(token)
+		case lexer.TryType:
+			return p.parseTryStatement(token)
 		default:
-			log.Panic(token)
+			p.errorAt(token, "unexpected %s", describe(token))
 		}
 	}
 }
@@ -299,16 +382,38 @@ func (p *Parser) nextToken() lexer.Token {
 type User{
 }
 */
-func (p *Parser) parseTypeObjectInit() []ast.TypeObjectPropTemplate {
+func (p *Parser) parseTypeObjectInit(declaration bool) []ast.TypeObjectPropTemplate {
 	var objectPropTemplates []ast.TypeObjectPropTemplate
 	for {
 		token := p.nextToken()
-		p.expectType(p.nextToken(), lexer.ColonType)
-		exp := p.parseFactor(0) //delay bind
-		objectPropTemplates = append(objectPropTemplates, ast.TypeObjectPropTemplate{
-			Name: token.Val,
-			Exp:  exp,
-		})
+		p.expectType(token, lexer.IDType)
+		if declaration && p.isAnnotation(token) {
+			// name type,name type = default
+			template := ast.TypeObjectPropTemplate{Name: token.Val, Type: p.parseTypeName()}
+			if p.ahead(0).Typ == lexer.AssignType {
+				p.nextToken()
+				template.Exp = p.parseFactor(0)
+			} else {
+				template.Exp = zeroValue(template.Type)
+			}
+			objectPropTemplates = append(objectPropTemplates, template)
+		} else if declaration && p.isFieldEnd(token) && p.isTypeName(token.Val) {
+			// type embedded
+			objectPropTemplates = append(objectPropTemplates, p.embeddedTemplate(token))
+		} else if declaration && p.isFieldEnd(token) {
+			// name,default is nil
+			objectPropTemplates = append(objectPropTemplates, ast.TypeObjectPropTemplate{
+				Name: token.Val,
+				Exp:  ast.NilObject{},
+			})
+		} else {
+			p.expectType(p.nextToken(), lexer.ColonType)
+			exp := p.parseFactor(0) //delay bind
+			objectPropTemplates = append(objectPropTemplates, ast.TypeObjectPropTemplate{
+				Name: token.Val,
+				Exp:  exp,
+			})
+		}
 		if ahead := p.ahead(0); ahead.Typ == lexer.CommaType || ahead.Typ == lexer.SemicolonType {
 			p.nextToken()
 		} else {
@@ -317,7 +422,7 @@ func (p *Parser) parseTypeObjectInit() []ast.TypeObjectPropTemplate {
 				if p.historyToken(1).Line != p.ahead(0).Line {
 					continue
 				}
-				log.Panic("require new Line")
+				p.errorAt(p.ahead(0), "expect new line or `,` before field `%s`", p.ahead(0).Val)
 			} else {
 				//log.Println(p.ahead(0))
 				break
@@ -332,21 +437,38 @@ func (p *Parser) parseTypeStatement() *ast.TypeObject {
 	token := p.nextToken()
 	//	log.Println(token)
 	p.expectType(token, lexer.IDType)
+	if ahead := p.ahead(0); ahead.Typ == lexer.IDType && ahead.Val == "strict" && ahead.Line == token.Line {
+		p.nextToken()
+		object.Strict = true
+	}
 	p.expectType(p.nextToken(), lexer.LeftBraceType) //{
+	object.Pos = p.pos(token)
+	object.VM = p.vm
 	object.Label = token.Val
 	if ahead := p.ahead(0); ahead.Typ == lexer.RightBraceType {
 		p.nextToken()
+		p.define(token, Definition{Kind: TypeDefinition, Name: object.Label})
 		return &object
 	} else {
-		object.TypeObjectPropTemplates = p.parseTypeObjectInit()
+		object.TypeObjectPropTemplates = p.parseTypeObjectInit(true)
 	}
 	p.expectType(p.nextToken(), lexer.RightBraceType) //}
+	var fields []string
+	var embedding bool
+	for _, template := range object.TypeObjectPropTemplates {
+		fields = append(fields, template.Name)
+		embedding = embedding || template.Embedded
+	}
+	if embedding {
+		p.embedding = append(p.embedding, &object)
+	}
+	p.define(token, Definition{Kind: TypeDefinition, Name: object.Label, Fields: fields})
 	return &object
 }
 
 func (p *Parser) expectType(token lexer.Token, expect lexer.Type) {
 	if token.Typ != expect {
-		log.Panicf("unexpect %s token", token.String())
+		p.errorAt(token, "expect `%s`, found %s", expect.String(), describe(token))
 	}
 }
 
@@ -356,15 +478,29 @@ func (p *Parser) parseVarInitStatement() ast.Expression {
 	p.expectType(token, lexer.IDType)
 	next := p.nextToken()
 	p.closureCheckAddVar(token.Val)
+	if next.Typ == lexer.CommaType {
+		// a, b := f()
+		var lefts = []runtime.Invokable{ast.GetVarStatement{Pos: p.pos(token), VM: p.vm, Label: token.Val}}
+		for next.Typ == lexer.CommaType {
+			name := p.nextToken()
+			p.expectType(name, lexer.IDType)
+			p.closureCheckAddVar(name.Val)
+			lefts = append(lefts, ast.GetVarStatement{Pos: p.pos(name), VM: p.vm, Label: name.Val})
+			next = p.nextToken()
+		}
+		p.expectType(next, lexer.VarInitType)
+		return p.newMultiAssignStatement(token, lefts, true)
+	}
 	if next.Typ == lexer.VarInitType {
 		expression := p.parseFactor(0)
 		return ast.VarInitExpression{
+			Pos:  p.pos(token),
 			Ctx:  p.vm,
 			Name: token.Val,
 			Exp:  expression,
 		}
 	}
-	log.Panicf("unknown token %s", next)
+	p.errorAt(next, "expect `:=`, found %s", describe(next))
 	return nil
 }
 
@@ -372,19 +508,36 @@ func (p *Parser) parseVarStatement() ast.Expression {
 	token := p.nextToken()
 	//log.Println(token)
 	p.expectType(token, lexer.IDType)
+	var typ string
+	if p.isAnnotation(token) {
+		typ = p.parseTypeName()
+	}
 	next := p.nextToken()
 	//var id = ....
 	p.closureCheckAddVar(token.Val)
 	if next.Typ == lexer.AssignType {
 		expression := p.parseFactor(0)
 		return ast.VarAssignStatement{
+			Pos:  p.pos(token),
 			Ctx:  p.vm,
 			Name: token.Val,
+			Type: typ,
 			Exp:  expression,
 		}
 	}
 	p.putToken(next)
+	if typ != "" {
+		// var a int
+		return ast.VarAssignStatement{
+			Pos:  p.pos(token),
+			Ctx:  p.vm,
+			Name: token.Val,
+			Type: typ,
+			Exp:  zeroValue(typ),
+		}
+	}
 	return ast.VarStatement{
+		Pos:   p.pos(token),
 		VM:    p.vm,
 		Label: token.Val,
 	}
@@ -400,6 +553,7 @@ func (p *Parser) parseLambdaStatement() ast.Expression {
 		p.assertTrue(p.popStatus() == FunctionStatus)
 	}()
 	var funcS ast.FuncExpression
+	funcS.Pos = p.pos(p.lastToken())
 	funcS.Closure = true
 	funcS.VM = p.vm
 	token := p.nextToken()
@@ -407,7 +561,8 @@ func (p *Parser) parseLambdaStatement() ast.Expression {
 	p.pushClosureCheck()
 
 	p.expectType(token, lexer.LeftParenthesisType)
-	funcS.Parameters = p.parseFuncParameters()
+	funcS.Parameters, funcS.ParameterTypes = p.parseFuncParameters()
+	funcS.Returns = p.parseReturnTypes()
 	p.expectType(p.nextToken(), lexer.LeftBraceType)
 
 	if p.ahead(0).Typ == lexer.RightBraceType { //empty body
@@ -415,11 +570,12 @@ func (p *Parser) parseLambdaStatement() ast.Expression {
 		return &funcS
 	}
 	for {
-		funcS.Statements = append(funcS.Statements, p.ParseStatement())
+		funcS.Statements = append(funcS.Statements, p.statement())
 		if p.ahead(0).Typ == lexer.RightBraceType {
 			p.nextToken()
 			break
 		}
+		p.expectNoEOF()
 		//log.Println("lambda Next token", p.ahead(0))
 	}
 	funcS.ClosureLabel = p.popClosureLabels()
@@ -428,15 +584,14 @@ func (p *Parser) parseLambdaStatement() ast.Expression {
 
 func (p *Parser) parseFactor(pre int) runtime.Invokable {
 	var exp runtime.Invokable
-	var parentExp runtime.Invokable
 	for {
 		token := p.nextToken()
 		switch token.Typ {
 		case lexer.FalseType:
-			p.assertNil(exp)
+			p.assertNil(exp, token)
 			exp = ast.Bool(false)
 		case lexer.TrueType:
-			p.assertNil(exp)
+			p.assertNil(exp, token)
 			exp = ast.Bool(true)
 		case lexer.LeftParenthesisType:
 			if exp == nil {
@@ -445,24 +600,25 @@ func (p *Parser) parseFactor(pre int) runtime.Invokable {
 				p.expectType(p.nextToken(), lexer.RightParenthesisType)
 			} else {
 				//log.Println("parseCallStatement")
-				exp = p.parseCallStatement(parentExp, exp)
+				exp = p.parseCallStatement(exp)
 			}
 		case lexer.RightParenthesisType: //end of parenthesis ()
 			if exp == nil {
-				log.Panic("parse bool exp failed")
+				p.errorAt(token, "unexpected `)`")
 			}
 			p.putToken(token)
 			return exp
 		case lexer.PeriodType:
 			token := p.nextToken()
 			p.expectType(token, lexer.IDType)
-			parentExp = exp
 			exp = ast.PeriodStatement{
-				Val: token.Val,
-				Exp: exp,
+				VM:   p.vm,
+				Val:  token.Val,
+				Exp:  exp,
+				Bind: true,
 			}
 		case lexer.NoType:
-			p.assertNoNil(exp)
+			p.assertNil(exp, token)
 			exp = ast.NoStatement{Exp: p.parseFactor(pre)}
 		case lexer.IDType:
 			if exp != nil {
@@ -471,8 +627,13 @@ func (p *Parser) parseFactor(pre int) runtime.Invokable {
 					return exp
 				}
 			}
-			p.assertNil(exp)
+			p.assertNil(exp, token)
+			if module, ok := p.isModule(token); ok {
+				exp = p.parseModuleMember(module)
+				continue
+			}
 			exp = ast.GetVarStatement{
+				Pos:   p.pos(token),
 				VM:    p.vm,
 				Label: token.Val,
 			}
@@ -484,12 +645,22 @@ func (p *Parser) parseFactor(pre int) runtime.Invokable {
 					return exp
 				}
 			}
-			p.assertNil(exp)
+			p.assertNil(exp, token)
 			exp = ast.String(token.Val)
 		case lexer.IntType:
-			p.assertNil(exp)
-			val, _ := strconv.ParseInt(token.Val, 10, 64)
+			p.assertNil(exp, token)
+			val, err := strconv.ParseInt(token.Val, 10, 64)
+			if err != nil {
+				p.errorAt(token, "invalid number %s", token.Val)
+			}
 			exp = ast.Int(val)
+		case lexer.FloatType:
+			p.assertNil(exp, token)
+			val, err := strconv.ParseFloat(token.Val, 64)
+			if err != nil {
+				p.errorAt(token, "invalid number %s", token.Val)
+			}
+			exp = ast.Float(val)
 		case lexer.FuncType: // func(){}()
 			if exp != nil {
 				p.putToken(token)
@@ -512,35 +683,58 @@ func (p *Parser) parseFactor(pre int) runtime.Invokable {
 			lexer.ModOpType,        // %
 			lexer.OrType:           // ||
 			if exp == nil {
-				log.Panic("exp nil")
+				p.errorAt(token, "missing left operand of `%s`", token.Typ.String())
 			}
 			if pre >= precedence(token.Typ) {
 				//				log.Println("return", pre, precedence(token.Typ))
 				p.putToken(token)
 				return exp
 			}
+			right := p.parseFactor(precedence(token.Typ))
+			if right == nil {
+				p.errorAt(token, "missing right operand of `%s`", token.Typ.String())
+			}
 			exp = ast.BinaryOpExpression{
 				OP:    token.Typ,
 				Left:  exp,
-				Right: p.parseFactor(precedence(token.Typ)),
+				Right: right,
+			}
+		case lexer.IsType:
+			if exp == nil {
+				p.errorAt(token, "missing left operand of `is`")
+			}
+			if pre >= precedence(token.Typ) {
+				p.putToken(token)
+				return exp
+			}
+			exp = ast.IsExpression{
+				Pos:   p.pos(token),
+				Exp:   exp,
+				Types: []ast.TypeName{p.parseTypeRef()},
 			}
 		case lexer.IncType:
-			p.assertNoNil(exp)
+			p.assertNoNil(exp, token)
 			exp = ast.IncFieldStatement{
-				Exp: exp,
+				Pos: p.pos(token),
+				Exp: target(exp),
 			}
 		case lexer.NilType:
-			p.assertNil(exp)
+			p.assertNil(exp, token)
 			exp = ast.NilObject{}
 		case lexer.LeftBraceType:
 			if status := p.getStatus(); status == IfStatus || status == ForStatus {
-				p.putToken(token)
 				if exp == nil {
-					log.Panicf("unexpect token %s", token)
+					exp = p.parseMapStatement()
+					continue
 				}
+				p.putToken(token)
 				//log.Println("return {")
 				return exp
 			}
+			if exp == nil {
+				exp = p.parseMapStatement()
+				continue
+			}
 			return p.ParseObjInitStatement(exp)
 		case lexer.LeftBracketType:
 			exp = p.parseBracketStatement(exp)
@@ -548,12 +742,13 @@ func (p *Parser) parseFactor(pre int) runtime.Invokable {
 			return exp
 		default:
 			if p.isTerminateToken(token) == false {
-				log.Panicf("unexpect token %s", token)
+				p.errorAt(token, "unexpected %s", describe(token))
 			}
-			p.putToken(token)
 			if exp == nil {
-				log.Panicf("unexpect token %s", token)
+				p.putToken(token)
+				p.errorAt(token, "expect expression, found %s", describe(token))
 			}
+			p.putToken(token)
 			return exp
 		}
 	}
@@ -566,6 +761,7 @@ func (p *Parser) parseFactor(pre int) runtime.Invokable {
 	getBracketStatement:ID[]
 		|ID[Factor]
 		|ID[FactorList]
+		|ID[Factor:Factor] // slice,bounds may be omitted
 
 	initBracketStatement:[Factor]
 			|[FactorList]
@@ -581,21 +777,60 @@ func (p *Parser) parseBracketStatement(exp runtime.Invokable) runtime.Invokable
 				p.nextToken()
 				return &arrayStatement
 			}
+			p.expectNoEOF()
 			arrayStatement.Inits = append(arrayStatement.Inits, p.parseFactor(0))
 			if p.ahead(0).Typ == lexer.CommaType { // ,
 				p.nextToken()
 			}
 		}
 	} else { //Get array field
-		index := p.parseFactor(0)
+		pos := p.pos(p.lastToken())
+		var index runtime.Invokable
+		if p.ahead(0).Typ != lexer.ColonType {
+			index = p.parseFactor(0)
+		}
+		// slice,a[lo:hi]
+		if p.ahead(0).Typ == lexer.ColonType {
+			p.nextToken()
+			var high runtime.Invokable
+			if p.ahead(0).Typ != lexer.RightBracketType {
+				high = p.parseFactor(0)
+			}
+			p.expectType(p.nextToken(), lexer.RightBracketType)
+			return ast.SliceExpression{Pos: pos, Exp: exp, Low: index, High: high}
+		}
 		p.expectType(p.nextToken(), lexer.RightBracketType)
-		return ast.ArrayGetElement{
+		return ast.IndexExpression{
+			Pos:   pos,
 			Exp:   exp,
 			Index: index,
 		}
 	}
 }
 
+/*
+	mapStatement:{}
+		|{Factor:Factor,...}
+*/
+func (p *Parser) parseMapStatement() runtime.Invokable {
+	var mapStatement ast.MakeMapStatement
+	for {
+		if p.ahead(0).Typ == lexer.RightBraceType {
+			p.nextToken()
+			return &mapStatement
+		}
+		p.expectNoEOF()
+		mapStatement.Keys = append(mapStatement.Keys, p.parseFactor(0))
+		p.expectType(p.nextToken(), lexer.ColonType)
+		mapStatement.Values = append(mapStatement.Values, p.parseFactor(0))
+		if ahead := p.ahead(0); ahead.Typ == lexer.CommaType { // ,
+			p.nextToken()
+		} else if ahead.Typ != lexer.RightBraceType {
+			p.errorAt(ahead, "expect `,` or `}`, found %s", describe(ahead))
+		}
+	}
+}
+
 func (p *Parser) ahead(index int) lexer.Token {
 	if len(p.tokens) <= index {
 		return lexer.Token{Typ: lexer.EOFType}
@@ -603,15 +838,32 @@ func (p *Parser) ahead(index int) lexer.Token {
 	return p.tokens[index]
 }
 
-func (p *Parser) parseCallStatement(parentExp, function runtime.Invokable) runtime.Invokable {
+/*
+callStatement:
+
+	|function(arguments)
+	|Type(arguments) // object created,init is called with arguments
+*/
+func (p *Parser) parseCallStatement(function runtime.Invokable) runtime.Invokable {
 	var call ast.CallStatement
+	call.Pos = p.pos(p.lastToken())
 	call.Function = function
-	call.ParentExp = parentExp
 	for {
 		if p.ahead(0).Typ == lexer.RightParenthesisType {
 			p.nextToken()
+			if p.isConstructor(function) {
+				return ast.ObjectInitStatement{
+					VM:                p.vm,
+					Exp:               function,
+					Constructor:       true,
+					Arguments:         call.Arguments,
+					ArgumentPositions: call.ArgumentPositions,
+				}
+			}
 			return &call
 		}
+		p.expectNoEOF()
+		call.ArgumentPositions = append(call.ArgumentPositions, p.pos(p.ahead(0)).Position)
 		call.Arguments = append(call.Arguments, p.parseFactor(0))
 		if p.ahead(0).Typ == lexer.CommaType { // ,
 			p.nextToken()
@@ -645,6 +897,7 @@ func User.add(){
 */
 func (p *Parser) parseFuncStatement() *ast.FuncExpression {
 	var funcS ast.FuncExpression
+	funcS.Pos = p.pos(p.lastToken())
 	token := p.nextToken()
 	p.expectType(token, lexer.IDType)
 
@@ -660,45 +913,78 @@ func (p *Parser) parseFuncStatement() *ast.FuncExpression {
 	if len(funcS.Labels) != 0 {
 		funcS.Parameters = append(funcS.Parameters, "this")
 	}
-	funcS.Parameters = append(funcS.Parameters, p.parseFuncParameters()...)
+	parameters, types := p.parseFuncParameters()
+	funcS.Parameters = append(funcS.Parameters, parameters...)
+	if types != nil {
+		if len(funcS.Labels) != 0 {
+			types = append([]string{funcS.Labels[0]}, types...)
+		}
+		funcS.ParameterTypes = types
+	}
+	funcS.Returns = p.parseReturnTypes()
+	if len(funcS.Labels) != 0 {
+		p.define(token, Definition{Kind: MethodDefinition, Name: strings.Join(funcS.Labels, "."), Parameters: parameters})
+	} else {
+		p.define(token, Definition{Kind: FunctionDefinition, Name: funcS.Label, Parameters: parameters})
+	}
 	p.expectType(p.nextToken(), lexer.LeftBraceType)
+	p.pushStatus(FunctionStatus)
+	defer func() {
+		p.assertTrue(p.popStatus() == FunctionStatus)
+	}()
 	for {
 		if p.ahead(0).Typ == lexer.RightBraceType {
 			p.nextToken()
 			break
 		}
-		funcS.Statements = append(funcS.Statements, p.ParseStatement())
+		p.expectNoEOF()
+		funcS.Statements = append(funcS.Statements, p.statement())
 	}
 	funcS.VM = p.vm
 	return &funcS
 }
 
-func (p *Parser) parseFuncParameters() []string {
-	var parameters []string
+// parseFuncParameters return parameters and their annotated types,types
+// is nil when no parameter annotated
+func (p *Parser) parseFuncParameters() (parameters []string, types []string) {
 	if p.ahead(0).Typ == lexer.RightParenthesisType {
 		p.nextToken()
-		return nil
+		return nil, nil
 	}
 	for {
 		token := p.nextToken()
 		p.expectType(token, lexer.IDType)
 		parameters = append(parameters, token.Val)
 		p.closureCheckAddVar(token.Val)
+		if p.isAnnotation(token) {
+			for len(types) < len(parameters)-1 {
+				types = append(types, "")
+			}
+			types = append(types, p.parseTypeName())
+		}
 		if ahead := p.ahead(0); ahead.Typ == lexer.CommaType {
 			p.nextToken()
 			continue
 		} else if ahead.Typ == lexer.RightParenthesisType {
 			p.nextToken()
 			break
+		} else {
+			p.errorAt(ahead, "expect `,` or `)`, found %s", describe(ahead))
 		}
 	}
-	return parameters
+	if types != nil {
+		for len(types) < len(parameters) {
+			types = append(types, "")
+		}
+	}
+	return parameters, types
 }
 
 func (p *Parser) parseBoolExpression(pre int) runtime.Invokable {
 	var exp runtime.Invokable
 	exp = p.parseFactor(0)
-	if _, ok := exp.(ast.BinaryOpExpression); ok {
+	switch exp.(type) {
+	case ast.BinaryOpExpression, ast.IsExpression:
 		return exp
 	}
 	log.Printf("parse exp bool failed,%s", exp.String())
@@ -713,11 +999,13 @@ func (p *Parser) parseIfStatement(elseif bool) ast.Expression {
 		//log.Println("out parseIfStatement")
 	}()
 	var ifS = ast.IfExpression{
-		VM: p.vm,
+		Pos: p.pos(p.lastToken()),
+		VM:  p.vm,
 	}
-	if p.ahead(0).Typ != lexer.LeftBraceType {
-		ifS.Check = p.parseBoolExpression(0)
+	if ahead := p.ahead(0); ahead.Typ == lexer.LeftBraceType {
+		p.errorAt(ahead, "missing condition in `if` statement")
 	}
+	ifS.Check = p.parseBoolExpression(0)
 	p.expectType(p.nextToken(), lexer.LeftBraceType)
 
 	if p.ahead(0).Typ == lexer.RightBraceType {
@@ -746,15 +1034,21 @@ func (p *Parser) parseIfStatement(elseif bool) ast.Expression {
 	return ifS
 
 }
-func (p *Parser) assertNil(exp runtime.Invokable) {
+func (p *Parser) assertNil(exp runtime.Invokable, token lexer.Token) {
 	if exp != nil {
-		log.Panicf("expect nil")
+		p.errorAt(token, "unexpected %s", describe(token))
 	}
 }
 
-func (p *Parser) assertNoNil(exp runtime.Invokable) {
+func (p *Parser) assertNoNil(exp runtime.Invokable, token lexer.Token) {
 	if exp == nil {
-		log.Panicf("expect nil")
+		p.errorAt(token, "unexpected %s", describe(token))
+	}
+}
+
+func (p *Parser) expectNoEOF() {
+	if token := p.ahead(0); token.Typ == lexer.EOFType {
+		p.errorAt(token, "unexpected EOF")
 	}
 }
 
@@ -766,10 +1060,18 @@ func (p *Parser) isTerminateToken(next lexer.Token) bool {
 		next.Typ == lexer.SemicolonType ||
 		next.Typ == lexer.RightBracketType ||
 		next.Typ == lexer.CommaType ||
+		next.Typ == lexer.ColonType ||
 		next.Typ == lexer.VarType ||
 		next.Typ == lexer.BreakType ||
+		next.Typ == lexer.ContinueType ||
+		next.Typ == lexer.TryType ||
+		next.Typ == lexer.ImportType ||
 		next.Typ == lexer.ReturnType ||
 		next.Typ == lexer.TypeType ||
+		next.Typ == lexer.InterfaceType ||
+		next.Typ == lexer.SwitchType ||
+		next.Typ == lexer.CaseType ||
+		next.Typ == lexer.DefaultType ||
 		next.Typ == lexer.EOFType {
 		return true
 	}
@@ -781,7 +1083,7 @@ func (p *Parser) historyToken(i int) lexer.Token {
 	if index < len(p.hTokens) && index >= 0 {
 		return p.hTokens[index]
 	}
-	log.Panic("out of history tokens range")
+	p.errorAt(p.lastToken(), "out of history tokens range")
 	return lexer.EmptyToken
 }
 
@@ -816,22 +1118,78 @@ func (p *Parser) popClosureLabels() []string {
 func (p *Parser) parseReturn() ast.Expression {
 	if p.ahead(0).Typ == lexer.RightBraceType {
 		return ast.ReturnStatement{
+			Pos: p.pos(p.lastToken()),
 			Exp: ast.NilObject{},
 			Val: ast.NilObject{},
 		}
 	}
 	return ast.ReturnStatement{
-		Exp: p.parseFactor(0),
+		Pos: p.pos(p.lastToken()),
+		Exp: p.parseValues(),
 	}
 }
 
-func (p *Parser) parseForStatement() ast.Expression {
+// parseValues parse expressions separated by comma,more than one
+// expression is TupleExpression
+func (p *Parser) parseValues() runtime.Invokable {
+	var exps ast.Expressions
+	for {
+		exps = append(exps, p.parseFactor(0))
+		if p.ahead(0).Typ != lexer.CommaType {
+			break
+		}
+		p.nextToken()
+	}
+	if len(exps) == 1 {
+		return exps[0]
+	}
+	return ast.TupleExpression{Exps: exps}
+}
+
+/*
+	labeledStatement:
+		|ID: for ... {}
+*/
+func (p *Parser) parseLabeledStatement(label lexer.Token) ast.Expression {
+	if p.findLoopLabel(label.Val) {
+		p.errorAt(label, "label `%s` already defined", label.Val)
+	}
+	p.nextToken() // :
+	p.nextToken() // for
+	return p.parseForStatement(label.Val)
+}
+
+// findLoopLabel find label of for loops enclosing current statement
+func (p *Parser) findLoopLabel(label string) bool {
+	for i := len(p.status) - 1; i >= 0; i-- {
+		if p.status[i] == ForStatus && p.loopLabels[i] == label {
+			return true
+		} else if p.status[i] == FunctionStatus {
+			break
+		}
+	}
+	return false
+}
+
+func (p *Parser) parseForStatement(label string) ast.Expression {
 	p.pushStatus(ForStatus)
+	if label != "" {
+		if p.loopLabels == nil {
+			p.loopLabels = map[int]string{}
+		}
+		p.loopLabels[len(p.status)-1] = label
+	}
 	defer func() {
+		delete(p.loopLabels, len(p.status)-1)
 		p.assertTrue(p.popStatus() == ForStatus)
 	}()
 	var forStatement = ast.ForExpression{
-		VM: p.vm,
+		Pos:   p.pos(p.lastToken()),
+		VM:    p.vm,
+		Label: label,
+	}
+	if p.isRangeStatement() {
+		return p.parseRangeStatement(forStatement)
 	}
 	token := p.nextToken()
 	//log.Println(token)
@@ -847,10 +1205,15 @@ func (p *Parser) parseForStatement() ast.Expression {
 		return &forStatement
 	} else {
 		//support object := expression;
-		p.putToken(token)
-		expression := p.parseVarInitStatement()
-		if p.nextToken().Typ != lexer.SemicolonType {
-			log.Panic("expect ; in `for` statement")
+		var expression ast.Expression
+		if token.Typ == lexer.VarType {
+			expression = p.parseVarStatement()
+		} else {
+			p.putToken(token)
+			expression = p.parseVarInitStatement()
+		}
+		if next := p.nextToken(); next.Typ != lexer.SemicolonType {
+			p.errorAt(next, "expect `;` in `for` statement, found %s", describe(next))
 			return nil
 		}
 		forStatement.Pre = expression
@@ -862,8 +1225,8 @@ func (p *Parser) parseForStatement() ast.Expression {
 	} else {
 		p.putToken(token)
 		expression := p.parseFactor(0)
-		if p.nextToken().Typ != lexer.SemicolonType {
-			log.Panic("expect ; in `for` check exp")
+		if next := p.nextToken(); next.Typ != lexer.SemicolonType {
+			p.errorAt(next, "expect `;` after `for` check expression, found %s", describe(next))
 		}
 		forStatement.Check = expression
 	}
@@ -885,15 +1248,61 @@ func (p *Parser) parseForStatement() ast.Expression {
 	return forStatement
 }
 
+// isRangeStatement check `range`,`k := range`,`k, v := range` after `for`
+func (p *Parser) isRangeStatement() bool {
+	if p.ahead(0).Typ == lexer.RangeType {
+		return true
+	}
+	if p.ahead(0).Typ != lexer.IDType {
+		return false
+	}
+	if p.ahead(1).Typ == lexer.VarInitType {
+		return p.ahead(2).Typ == lexer.RangeType
+	}
+	return p.ahead(1).Typ == lexer.CommaType &&
+		p.ahead(2).Typ == lexer.IDType &&
+		p.ahead(3).Typ == lexer.VarInitType &&
+		p.ahead(4).Typ == lexer.RangeType
+}
+
+/*
+	rangeStatement:
+		|for range Factor {}
+		|for ID := range Factor {}
+		|for ID, ID := range Factor {}
+*/
+func (p *Parser) parseRangeStatement(forStatement ast.ForExpression) ast.Expression {
+	if token := p.nextToken(); token.Typ == lexer.IDType {
+		forStatement.Key = token.Val
+		if next := p.nextToken(); next.Typ == lexer.CommaType {
+			forStatement.Value = p.nextToken().Val
+			p.nextToken() // :=
+		}
+		p.nextToken() // range
+	}
+	forStatement.Range = p.parseFactor(0)
+	p.assertNoNil(forStatement.Range, p.historyToken(1))
+	p.expectType(p.nextToken(), lexer.LeftBraceType)
+	forStatement.Statements = p.ParseStatements()
+	p.expectType(p.nextToken(), lexer.RightBraceType)
+	return forStatement
+}
+
 func (p *Parser) ParseStatements() ast.Expressions {
+	p.pushStatus(BlockStatus)
+	defer func() {
+		p.assertTrue(p.popStatus() == BlockStatus)
+	}()
 	var statements ast.Expressions
 	if p.ahead(0).Typ == lexer.RightBraceType {
 		return append(statements, ast.NopStatement{})
 	}
 	for {
-		statement := p.ParseStatement()
+		statement := p.statement()
 		statements = append(statements, statement)
-		if p.ahead(0).Typ == lexer.RightBraceType {
+		if p.ahead(0).Typ != lexer.RightBraceType {
+			p.expectNoEOF()
+		} else {
 			if p.getStatus() == GlobalStatus {
 				p.nextToken()
 				continue
@@ -911,7 +1320,7 @@ func (p *Parser) ParseObjInitStatement(exp runtime.Invokable) runtime.Invokable
 		p.nextToken()
 		return statement
 	} else {
-		statement.PropTemplates = p.parseTypeObjectInit()
+		statement.PropTemplates = p.parseTypeObjectInit(false)
 	}
 	p.expectType(p.nextToken(), lexer.RightBraceType)
 	return statement
@@ -922,7 +1331,7 @@ func (p *Parser) pushStatus(status PStatus) {
 }
 func (p *Parser) popStatus() PStatus {
 	if len(p.status) == 0 {
-		log.Panic("status stack empty")
+		p.errorAt(p.lastToken(), "status stack empty")
 	}
 	status := p.status[len(p.status)-1]
 	p.status = p.status[:len(p.status)-1]
@@ -946,33 +1355,208 @@ func (p *Parser) checkInStatus(status PStatus) bool {
 	return false
 }
 
-func (p Parser) getStatus() PStatus {
+// innerStatus return the innermost of statuses enclosing current statement
+// in the function,-1 if none of them
+func (p *Parser) innerStatus(statuses ...PStatus) PStatus {
+	for i := len(p.status) - 1; i >= 0 && p.status[i] != FunctionStatus; i-- {
+		for _, status := range statuses {
+			if p.status[i] == status {
+				return status
+			}
+		}
+	}
+	return -1
+}
+
+func (p *Parser) getStatus() PStatus {
 	if len(p.status) != 0 {
 		return p.status[len(p.status)-1]
 	}
-	log.Panic("status stack empty")
+	p.errorAt(p.lastToken(), "status stack empty")
 	return 0
 }
 
-func (p *Parser) parseBreakStatement() ast.Expression {
+func (p *Parser) parseBreakStatement(token lexer.Token) ast.Expression {
 	if p.checkInStatus(ForStatus) == false {
-		log.Panic("current no in `for` brock ")
+		p.errorAt(token, "`break` is not in `for` loop")
+	}
+	if label := p.parseLoopLabel(token); label != "" {
+		return &ast.BreakObject{Label: label}
+	}
+	if p.innerStatus(ForStatus, CaseStatus) == CaseStatus {
+		p.errorAt(token, "`break` in `case` of switch is not supported, use label of `for` loop")
 	}
 	return ast.BreakObj
 }
 
+func (p *Parser) parseContinueStatement(token lexer.Token) ast.Expression {
+	if p.checkInStatus(ForStatus) == false {
+		p.errorAt(token, "`continue` is not in `for` loop")
+	}
+	if label := p.parseLoopLabel(token); label != "" {
+		return &ast.ContinueObject{Label: label}
+	}
+	return ast.ContinueObj
+}
+
+// parseLoopLabel parse label follow break or continue in the same line
+func (p *Parser) parseLoopLabel(token lexer.Token) string {
+	label := p.ahead(0)
+	if label.Typ != lexer.IDType || label.Line != token.Line {
+		return ""
+	}
+	p.nextToken()
+	if p.findLoopLabel(label.Val) == false {
+		p.errorAt(label, "undefined loop label `%s`", label.Val)
+	}
+	return label.Val
+}
+
+/*
+	tryStatement:
+		|try {} catch ID {}
+*/
+func (p *Parser) parseTryStatement(token lexer.Token) ast.Expression {
+	var tryStatement = ast.TryStatement{
+		Pos: p.pos(token),
+		VM:  p.vm,
+	}
+	p.expectType(p.nextToken(), lexer.LeftBraceType)
+	tryStatement.Statements = p.ParseStatements()
+	p.expectType(p.nextToken(), lexer.RightBraceType)
+	if next := p.nextToken(); next.Typ != lexer.CatchType {
+		p.errorAt(next, "expect `catch` after `try` block, found %s", describe(next))
+	}
+	label := p.nextToken()
+	p.expectType(label, lexer.IDType)
+	tryStatement.Label = label.Val
+	p.closureCheckAddVar(label.Val)
+	p.expectType(p.nextToken(), lexer.LeftBraceType)
+	tryStatement.Catch = p.ParseStatements()
+	p.expectType(p.nextToken(), lexer.RightBraceType)
+	return tryStatement
+}
+
+// isVarInitList check the rest of `a, b, c :=` after the first comma
+func (p *Parser) isVarInitList() bool {
+	for index := 0; ; index += 2 {
+		if p.ahead(index).Typ != lexer.IDType {
+			return false
+		}
+		switch p.ahead(index + 1).Typ {
+		case lexer.VarInitType:
+			return true
+		case lexer.CommaType:
+		default:
+			return false
+		}
+	}
+}
+
+/*
+	multiAssignStatement:
+		|left, left = values
+	left:
+		|ID
+		|left.ID
+		|left[exp]
+*/
+func (p *Parser) parseMultiAssignStatement(token lexer.Token, exp runtime.Invokable) ast.Expression {
+	var lefts = []runtime.Invokable{exp}
+	for {
+		left := p.nextToken()
+		p.expectType(left, lexer.IDType)
+		var exp runtime.Invokable = ast.GetVarStatement{Pos: p.pos(left), VM: p.vm, Label: left.Val}
+	selector:
+		for {
+			switch p.ahead(0).Typ {
+			case lexer.PeriodType:
+				p.nextToken()
+				field := p.nextToken()
+				p.expectType(field, lexer.IDType)
+				exp = ast.PeriodStatement{VM: p.vm, Val: field.Val, Exp: exp}
+			case lexer.LeftBracketType:
+				p.nextToken()
+				exp = p.parseBracketStatement(exp)
+			default:
+				break selector
+			}
+		}
+		lefts = append(lefts, exp)
+		next := p.nextToken()
+		if next.Typ == lexer.AssignType {
+			break
+		}
+		if next.Typ != lexer.CommaType {
+			p.errorAt(next, "expect `=`, found %s", describe(next))
+		}
+	}
+	return p.newMultiAssignStatement(token, lefts, false)
+}
+
+// newMultiAssignStatement parse the values assigned to lefts,only call of
+// function can return multiple values
+func (p *Parser) newMultiAssignStatement(token lexer.Token, lefts []runtime.Invokable, define bool) ast.Expression {
+	var values = 1
+	exp := p.parseValues()
+	if tuple, ok := exp.(ast.TupleExpression); ok {
+		values = len(tuple.Exps)
+	} else if exp.GetType() == lexer.CallType {
+		values = len(lefts)
+	}
+	if values != len(lefts) {
+		p.errorAt(token, "assignment mismatch: %d variables but %d values", len(lefts), values)
+	}
+	for index := range lefts {
+		lefts[index] = target(lefts[index])
+	}
+	return ast.MultiAssignStatement{
+		Pos:    p.pos(token),
+		VM:     p.vm,
+		Define: define,
+		Lefts:  lefts,
+		Exp:    exp,
+	}
+}
+
 func (p *Parser) parseAssignStatement(exp runtime.Invokable) ast.AssignStatement {
 	return ast.AssignStatement{
+		Pos:  p.pos(p.lastToken()),
 		Exp:  p.parseFactor(0),
-		Left: exp,
+		Left: target(exp),
 	}
 }
 
-func (p *Parser) addUserFunction(function *ast.FuncExpression) {
+// target return exp assigned,method of field is not bound to object
+func target(exp runtime.Invokable) runtime.Invokable {
+	if period, ok := exp.(ast.PeriodStatement); ok {
+		period.Bind = false
+		return period
+	}
+	return exp
+}
+
+// isConstructor check function called is type,`List(10)`
+func (p *Parser) isConstructor(function runtime.Invokable) bool {
+	switch function := function.(type) {
+	case ast.GetVarStatement:
+		return p.isTypeName(function.Label)
+	case ast.ModuleMember:
+		object := function.Module.VM.GetTypeObject(function.Label)
+		if object == nil {
+			return false
+		}
+		_, ok := object.Pointer.(*ast.TypeObject)
+		return ok
+	}
+	return false
+}
+
+func (p *Parser) addUserFunction(name lexer.Token, function *ast.FuncExpression) {
 	if function.Labels != nil {
 		structObject := p.vm.GetTypeObject(function.Labels[0])
 		if structObject == nil { //todo fixme
-			log.Panic("no find structObject", function.Labels[0])
+			p.errorAt(name, "undefined type `%s`", function.Labels[0])
 		}
 		structObject.Pointer.(*ast.TypeObject).AddObject(function.Labels[1], &runtime.Object{
 			Pointer: function,
@@ -980,8 +1564,11 @@ func (p *Parser) addUserFunction(function *ast.FuncExpression) {
 		})
 		return
 	}
-	if _, ok := runtime.Functions[function.Label]; ok {
-		log.Panic("function name conflict with built in function", function.Label)
+	if _, ok := p.vm.Functions[function.Label]; ok {
+		p.errorAt(name, "function `%s` conflict with built in function", function.Label)
+	}
+	if _, ok := p.vm.GlobalFunctions[function.Label]; ok {
+		p.errorAt(name, "function `%s` redeclared", function.Label)
 	}
 	p.vm.AddGlobalFunction(&runtime.Object{
 		Pointer: function,
@@ -2,8 +2,10 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
+	"gitlab.com/akzj/qp/ast"
 	"gitlab.com/akzj/qp/lexer"
 )
 
@@ -163,3 +165,69 @@ func(){
 		fmt.Println(statements.String())
 	}
 }
+
+func TestTypeFields(t *testing.T) {
+	p := New("type User strict {\n\tname\n\tage int = 18, score float\n\ttags: nil\n}\nvar u = User{name: \"bob\"}\n")
+	statements, errs := p.ParseWithErrors()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	object := p.GetVMContext().GetTypeObject("User").Pointer.(*ast.TypeObject)
+	if object.Strict == false {
+		t.Fatal("type is no strict")
+	}
+	var fields []string
+	for _, template := range object.TypeObjectPropTemplates {
+		fields = append(fields, template.Name+" "+template.Type+" "+template.Exp.String())
+	}
+	expect := []string{"name  nil", "age int 18", "score float 0", "tags  nil"}
+	if strings.Join(fields, ",") != strings.Join(expect, ",") {
+		t.Fatalf("unexpected fields %q", fields)
+	}
+	statements.Invoke()
+	if u := p.GetVMContext().GetVar("u").Pointer.String(); u != "User{age:18 name:bob score:0 tags:nil}" {
+		t.Fatalf("unexpected object %s", u)
+	}
+}
+
+func TestInterface(t *testing.T) {
+	p := New("interface Printer {\n\tprint(prefix string) string\n}\ninterface Named { name(); Printer }\n" +
+		"type User {\n\tfirst string\n}\nfunc User.print(prefix string) string {\n\treturn prefix + this.first\n}\n" +
+		"type Admin {\n\tUser\n}\nvar a = Admin{User: User{first: \"ann\"}}\nvar s = a.print(\"> \")\nvar ok = a is Printer\n")
+	statements, errs := p.ParseWithErrors()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	named := p.GetVMContext().GetTypeObject("Named").Pointer.(*ast.InterfaceObject)
+	if names := named.MethodNames(); strings.Join(names, ",") != "name,print" {
+		t.Fatalf("unexpected methods %q", names)
+	}
+	admin := p.GetVMContext().GetTypeObject("Admin").Pointer.(*ast.TypeObject)
+	if admin.GetObject("print") == nil {
+		t.Fatal("method `print` no promoted")
+	}
+	statements.Invoke()
+	if s := p.GetVMContext().GetVar("s").Pointer.(ast.String); s != "> ann" {
+		t.Fatalf("unexpected result %s", s)
+	}
+	if ok := p.GetVMContext().GetVar("ok").Pointer.String(); ok != "true" {
+		t.Fatalf("unexpected result %s", ok)
+	}
+
+	testcases := []struct {
+		data string
+		err  string
+	}{
+		{"interface A { B }\ninterface B { A }\n", "recursive interface"},
+		{"interface A { Unknown }\n", "undefined interface `Unknown` embedded by `A`"},
+		{"type A {}\nfunc A.m() {}\ntype B {}\nfunc B.m() {}\ntype C {\n\tA\n\tB\n}\n",
+			"ambiguous method `m` of type `C` promoted from A and B"},
+		{"case int:\n", "`case` is not in `switch` statement"},
+	}
+	for _, testcase := range testcases {
+		_, errs := New(testcase.data).ParseWithErrors()
+		if len(errs) == 0 || strings.Contains(errs[0].Error(), testcase.err) == false {
+			t.Fatalf("expect error %q,got %v", testcase.err, errs)
+		}
+	}
+}
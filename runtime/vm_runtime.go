@@ -85,26 +85,76 @@ func (m *Memory) popStackFrame() {
 	}
 }
 
+// StackMark is a snapshot of the stack pointers,see VMRuntime.Mark
+type StackMark struct {
+	frame StackFrame
+	size  int
+}
+
+func (m *Memory) mark() StackMark {
+	return StackMark{
+		frame: StackFrame{
+			stackTopPointer:    m.stackTopPointer,
+			stackBottomPointer: m.stackBottomPointer,
+			stackGCPointer:     m.stackGCPointer,
+		},
+		size: len(m.stackFrames),
+	}
+}
+
+func (m *Memory) reset(mark StackMark) {
+	for i := mark.frame.stackTopPointer; i < m.stackTopPointer; i++ {
+		m.stack[i] = nil
+	}
+	if len(m.stackFrames) > mark.size {
+		m.stackFrames = m.stackFrames[:mark.size]
+	}
+	m.stackTopPointer = mark.frame.stackTopPointer
+	m.stackBottomPointer = mark.frame.stackBottomPointer
+	m.stackGCPointer = mark.frame.stackGCPointer
+}
+
 type VMRuntime struct {
 	mem             *Memory
+	Functions       map[string]*Object // built in and host functions
+	ArrayFunctions  map[string]*Object // built in methods of array
+	StringFunctions map[string]*Object // built in methods of string
 	GlobalFunctions map[string]*Object
 	structObjects   map[string]*Object
+	calls           int // functions being called
 }
 
+// MaxCallDepth is the limit of nested calls of functions,deeper call is
+// stack overflow
+const MaxCallDepth = 10000
+
+// New make VMRuntime with a copy of the built in Functions,ArrayFunctions
+// and StringFunctions,functions added to one VMRuntime are invisible to others
 func New() *VMRuntime {
 	return &VMRuntime{
 		mem:             NewMemory(),
+		Functions:       copyFunctions(Functions),
+		ArrayFunctions:  copyFunctions(ArrayFunctions),
+		StringFunctions: copyFunctions(StringFunctions),
 		structObjects:   map[string]*Object{},
 		GlobalFunctions: map[string]*Object{},
 	}
 }
 
+func copyFunctions(functions map[string]*Object) map[string]*Object {
+	copied := make(map[string]*Object, len(functions))
+	for label, object := range functions {
+		copied[label] = object
+	}
+	return copied
+}
+
 func (ctx *VMRuntime) AllocObject(label string) *Object {
 	return ctx.mem.Alloc(label)
 }
 
 func (ctx *VMRuntime) GetObject(label string) *Object {
-	if obj, ok := Functions[label]; ok {
+	if obj, ok := ctx.Functions[label]; ok {
 		return &Object{Pointer: obj}
 	}
 	if obj, ok := ctx.GlobalFunctions[label]; ok {
@@ -116,6 +166,17 @@ func (ctx *VMRuntime) GetObject(label string) *Object {
 	return ctx.mem.GetObject(label)
 }
 
+// Mark return current stack state,stack frames leaked by a panic
+// are dropped by Reset
+func (ctx *VMRuntime) Mark() StackMark {
+	return ctx.mem.mark()
+}
+
+// Reset drop objects and frames pushed after mark
+func (ctx *VMRuntime) Reset(mark StackMark) {
+	ctx.mem.reset(mark)
+}
+
 func (ctx *VMRuntime) PushStackFrame(isolate bool) {
 	ctx.mem.pushStackFrame(isolate)
 }
@@ -124,6 +185,30 @@ func (ctx *VMRuntime) PopStackFrame() {
 	ctx.mem.popStackFrame()
 }
 
+// EnterCall count call of function,false is returned when calls nest
+// deeper than MaxCallDepth.LeaveCall must be called when the call returns
+func (ctx *VMRuntime) EnterCall() bool {
+	if ctx.calls >= MaxCallDepth {
+		return false
+	}
+	ctx.calls++
+	return true
+}
+
+func (ctx *VMRuntime) LeaveCall() {
+	ctx.calls--
+}
+
+// AddFunction add built in or host function
+func (ctx *VMRuntime) AddFunction(object *Object) {
+	ctx.Functions[object.Label] = object
+}
+
+// GetVar return the var object with label in current stack frame
+func (ctx *VMRuntime) GetVar(label string) *Object {
+	return ctx.mem.GetObject(label)
+}
+
 func (ctx *VMRuntime) AddGlobalFunction(object *Object) {
 	if _, ok := ctx.GlobalFunctions[object.Label]; ok {
 		log.Panic("Object name repeated")
@@ -143,7 +228,7 @@ func (ctx *VMRuntime) GetTypeObject(label string) *Object {
 }
 
 func (ctx *VMRuntime) IsGlobal(label string) bool {
-	if _, ok := Functions[label]; ok {
+	if _, ok := ctx.Functions[label]; ok {
 		return true
 	}
 	return false
@@ -2,7 +2,10 @@ package stackmachine
 
 import (
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -12,15 +15,22 @@ type Function struct {
 	Call func(object ...Object) []Object
 }
 
-func __println(object ...Object) []Object {
-	for index, obj := range object {
-		if index != 0 {
-			fmt.Print(" ")
+// Println make println function which write to writer,os.Stdout when nil
+func Println(writer io.Writer) func(object ...Object) []Object {
+	return func(object ...Object) []Object {
+		var writer = writer
+		if writer == nil {
+			writer = os.Stdout
 		}
-		fmt.Print(obj)
+		for index, obj := range object {
+			if index != 0 {
+				fmt.Fprint(writer, " ")
+			}
+			fmt.Fprint(writer, obj)
+		}
+		fmt.Fprintln(writer)
+		return nil
 	}
-	fmt.Println()
-	return nil
 }
 
 func __tLower_(object ...Object) []Object {
@@ -39,8 +49,11 @@ func __print(object ...Object) []Object {
 }
 
 func __panic(object ...Object) []Object {
-	log.Panicln(object)
-	return nil
+	var messages []string
+	for _, obj := range object {
+		messages = append(messages, obj.String())
+	}
+	panic(strings.Join(messages, " "))
 }
 
 func __now__(object ...Object) []Object {
@@ -53,12 +66,78 @@ func __now__(object ...Object) []Object {
 	}
 }
 
+func __len__(object ...Object) []Object {
+	var length int
+	switch object[0].Type {
+	case Map:
+		length = len(object[0].Obj.(*mapObject).keys)
+	case Array:
+		length = len(object[0].Obj.(ObjectArray))
+	case String:
+		length = len(object[0].Obj.(string))
+	default:
+		panicln("len() unsupported type", object[0].String())
+	}
+	return []Object{{Type: Int, Int: int64(length)}}
+}
+
+func __keys__(object ...Object) []Object {
+	if object[0].Type != Map {
+		panicln("keys() require map", object[0].String())
+	}
+	return []Object{{Type: Array, Obj: object[0].Obj.(*mapObject).keyObjects()}}
+}
+
+func __delete__(object ...Object) []Object {
+	if len(object) != 2 || object[0].Type != Map {
+		panicln("delete() Arguments error")
+	}
+	object[0].Obj.(*mapObject).delete(object[1])
+	return nil
+}
+
+func __int__(object ...Object) []Object {
+	switch object[0].Type {
+	case Int:
+		return []Object{object[0]}
+	case Float:
+		return []Object{{Type: Int, Int: int64(object[0].Float())}}
+	case String:
+		val, err := strconv.ParseInt(object[0].Obj.(string), 10, 64)
+		if err != nil {
+			panicln("int() invalid syntax", object[0].String())
+		}
+		return []Object{{Type: Int, Int: val}}
+	default:
+		panicln("int() unsupported type", object[0].String())
+	}
+	return nil
+}
+
+func __float__(object ...Object) []Object {
+	switch object[0].Type {
+	case Float:
+		return []Object{object[0]}
+	case Int:
+		return []Object{NewFloat(float64(object[0].Int))}
+	case String:
+		val, err := strconv.ParseFloat(object[0].Obj.(string), 64)
+		if err != nil {
+			panicln("float() invalid syntax", object[0].String())
+		}
+		return []Object{NewFloat(val)}
+	default:
+		panicln("float() unsupported type", object[0].String())
+	}
+	return nil
+}
+
 var BuiltInFunctionsIndex = map[string]int64{}
 
 var BuiltInFunctions = []Function{
 	{
 		Name: "println",
-		Call: __println,
+		Call: Println(nil),
 	},
 	{
 		Name: "print",
@@ -76,6 +155,26 @@ var BuiltInFunctions = []Function{
 		Name: "string.to_lower",
 		Call: __tLower_,
 	},
+	{
+		Name: "len",
+		Call: __len__,
+	},
+	{
+		Name: "keys",
+		Call: __keys__,
+	},
+	{
+		Name: "delete",
+		Call: __delete__,
+	},
+	{
+		Name: "int",
+		Call: __int__,
+	},
+	{
+		Name: "float",
+		Call: __float__,
+	},
 }
 
 func init() {
@@ -0,0 +1,243 @@
+package stackmachine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// .qpc file layout,integers are varint encoded
+//
+//	header        magic "qpc\x00",version
+//	symbol table  count,symbols
+//	builtin table count,names of built in functions at build time
+//	string pool   count,strings
+//	file table    count,source files
+//	instructions  count,instructions,Str is the index of string pool,
+//	              source position is the index of file table and line
+const (
+	BytecodeMagic   = "qpc\x00"
+	BytecodeVersion = 8
+)
+
+// ErrBadBytecode is returned by LoadBytecode when the input is not a .qpc file
+var ErrBadBytecode = errors.New("bad bytecode file")
+
+const maxBytecodeString = 1 << 24
+
+type bytecodeWriter struct {
+	writer *bufio.Writer
+	n      int64
+	err    error
+	buffer [binary.MaxVarintLen64]byte
+}
+
+func (w *bytecodeWriter) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.writer.Write(data)
+	w.n += int64(n)
+	w.err = err
+}
+
+func (w *bytecodeWriter) writeUvarint(val uint64) {
+	w.write(w.buffer[:binary.PutUvarint(w.buffer[:], val)])
+}
+
+func (w *bytecodeWriter) writeVarint(val int64) {
+	w.write(w.buffer[:binary.PutVarint(w.buffer[:], val)])
+}
+
+func (w *bytecodeWriter) writeString(s string) {
+	w.writeUvarint(uint64(len(s)))
+	w.write([]byte(s))
+}
+
+func (w *bytecodeWriter) writeStrings(strings []string) {
+	w.writeUvarint(uint64(len(strings)))
+	for _, s := range strings {
+		w.writeString(s)
+	}
+}
+
+// WriteTo write the generated code to writer in .qpc format
+func (genCode *CodeGenerator) WriteTo(writer io.Writer) (int64, error) {
+	pool := NewSymbolTable()
+	for _, ins := range genCode.ins {
+		pool.addSymbol(ins.Str)
+	}
+	w := &bytecodeWriter{writer: bufio.NewWriter(writer)}
+	w.write([]byte(BytecodeMagic))
+	w.writeUvarint(BytecodeVersion)
+	w.writeStrings(genCode.symbolTable.symbols)
+	w.writeStrings(genCode.builtSymbolTable.symbols)
+	w.writeStrings(pool.symbols)
+	w.writeStrings(genCode.files.symbols)
+	w.writeUvarint(uint64(len(genCode.ins)))
+	for _, ins := range genCode.ins {
+		str, _ := pool.getSymbol(ins.Str)
+		w.write([]byte{
+			byte(ins.Type),
+			byte(ins.ValTyp),
+			byte(ins.CmpTyp),
+			byte(ins.JumpTyp),
+			byte(ins.ResetStackTyp)})
+		w.writeVarint(ins.symbol)
+		w.writeVarint(ins.Val)
+		w.writeUvarint(uint64(str))
+		w.writeUvarint(uint64(ins.file))
+		w.writeUvarint(uint64(ins.Line))
+	}
+	if w.err == nil {
+		w.err = w.writer.Flush()
+	}
+	return w.n, w.err
+}
+
+type bytecodeReader struct {
+	reader *bufio.Reader
+	err    error
+}
+
+func (r *bytecodeReader) readUvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	var val uint64
+	val, r.err = binary.ReadUvarint(r.reader)
+	return val
+}
+
+func (r *bytecodeReader) readVarint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	var val int64
+	val, r.err = binary.ReadVarint(r.reader)
+	return val
+}
+
+func (r *bytecodeReader) readBytes(count uint64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if count > maxBytecodeString {
+		r.err = ErrBadBytecode
+		return nil
+	}
+	data := make([]byte, count)
+	_, r.err = io.ReadFull(r.reader, data)
+	return data
+}
+
+func (r *bytecodeReader) readStrings() []string {
+	count := r.readUvarint()
+	var strings []string
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		strings = append(strings, string(r.readBytes(r.readUvarint())))
+	}
+	return strings
+}
+
+// LoadBytecode read .qpc file from reader,the version,built in functions and
+// references of instructions are validated before the code is returned
+func LoadBytecode(reader io.Reader) (*CodeGenerator, error) {
+	r := &bytecodeReader{reader: bufio.NewReader(reader)}
+	if magic := r.readBytes(uint64(len(BytecodeMagic))); r.err != nil || string(magic) != BytecodeMagic {
+		return nil, ErrBadBytecode
+	}
+	if version := r.readUvarint(); r.err == nil && version != BytecodeVersion {
+		return nil, fmt.Errorf("unsupported bytecode version %d,want %d", version, BytecodeVersion)
+	}
+	genCode := NewCodeGenerator()
+	for _, symbol := range r.readStrings() {
+		genCode.symbolTable.addSymbol(symbol)
+	}
+	builtIns := r.readStrings()
+	pool := r.readStrings()
+	for _, file := range r.readStrings() {
+		genCode.files.addSymbol(file)
+	}
+	count := r.readUvarint()
+	for i := uint64(0); i < count && r.err == nil; i++ {
+		var header = r.readBytes(5)
+		if r.err != nil {
+			break
+		}
+		ins := Instruction{
+			Type:          InstType(header[0]),
+			ValTyp:        ValType(header[1]),
+			CmpTyp:        CmpType(header[2]),
+			JumpTyp:       JumpType(header[3]),
+			ResetStackTyp: ResetStackType(header[4]),
+			symbol:        r.readVarint(),
+			Val:           r.readVarint(),
+		}
+		str := r.readUvarint()
+		file := r.readUvarint()
+		ins.Line = int(r.readUvarint())
+		if r.err != nil {
+			break
+		}
+		if str >= uint64(len(pool)) {
+			return nil, fmt.Errorf("instruction %d: string %d out of pool", i, str)
+		}
+		if file >= uint64(len(genCode.files.symbols)) && file != 0 {
+			return nil, fmt.Errorf("instruction %d: file %d out of table", i, file)
+		}
+		ins.Str = pool[str]
+		ins.file = int64(file)
+		genCode.ins = append(genCode.ins, ins)
+	}
+	if r.err != nil {
+		if r.err == io.EOF || r.err == io.ErrUnexpectedEOF {
+			return nil, ErrBadBytecode
+		}
+		return nil, r.err
+	}
+	if err := genCode.validate(builtIns); err != nil {
+		return nil, err
+	}
+	return genCode, nil
+}
+
+// validate check instructions loaded from file,index of built in function
+// is relocated to the index of the running built in functions
+func (genCode *CodeGenerator) validate(builtIns []string) error {
+	for index, ins := range genCode.ins {
+		if ins.Type >= instTypeCount {
+			return fmt.Errorf("instruction %d: unknown instruction type %d", index, ins.Type)
+		}
+		if ins.ValTyp >= valTypeCount {
+			return fmt.Errorf("instruction %d: unknown value type %d", index, ins.ValTyp)
+		}
+		switch ins.Type {
+		case Call:
+			if ins.Val < 0 || ins.Val >= int64(len(builtIns)) {
+				return fmt.Errorf("instruction %d: built in function %d out of table", index, ins.Val)
+			}
+			builtIn, ok := BuiltInFunctionsIndex[builtIns[ins.Val]]
+			if ok == false {
+				return fmt.Errorf("instruction %d: unknown built in function `%s`", index, builtIns[ins.Val])
+			}
+			genCode.ins[index].Val = builtIn
+			genCode.ins[index].symbol = builtIn
+		case Jump, Try:
+			target := ins.Val
+			if ins.JumpTyp == RJump || ins.Type == Try {
+				target += int64(index)
+			}
+			if target < 0 || target > int64(len(genCode.ins)) {
+				return fmt.Errorf("instruction %d: jump target %d out of code", index, target)
+			}
+		default:
+			if ins.symbol < 0 || ins.symbol > 0 && ins.symbol >= int64(len(genCode.symbolTable.symbols)) {
+				return fmt.Errorf("instruction %d: symbol %d out of table", index, ins.symbol)
+			}
+		}
+	}
+	return nil
+}
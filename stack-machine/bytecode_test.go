@@ -0,0 +1,77 @@
+package stackmachine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp/parser"
+)
+
+func genScript(script string) *CodeGenerator {
+	parser := parser.New(script)
+	statements := parser.Parse()
+	for _, it := range parser.GetVMContext().Objects() {
+		statements = append(statements, it)
+	}
+	return NewCodeGenerator().Gen(statements)
+}
+
+func TestBytecode(t *testing.T) {
+	gen := genScript(`
+type User{}
+func User.hello(){
+	return "hello " + this.name
+}
+var user = User{name: "qp"}
+for i := 0; i < 2; i++ {
+	println(i, user.hello(), len("ab"), 1.5)
+}
+`)
+	var buffer bytes.Buffer
+	if _, err := gen.WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadBytecode(bytes.NewReader(buffer.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.String() != gen.String() {
+		t.Fatalf("loaded code mismatch\n%s\n%s", gen, loaded)
+	}
+	var output bytes.Buffer
+	if err := NewMachine(loaded, Options{Stdout: &output}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	if output.String() != "0 hello qp 2 1.5\n1 hello qp 2 1.5\n" {
+		t.Fatalf("unexpected output %q", output.String())
+	}
+}
+
+func TestBytecodeInvalid(t *testing.T) {
+	var buffer bytes.Buffer
+	if _, err := genScript(`println(1)`).WriteTo(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	data := buffer.Bytes()
+
+	if _, err := LoadBytecode(bytes.NewReader([]byte("println(1)"))); err != ErrBadBytecode {
+		t.Fatalf("expect bad bytecode error,got %v", err)
+	}
+	if _, err := LoadBytecode(bytes.NewReader(data[:len(data)-2])); err != ErrBadBytecode {
+		t.Fatalf("expect bad bytecode error,got %v", err)
+	}
+
+	version := append([]byte{}, data...)
+	version[len(BytecodeMagic)] = BytecodeVersion + 1
+	if _, err := LoadBytecode(bytes.NewReader(version)); err == nil ||
+		strings.Contains(err.Error(), "version") == false {
+		t.Fatalf("expect version error,got %v", err)
+	}
+
+	builtIn := bytes.Replace(data, []byte("println"), []byte("printlm"), 1)
+	if _, err := LoadBytecode(bytes.NewReader(builtIn)); err == nil ||
+		strings.Contains(err.Error(), "printlm") == false {
+		t.Fatalf("expect unknown built in function error,got %v", err)
+	}
+}
@@ -0,0 +1,569 @@
+package stackmachine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const debuggerPrompt = "(debug) "
+
+const debuggerHelp = `commands:
+	break|b [file:]line    pause at line,line of all files when file omitted
+	break|b function       pause at function call,Type.method for method
+	delete|d id            delete breakpoint
+	breakpoints            show breakpoints
+	continue|c             run until breakpoint
+	step|s                 run to next line,step into function call
+	next|n                 run to next line,step over function call
+	out|o                  run until current function return
+	locals                 show variables of current function
+	print|p name           show variable
+	stack|bt               show call stack
+	list|l                 show source around current line
+	quit|q                 stop program`
+
+// Breakpoint pause machine at source line or function call
+type Breakpoint struct {
+	ID       int
+	File     string // empty matches all files
+	Line     int
+	Function string
+	entry    int64 // IP of function body
+}
+
+func (b *Breakpoint) String() string {
+	if b.Function != "" {
+		return b.Function
+	}
+	if b.File == "" {
+		return strconv.Itoa(b.Line)
+	}
+	return b.File + ":" + strconv.Itoa(b.Line)
+}
+
+// Variable is local variable of function,field of object or element of
+// array and map
+type Variable struct {
+	Name    string
+	Value   Object
+	Closure bool // captured by lambda
+}
+
+// Resume is the way paused machine go on
+type Resume int
+
+const (
+	ResumeContinue Resume = iota // run until breakpoint
+	ResumeStepInto               // run to next line,step into function call
+	ResumeStepOver               // run to next line,step over function call
+	ResumeStepOut                // run until current function return
+	ResumeStop                   // stop program
+)
+
+// position is source line of instruction in call stack
+type position struct {
+	file  int64
+	line  int
+	depth int
+}
+
+// frameState is function call in call stack
+type frameState struct {
+	IP    int64
+	stack []Object
+	SP    int64
+}
+
+// Debugger pause machine at breakpoints and steps,pause is called when
+// machine paused and the returned Resume make machine go on
+type Debugger struct {
+	m           *Machine
+	pause       func(d *Debugger) Resume
+	breakpoints []*Breakpoint
+	nextID      int
+	step        Resume
+	from        position // position when step begin
+	last        position // position of last instruction
+	reason      string
+	stopped     bool
+	mutex       sync.Mutex // guard breakpoints,they may be set while running
+	interrupt   int32      // set by Pause or Stop from other goroutine
+}
+
+const (
+	interruptPause int32 = iota + 1
+	interruptStop
+)
+
+// AttachDebugger make debugger of m,the program is paused before the first
+// line when stopOnEntry
+func AttachDebugger(m *Machine, stopOnEntry bool, pause func(d *Debugger) Resume) *Debugger {
+	d := &Debugger{
+		m:     m,
+		pause: pause,
+		step:  ResumeContinue,
+		from:  position{line: -1},
+		last:  position{line: -1},
+	}
+	if stopOnEntry {
+		d.step = ResumeStepInto
+	}
+	m.hook = d.hook
+	return d
+}
+
+// NewDebugger make debugger of m run by commands read from in,the program
+// is paused before the first line
+func NewDebugger(m *Machine, in io.Reader, out io.Writer) *Debugger {
+	c := &console{in: bufio.NewScanner(in), out: out, sources: map[string][]string{}}
+	return AttachDebugger(m, true, c.prompt)
+}
+
+// Run run the program until exit or stop,exited is false when the program
+// is stopped by debugger
+func (d *Debugger) Run() (exited bool, err error) {
+	err = d.m.Run()
+	return d.stopped == false, err
+}
+
+// Reason return why machine paused: entry,breakpoint,step or pause
+func (d *Debugger) Reason() string {
+	return d.reason
+}
+
+// Pause make running machine pause at next line,it may be called from other
+// goroutine
+func (d *Debugger) Pause() {
+	atomic.StoreInt32(&d.interrupt, interruptPause)
+}
+
+// Stop make running machine stop,it may be called from other goroutine
+func (d *Debugger) Stop() {
+	atomic.StoreInt32(&d.interrupt, interruptStop)
+}
+
+// Break add breakpoint by `line`,`file:line` or function name
+func (d *Debugger) Break(spec string) (*Breakpoint, error) {
+	b := &Breakpoint{}
+	file, line := "", spec
+	if index := strings.LastIndex(spec, ":"); index != -1 {
+		file, line = spec[:index], spec[index+1:]
+	}
+	if n, err := strconv.Atoi(line); err == nil {
+		b.File, b.Line = file, n
+		if d.lineExists(b) == false {
+			return nil, fmt.Errorf("no code at line %s", spec)
+		}
+	} else {
+		entry, ok := d.functionEntry(spec)
+		if ok == false {
+			return nil, fmt.Errorf("no function `%s`", spec)
+		}
+		b.Function, b.entry = spec, entry
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.nextID++
+	b.ID = d.nextID
+	d.breakpoints = append(d.breakpoints, b)
+	return b, nil
+}
+
+// Breakpoints return breakpoints in order of adding
+func (d *Debugger) Breakpoints() []*Breakpoint {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]*Breakpoint{}, d.breakpoints...)
+}
+
+// Delete delete breakpoint of id
+func (d *Debugger) Delete(id int) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for index, b := range d.breakpoints {
+		if b.ID == id {
+			d.breakpoints = append(d.breakpoints[:index], d.breakpoints[index+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// frames return function calls of call stack,begin with the innermost
+// function call
+func (d *Debugger) frames() []frameState {
+	m := d.m
+	frames := []frameState{{IP: m.IP, stack: m.stack, SP: m.SP}}
+	for i := len(m.stackFrames) - 1; i >= 0; i-- {
+		if frame := m.stackFrames[i]; frame.ret > 0 {
+			frames = append(frames, frameState{IP: frame.ret - 1, stack: frame.stack, SP: frame.SP})
+		}
+	}
+	return frames
+}
+
+// Locals return variables of function call in order of declaration,frame
+// is the index of Stack,variable shadowed is skipped
+func (d *Debugger) Locals(frame int) []Variable {
+	frames := d.frames()
+	if frame < 0 || frame >= len(frames) {
+		return nil
+	}
+	state := frames[frame]
+	var locals []Variable
+	var seen = map[string]bool{}
+	for v := d.m.instructions[state.IP].vars; v != nil; v = v.next {
+		if seen[v.name] || v.slot > state.SP {
+			continue
+		}
+		seen[v.name] = true
+		locals = append([]Variable{{Name: v.name, Value: state.stack[v.slot], Closure: v.closure}}, locals...)
+	}
+	return locals
+}
+
+// Stack return the qp call stack,begin with the innermost function call
+func (d *Debugger) Stack() []Frame {
+	return d.m.trace()
+}
+
+func (d *Debugger) fileMatch(b *Breakpoint, file int64) bool {
+	if b.File == "" {
+		return true
+	}
+	name := d.m.files.symbols[file]
+	return name == b.File || strings.HasSuffix(name, string(filepath.Separator)+b.File)
+}
+
+func (d *Debugger) lineExists(b *Breakpoint) bool {
+	for _, ins := range d.m.instructions {
+		if ins.Line == b.Line && d.fileMatch(b, ins.file) {
+			return true
+		}
+	}
+	return false
+}
+
+// functionEntry return IP of the first instruction after arguments and
+// closure of function are loaded
+func (d *Debugger) functionEntry(name string) (int64, bool) {
+	for IP, ins := range d.m.instructions {
+		if ins.Type != Label {
+			continue
+		}
+		label := d.m.symbolTable.symbols[ins.symbol]
+		if label != name && strings.HasSuffix(label, "."+name) == false {
+			continue
+		}
+		entry := int64(IP) + 1
+		for entry < int64(len(d.m.instructions)) {
+			switch d.m.instructions[entry].Type {
+			case MakeStack, LoadR, InitClosure:
+				entry++
+				continue
+			}
+			break
+		}
+		return entry, true
+	}
+	return 0, false
+}
+
+// pausable check machine can pause at ins,instructions of call and return
+// belong to no line
+func pausable(ins Instruction) bool {
+	switch ins.Type {
+	case Label, MakeStack, PopStack, Ret, Exit:
+		return false
+	}
+	return ins.Line != 0
+}
+
+func (d *Debugger) hook() bool {
+	ins := d.m.instructions[d.m.IP]
+	if pausable(ins) == false {
+		return true
+	}
+	pos := position{file: ins.file, line: ins.Line, depth: len(d.m.stackFrames)}
+	last := d.last
+	d.last = pos
+	switch atomic.SwapInt32(&d.interrupt, 0) {
+	case interruptPause:
+		d.reason = "pause"
+	case interruptStop:
+		d.stopped = true
+		d.m.IP = -1
+		return false
+	default:
+		d.reason = d.pauseReason(pos, last)
+	}
+	if d.reason == "" {
+		return true
+	}
+	d.step = d.pause(d)
+	d.from = pos
+	if d.step == ResumeStop {
+		d.stopped = true
+		d.m.IP = -1
+		return false
+	}
+	return true
+}
+
+// pauseReason return why machine pause at pos,empty if no pause
+func (d *Debugger) pauseReason(pos, last position) string {
+	// returning to the line of call is no entering the line
+	newLine := pos != last && pos.depth >= last.depth
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for _, b := range d.breakpoints {
+		if b.Function != "" {
+			if b.entry == d.m.IP {
+				return "breakpoint"
+			}
+		} else if newLine && b.Line == pos.line && d.fileMatch(b, pos.file) {
+			return "breakpoint"
+		}
+	}
+	from := d.from
+	var pause bool
+	switch d.step {
+	case ResumeStepInto:
+		pause = pos != from
+	case ResumeStepOver:
+		pause = pos.depth < from.depth || pos.depth == from.depth && pos != from
+	case ResumeStepOut:
+		pause = pos.depth < from.depth
+	}
+	if pause == false {
+		return ""
+	}
+	if from.line == -1 {
+		return "entry"
+	}
+	return "step"
+}
+
+// console is debugger front end of commands
+type console struct {
+	d       *Debugger
+	in      *bufio.Scanner
+	out     io.Writer
+	sources map[string][]string
+}
+
+// prompt read and run commands until the machine is resumed
+func (c *console) prompt(d *Debugger) Resume {
+	c.d = d
+	c.printPosition()
+	for {
+		fmt.Fprint(c.out, debuggerPrompt)
+		if c.in.Scan() == false {
+			fmt.Fprintln(c.out)
+			return ResumeStop
+		}
+		fields := strings.Fields(c.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		arg := strings.Join(fields[1:], " ")
+		switch fields[0] {
+		case "break", "b":
+			if b, err := d.Break(arg); err != nil {
+				fmt.Fprintln(c.out, err.Error())
+			} else {
+				fmt.Fprintf(c.out, "breakpoint %d at %s\n", b.ID, b)
+			}
+		case "delete", "d":
+			id, err := strconv.Atoi(arg)
+			if err != nil || d.Delete(id) == false {
+				fmt.Fprintf(c.out, "no breakpoint `%s`\n", arg)
+			}
+		case "breakpoints":
+			for _, b := range d.Breakpoints() {
+				fmt.Fprintf(c.out, "%d\t%s\n", b.ID, b)
+			}
+		case "continue", "c":
+			return ResumeContinue
+		case "step", "s":
+			return ResumeStepInto
+		case "next", "n":
+			return ResumeStepOver
+		case "out", "o":
+			return ResumeStepOut
+		case "locals":
+			for _, local := range d.Locals(0) {
+				c.printVariable(local)
+			}
+		case "print", "p":
+			c.print(arg)
+		case "stack", "bt":
+			for index, frame := range d.Stack() {
+				fmt.Fprintf(c.out, "#%d %s\n", index, frame)
+			}
+		case "list", "l":
+			c.list(5)
+		case "help", "h":
+			fmt.Fprintln(c.out, debuggerHelp)
+		case "quit", "q":
+			return ResumeStop
+		default:
+			fmt.Fprintf(c.out, "unknown command `%s`,help show commands\n", fields[0])
+		}
+	}
+}
+
+func (c *console) print(name string) {
+	for _, local := range c.d.Locals(0) {
+		if local.Name == name {
+			c.printVariable(local)
+			return
+		}
+	}
+	fmt.Fprintf(c.out, "no variable `%s`\n", name)
+}
+
+func (c *console) printVariable(v Variable) {
+	if v.Closure {
+		fmt.Fprintf(c.out, "%s = %s (closure)\n", v.Name, v.Value.Describe())
+	} else {
+		fmt.Fprintf(c.out, "%s = %s\n", v.Name, v.Value.Describe())
+	}
+}
+
+func (c *console) printPosition() {
+	fmt.Fprintln(c.out, c.d.Stack()[0].String())
+	c.list(0)
+}
+
+// list print source lines around current line
+func (c *console) list(around int) {
+	frame := c.d.Stack()[0]
+	lines := c.source(frame.File)
+	for line := frame.Line - around; line <= frame.Line+around; line++ {
+		if line < 1 || line > len(lines) {
+			continue
+		}
+		mark := " "
+		if line == frame.Line {
+			mark = ">"
+		}
+		fmt.Fprintf(c.out, "%s%4d\t%s\n", mark, line, lines[line-1])
+	}
+}
+
+func (c *console) source(file string) []string {
+	if lines, ok := c.sources[file]; ok {
+		return lines
+	}
+	var lines []string
+	if data, err := ioutil.ReadFile(file); err == nil {
+		lines = strings.Split(string(data), "\n")
+	}
+	c.sources[file] = lines
+	return lines
+}
+
+// Describe format value for debugger,fields of object are shown
+func (obj Object) Describe() string {
+	return describe(obj, 0)
+}
+
+func describe(obj Object, depth int) string {
+	switch obj.Type {
+	case String:
+		return strconv.Quote(obj.Obj.(string))
+	case Obj, Array, Map:
+		if depth > 2 {
+			if obj.Type == Array {
+				return "[...]"
+			}
+			return "{...}"
+		}
+		var items []string
+		for _, field := range obj.Fields() {
+			if obj.Type == Array {
+				items = append(items, describe(field.Value, depth+1))
+			} else {
+				items = append(items, field.Name+": "+describe(field.Value, depth+1))
+			}
+		}
+		if obj.Type == Array {
+			return "[" + strings.Join(items, ", ") + "]"
+		}
+		if obj.Type == Map {
+			return "map{" + strings.Join(items, ", ") + "}"
+		}
+		return "{" + strings.Join(items, ", ") + "}"
+	}
+	return obj.String()
+}
+
+// Fields return fields of object sorted by name,elements of array and map,
+// nil for other values
+func (obj Object) Fields() []Variable {
+	var fields []Variable
+	switch obj.Type {
+	case Obj:
+		object, _ := obj.Obj.(objectMap)
+		var keys []string
+		for key := range object {
+			if strings.HasPrefix(key, "__") == false {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fields = append(fields, Variable{Name: key, Value: *object[key]})
+		}
+	case Array:
+		for index, it := range obj.Obj.(ObjectArray) {
+			fields = append(fields, Variable{Name: strconv.Itoa(index), Value: it})
+		}
+	case Map:
+		object := obj.Obj.(*mapObject)
+		for _, key := range object.keys {
+			fields = append(fields, Variable{Name: describe(key.object(), 0), Value: *object.values[key]})
+		}
+	}
+	return fields
+}
+
+// TypeName return name of value type for debugger
+func (obj Object) TypeName() string {
+	switch obj.Type {
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Bool:
+		return "bool"
+	case String:
+		return "string"
+	case Nil:
+		return "nil"
+	case Obj:
+		return "object"
+	case Array:
+		return "array"
+	case Map:
+		return "map"
+	case Time:
+		return "time"
+	case Duration:
+		return "duration"
+	case Error:
+		return "error"
+	case BFunc, OFunc, Lambda, GFunc, Method:
+		return "func"
+	}
+	return "unknown"
+}
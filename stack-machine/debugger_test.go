@@ -0,0 +1,71 @@
+package stackmachine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp/parser"
+)
+
+func TestDebugger(t *testing.T) {
+	p := parser.New(`
+func add(x, y){
+	var s = x + y
+	return s
+}
+var a = 1
+var f = func(){
+	return a
+}
+for var i = 0; i < 2; i++ {
+	a = add(a, i)
+}
+f()
+println(a)
+`).SetFile("debug.qp")
+	statements := p.Parse()
+	for _, it := range p.GetVMContext().Objects() {
+		statements = append(statements, it)
+	}
+	var stdout, out bytes.Buffer
+	m := NewMachine(NewCodeGenerator().Gen(statements), Options{Stdout: &stdout})
+	commands := []string{
+		"break add",
+		"break 8",
+		"break 99",
+		"continue",
+		"locals",
+		"next",
+		"print s",
+		"stack",
+		"out",
+		"print i",
+		"delete 1",
+		"continue",
+		"locals",
+		"continue",
+	}
+	d := NewDebugger(m, strings.NewReader(strings.Join(commands, "\n")), &out)
+	exited, err := d.Run()
+	if err != nil || exited == false {
+		t.Fatal(exited, err)
+	}
+	for _, expect := range []string{
+		"main (debug.qp:6)",
+		"breakpoint 1 at add",
+		"no code at line 99",
+		"add (debug.qp:3)\n(debug) x = 1\ny = 0\n",
+		"add (debug.qp:4)\n(debug) s = 1\n",
+		"#0 add (debug.qp:4)\n#1 main (debug.qp:11)\n",
+		"main (debug.qp:11)\n(debug) i = 0\n",
+		"(debug.qp:8)\n(debug) a = 1 (closure)\n",
+	} {
+		if strings.Contains(out.String(), expect) == false {
+			t.Fatalf("expect %q in output\n%s", expect, out.String())
+		}
+	}
+	if stdout.String() != "2\n" {
+		t.Fatalf("unexpected stdout %q", stdout.String())
+	}
+}
@@ -0,0 +1,102 @@
+package stackmachine
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const mainFunctionName = "main"
+
+// Disassemble write the listing of code to writer,instructions are grouped
+// by function,jump targets,called functions and closure variables are resolved
+func (genCode *CodeGenerator) Disassemble(writer io.Writer) error {
+	functions := map[int64]string{}
+	for index, ins := range genCode.ins {
+		if ins.Type == Label {
+			functions[int64(index)] = genCode.symbolTable.symbols[ins.symbol]
+		}
+	}
+	closures := map[int64][]string{}
+	for index, ins := range genCode.ins {
+		if ins.Type == Push && ins.ValTyp == Lambda {
+			closures[ins.Val] = genCode.closureSymbols(index)
+		}
+	}
+
+	w := bufio.NewWriter(writer)
+	fmt.Fprintln(w, mainFunctionName+":")
+	for index, ins := range genCode.ins {
+		if ins.Type == Label {
+			fmt.Fprintln(w)
+			if symbols, ok := closures[int64(index)]; ok {
+				fmt.Fprintf(w, "%s: closure(%s)\n", functions[int64(index)], strings.Join(symbols, ", "))
+			} else {
+				fmt.Fprintf(w, "%s:\n", functions[int64(index)])
+			}
+			continue
+		}
+		var line = fmt.Sprintf("%6d  %6s  %s", index, genCode.position(ins), ins.String(genCode.symbolTable, genCode.builtSymbolTable))
+		if comment := genCode.comment(index, functions); comment != "" {
+			line = fmt.Sprintf("%-48s ; %s", line, comment)
+		}
+		fmt.Fprintln(w, line)
+	}
+	return w.Flush()
+}
+
+// position return source position of instruction,only line is shown for the
+// main file
+func (genCode *CodeGenerator) position(ins Instruction) string {
+	if ins.Line == 0 {
+		return ""
+	}
+	var line = strconv.Itoa(ins.Line)
+	if ins.file != 0 && ins.file < int64(len(genCode.files.symbols)) {
+		return genCode.files.symbols[ins.file] + ":" + line
+	}
+	return line
+}
+
+// comment resolve the target of instruction
+func (genCode *CodeGenerator) comment(index int, functions map[int64]string) string {
+	ins := genCode.ins[index]
+	switch {
+	case ins.Type == Jump:
+		target := ins.Val
+		if ins.JumpTyp == RJump {
+			target += int64(index)
+		}
+		if name, ok := functions[target]; ok {
+			return "-> " + strconv.FormatInt(target, 10) + " " + name
+		}
+		return "-> " + strconv.FormatInt(target, 10)
+	case ins.Type == Try:
+		return "catch " + strconv.FormatInt(int64(index)+ins.Val, 10)
+	case ins.Type == Push && ins.ValTyp == IP:
+		return "return to " + strconv.FormatInt(int64(index)+ins.Val, 10)
+	case ins.Type == Push && (ins.ValTyp == OFunc || ins.ValTyp == Lambda):
+		return "-> " + strconv.FormatInt(ins.Val, 10) + " " + functions[ins.Val]
+	}
+	return ""
+}
+
+// closureSymbols return the variables captured by lambda pushed at index,
+// the lambda is followed by make_array,(load,append)...,StoreO __Closure__
+func (genCode *CodeGenerator) closureSymbols(index int) []string {
+	symbols := []string{}
+	for _, ins := range genCode.ins[index+1:] {
+		switch ins.Type {
+		case Load:
+			if ins.Val >= 0 {
+				symbols = append(symbols, genCode.symbolTable.symbols[ins.symbol])
+			}
+		case MakeArray, Append:
+		default:
+			return symbols
+		}
+	}
+	return symbols
+}
@@ -0,0 +1,84 @@
+package stackmachine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDisassemble(t *testing.T) {
+	gen := genScript(`
+func add(a){
+	return a + 1
+}
+var f = func(){
+	var n = 2
+	return func(){
+		return n
+	}
+}
+for i := 0; i < 2; i++ {
+	var g = f()
+	println(g(), add(i))
+}
+`)
+	var buffer bytes.Buffer
+	if err := gen.Disassemble(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	listing := buffer.String()
+	for _, expect := range []string{
+		"main:\n",
+		"\nadd:\n",
+		": closure(n)\n",
+		"load top 2",
+		"call println",
+		"return to ",
+	} {
+		if strings.Contains(listing, expect) == false {
+			t.Fatalf("expect `%s` in listing\n%s", expect, listing)
+		}
+	}
+	for _, line := range strings.Split(listing, "\n") {
+		if strings.Contains(line, "jump") && strings.Contains(line, "; -> ") == false {
+			t.Fatalf("jump target is not resolved `%s`", line)
+		}
+	}
+}
+
+func TestDisassembleAssign(t *testing.T) {
+	gen := genScript(`
+type Account {
+	balance int = 0
+}
+var a = Account{}
+var total = 0
+for i := 0; i < 3; i++ {
+	total = total + i
+	total++
+}
+for k, v := range [1] {
+	println(k, v, a)
+}
+`)
+	var buffer bytes.Buffer
+	if err := gen.Disassemble(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	listing := buffer.String()
+	for _, expect := range []string{
+		"load total 1",
+		"store total 1",
+		"store i 2",
+		"load range 2",
+	} {
+		if strings.Contains(listing, expect) == false {
+			t.Fatalf("expect `%s` in listing\n%s", expect, listing)
+		}
+	}
+	for _, line := range strings.Split(listing, "\n") {
+		if strings.Contains(line, " Account ") {
+			t.Fatalf("unexpected symbol in `%s`", line)
+		}
+	}
+}
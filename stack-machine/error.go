@@ -0,0 +1,114 @@
+package stackmachine
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Frame is a function call of qp stack trace
+type Frame struct {
+	Function string
+	File     string
+	Line     int // 0 if unknown
+}
+
+func (f Frame) String() string {
+	var pos = f.File
+	if f.Line != 0 {
+		pos += ":" + strconv.Itoa(f.Line)
+	}
+	if pos == "" {
+		return f.Function
+	}
+	return f.Function + " (" + pos + ")"
+}
+
+// RuntimeError is the error raised by running script,Trace begin with the
+// innermost function call
+type RuntimeError struct {
+	Message string
+	Trace   []Frame
+	err     runtime.Error // panic of Go runtime the error is made of
+}
+
+func (e *RuntimeError) Error() string {
+	var builder strings.Builder
+	builder.WriteString(e.Message)
+	for i := 0; i < len(e.Trace); {
+		builder.WriteString("\n\t")
+		builder.WriteString(e.Trace[i].String())
+		// elide frames same as the previous,deep recursion repeat them
+		repeated := 0
+		for i++; i < len(e.Trace) && e.Trace[i] == e.Trace[i-1]; i++ {
+			repeated++
+		}
+		if repeated > 0 {
+			builder.WriteString("\n\t... repeated " + strconv.Itoa(repeated) + " times")
+		}
+	}
+	return builder.String()
+}
+
+// Unwrap return the panic of Go runtime the error is made of,nil if the
+// error is raised by script
+func (e *RuntimeError) Unwrap() error {
+	if e.err == nil {
+		return nil
+	}
+	return e.err
+}
+
+func panicln(v ...interface{}) {
+	panic(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+func panicf(format string, v ...interface{}) {
+	panic(fmt.Sprintf(format, v...))
+}
+
+// errorMessage return message of the value recovered from Run
+func errorMessage(r interface{}) string {
+	if err, ok := r.(error); ok {
+		return err.Error()
+	}
+	return fmt.Sprint(r)
+}
+
+// runtimeError make RuntimeError of the value recovered from Run
+func (m *Machine) runtimeError(r interface{}) *RuntimeError {
+	err, _ := r.(runtime.Error)
+	return &RuntimeError{Message: errorMessage(r), Trace: m.trace(), err: err}
+}
+
+// trace return the qp call stack,begin with the innermost function call
+func (m *Machine) trace() []Frame {
+	trace := []Frame{m.frame(m.IP)}
+	for i := len(m.stackFrames) - 1; i >= 0; i-- {
+		if ret := m.stackFrames[i].ret; ret > 0 {
+			trace = append(trace, m.frame(ret-1))
+		}
+	}
+	return trace
+}
+
+// frame return the function and source position of instruction at IP
+func (m *Machine) frame(IP int64) Frame {
+	frame := Frame{Function: mainFunctionName}
+	if IP < 0 || IP >= int64(len(m.instructions)) {
+		return frame
+	}
+	for i := IP; i >= 0; i-- {
+		if ins := m.instructions[i]; ins.Type == Label {
+			frame.Function = m.symbolTable.symbols[ins.symbol]
+			break
+		}
+	}
+	ins := m.instructions[IP]
+	frame.Line = ins.Line
+	if ins.file < int64(len(m.files.symbols)) {
+		frame.File = m.files.symbols[ins.file]
+	}
+	return frame
+}
@@ -0,0 +1,142 @@
+package stackmachine
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/parser"
+)
+
+func TestRuntimeErrorTrace(t *testing.T) {
+	p := parser.New(`
+func get(m, k){
+	return m[k]
+}
+func find(m){
+	return get(m, 1)
+}
+println(find([1, 2]))
+println(find(1))
+`).SetFile("trace.qp")
+	statements := p.Parse()
+	for _, it := range p.GetVMContext().Objects() {
+		statements = append(statements, it)
+	}
+	gen := NewCodeGenerator().Gen(statements)
+	err := NewMachine(gen, Options{}).Run()
+	runtimeError, ok := err.(*RuntimeError)
+	if ok == false {
+		t.Fatalf("expect *RuntimeError,got %v", err)
+	}
+	expect := []Frame{
+		{Function: "get", File: "trace.qp", Line: 3},
+		{Function: "find", File: "trace.qp", Line: 6},
+		{Function: "main", File: "trace.qp", Line: 9},
+	}
+	if len(runtimeError.Trace) != len(expect) {
+		t.Fatalf("unexpected trace\n%s", runtimeError.Error())
+	}
+	for index, frame := range expect {
+		if runtimeError.Trace[index] != frame {
+			t.Fatalf("expect frame %s,got %s", frame, runtimeError.Trace[index])
+		}
+	}
+}
+
+func TestRuntimeErrorIndex(t *testing.T) {
+	p := parser.New("var a = [1, 2]\na[1] = 3\nvar i = 0 - 1\nprintln(a[i])\n").SetFile("index.qp")
+	gen := NewCodeGenerator().Gen(p.Parse())
+	err := NewMachine(gen, Options{}).Run()
+	runtimeError, ok := err.(*RuntimeError)
+	if ok == false {
+		t.Fatalf("expect *RuntimeError,got %v", err)
+	}
+	if runtimeError.Message != "invalid array index -1 (index must be non-negative)" {
+		t.Fatalf("unexpected message %s", runtimeError.Message)
+	}
+	if frame := runtimeError.Trace[0]; frame.Line != 4 {
+		t.Fatalf("unexpected frame %s", frame)
+	}
+}
+
+func TestGenErrorUndefined(t *testing.T) {
+	for src, expect := range map[string]string{
+		"var a = 1\nprintln(nofunc(a))\n":                     "undefined.qp:2:15: undefined function `nofunc`",
+		"var g = 1\nfunc f() {\n\tvar x = 2\n\treturn g\n}\n": "undefined.qp:4:9: undefined `g`",
+	} {
+		if err := genError(src); err == nil || err.Error() != expect {
+			t.Fatalf("expect error %s,got %v", expect, err)
+		}
+	}
+}
+
+// genError return the error generating src panics with
+func genError(src string) (err *ast.Error) {
+	p := parser.New(src).SetFile("undefined.qp")
+	statements := p.Parse()
+	for _, it := range p.GetVMContext().Objects() {
+		statements = append(statements, it)
+	}
+	defer func() {
+		err, _ = recover().(*ast.Error)
+	}()
+	NewCodeGenerator().Gen(statements)
+	return nil
+}
+
+func TestRuntimeErrorStackOverflow(t *testing.T) {
+	var buffer bytes.Buffer
+	err := run(`
+func f(n){
+	return f(n + 1)
+}
+try {
+	f(0)
+} catch e {
+	println(e.message)
+}
+f(0)
+`, &buffer)
+	if buffer.String() != "stack overflow\n" {
+		t.Fatalf("unexpected output %q", buffer.String())
+	}
+	runtimeError, ok := err.(*RuntimeError)
+	if ok == false || runtimeError.Message != "stack overflow" {
+		t.Fatalf("expect stack overflow,got %v", err)
+	}
+	expect := "stack overflow\n\tf (overflow.qp:2)\n\tf (overflow.qp:3)\n\t... repeated 9998 times\n\tmain (overflow.qp:10)"
+	if runtimeError.Error() != expect {
+		t.Fatalf("unexpected error\n%s", runtimeError.Error())
+	}
+}
+
+func TestRuntimeErrorInvalidOperation(t *testing.T) {
+	for src, expect := range map[string]string{
+		"func f(a, b){ return a + b }\nf(1, \"a\")\n":     "invalid operation: int + string",
+		"func f(a, b){ return a - b }\nf(\"a\", \"b\")\n": "invalid operation: string - string",
+		"func f(a, b){ return a < b }\nf(true, false)\n":  "invalid operation: bool < bool",
+		"func f(a, b){ return a * b }\nf(nil, 1)\n":       "invalid operation: nil * int",
+	} {
+		err := run(src, nil)
+		if runtimeError, ok := err.(*RuntimeError); ok == false || runtimeError.Message != expect {
+			t.Fatalf("expect %s,got %v", expect, err)
+		}
+	}
+	var buffer bytes.Buffer
+	err := run("func f(a, b){ return a == b }\nprintln(f(true, true), f(true, false), f(nil, 1), f(1, nil), f(nil, nil))\n", &buffer)
+	if err != nil || buffer.String() != "true false false false true\n" {
+		t.Fatalf("unexpected output %q,error %v", buffer.String(), err)
+	}
+}
+
+// run the script src,println write to stdout
+func run(src string, stdout io.Writer) error {
+	p := parser.New(src).SetFile("overflow.qp")
+	statements := p.Parse()
+	for _, it := range p.GetVMContext().Objects() {
+		statements = append(statements, it)
+	}
+	return NewMachine(NewCodeGenerator().Gen(statements), Options{Stdout: stdout}).Run()
+}
@@ -17,6 +17,7 @@ import (
 type toLink struct {
 	label string
 	IP    int64
+	pos   lexer.Position // position of call,for undefined function error
 }
 
 type FuncInstruction struct {
@@ -31,8 +32,19 @@ type stackSymbol struct {
 }
 type stackFrame struct {
 	function bool
+	isolate  bool // function stack frame,symbols of outer frames are invisible
 	stack    []stackSymbol
 	sp       int
+	vars     *localVar // variables visible in frame,for debugger
+}
+
+// localVar is variable in stack of function,the list of it is shared by
+// instructions in scope of variables
+type localVar struct {
+	name    string
+	slot    int64
+	closure bool
+	next    *localVar
 }
 type StackManager struct {
 	currStack  stackFrame
@@ -47,7 +59,7 @@ func (s *StackManager) Store(symbol string) int64 {
 	if len(symbol) != 0 {
 		for _, label := range s.currStack.stack {
 			if label.symbol == symbol {
-				log.Panicln("redefine symbol", symbol, s.stackFrame, s.currStack)
+				ast.Panicln("redefine symbol", symbol)
 			}
 		}
 	}
@@ -55,30 +67,35 @@ func (s *StackManager) Store(symbol string) int64 {
 		symbol: symbol,
 		sp:     s.currStack.sp,
 	})
+	if len(symbol) != 0 {
+		s.currStack.vars = &localVar{name: symbol, slot: int64(s.currStack.sp), next: s.currStack.vars}
+	}
 	s.currStack.sp++
 	return s.SP()
 }
 
 func (s *StackManager) load(label string) (int64, bool) {
-	stacks := append([]stackFrame{}, s.currStack)
-	stacks = append(stacks, s.stackFrame...)
-	for j := len(stacks) - 1; j >= 0; j-- {
-		stack := stacks[j]
+	stack := s.currStack
+	for j := len(s.stackFrame); ; j-- {
 		for i := len(stack.stack) - 1; i >= 0; i-- {
 			if stack.stack[i].symbol == label {
 				return int64(stack.stack[i].sp), true
 			}
 		}
+		if stack.isolate || j == 0 {
+			return -1, false
+		}
+		stack = s.stackFrame[j-1]
 	}
-	return -1, false
 }
 
 func (s *StackManager) pushStackFrame(funcStack bool) {
 	s.stackFrame = append(s.stackFrame, s.currStack)
 	if !funcStack {
 		s.currStack.stack = nil
+		s.currStack.isolate = false
 	} else {
-		s.currStack = stackFrame{function: funcStack}
+		s.currStack = stackFrame{function: funcStack, isolate: true}
 	}
 }
 func (s *StackManager) popStackFrame() {
@@ -97,6 +114,12 @@ type CodeGenerator struct {
 	toLinks          []toLink
 	sm               *StackManager
 	funcInstructions map[string]FuncInstruction
+	loops            []*loopContext
+	tries            int            // count of try statements enclosing generating statement
+	files            *SymbolTable   // source files of instructions
+	pos              lexer.Position // source position of generating statement
+	module           *ast.Module    // module being generated,nil for main script
+	imported         map[*ast.Module]bool
 }
 
 func NewCodeGenerator() *CodeGenerator {
@@ -106,6 +129,8 @@ func NewCodeGenerator() *CodeGenerator {
 		ins:              []Instruction{},
 		sm:               NewStackManager(),
 		funcInstructions: map[string]FuncInstruction{},
+		files:            NewSymbolTable(),
+		imported:         map[*ast.Module]bool{},
 	}
 	for _, function := range BuiltInFunctions {
 		gc.builtSymbolTable.addSymbol(function.Name)
@@ -128,9 +153,29 @@ func (genCode *CodeGenerator) String() string {
 }
 
 func (genCode *CodeGenerator) pushIns(instruction Instruction) {
+	instruction.file = genCode.files.addSymbol(genCode.pos.File)
+	instruction.Line = genCode.pos.Line
+	instruction.vars = genCode.sm.currStack.vars
 	genCode.ins = append(genCode.ins, instruction)
 }
 
+// errorf abort generating with error at position of generating statement
+func (genCode *CodeGenerator) errorf(format string, args ...interface{}) {
+	ast.PanicAt(genCode.pos, format, args...)
+}
+
+// setPosition make instructions of statement carry its source position,
+// the returned function restore the position of outer statement
+func (genCode *CodeGenerator) setPosition(statement runtime.Invokable) func() {
+	pos := genCode.pos
+	if positioned, ok := statement.(ast.Positioned); ok && positioned.GetPosition().Line != 0 {
+		genCode.pos = positioned.GetPosition()
+	}
+	return func() {
+		genCode.pos = pos
+	}
+}
+
 func (genCode *CodeGenerator) Gen(statements []ast.Expression) *CodeGenerator {
 	for _, statement := range statements {
 		genCode.genStatement(statement)
@@ -146,6 +191,7 @@ func (genCode *CodeGenerator) Gen(statements []ast.Expression) *CodeGenerator {
 }
 
 func (genCode *CodeGenerator) genStatement(statement runtime.Invokable) int {
+	defer genCode.setPosition(statement)()
 	switch statement := statement.(type) {
 	case ast.Int:
 		genCode.pushIns(Instruction{
@@ -153,6 +199,12 @@ func (genCode *CodeGenerator) genStatement(statement runtime.Invokable) int {
 			ValTyp: Int,
 			Val:    int64(statement),
 		})
+	case ast.Float:
+		genCode.pushIns(Instruction{
+			Type:   Push,
+			ValTyp: Float,
+			Val:    NewFloat(float64(statement)).Int,
+		})
 	case ast.Bool:
 		var b = FALSE
 		if statement {
@@ -175,6 +227,10 @@ func (genCode *CodeGenerator) genStatement(statement runtime.Invokable) int {
 			genCode.pushIns(Instruction{Type: LoadR, Val: 1})
 		}
 		genCode.genOpCode(statement.OP)
+		if statement.Int {
+			last := &genCode.ins[len(genCode.ins)-1]
+			last.Type = last.Type.specialized()
+		}
 	case ast.VarAssignStatement:
 		genCode.genStatement(statement.Exp)
 		if statement.Exp.GetType() == lexer.CallType {
@@ -215,6 +271,10 @@ func (genCode *CodeGenerator) genStatement(statement runtime.Invokable) int {
 		genCode.genReturnStatement(statement)
 	case ast.AssignStatement:
 		genCode.genAssignStatement(statement)
+	case ast.MultiAssignStatement:
+		return genCode.genMultiAssignStatement(statement)
+	case topValue:
+		genCode.pushIns(Instruction{Type: Load, Val: -statement.offset})
 	case ast.String:
 		genCode.pushIns(Instruction{
 			Type:   Push,
@@ -222,19 +282,34 @@ func (genCode *CodeGenerator) genStatement(statement runtime.Invokable) int {
 			Str:    string(statement),
 		})
 	case ast.PeriodStatement:
-		genCode.genStatement(statement.Exp)
+		genCode.genValue(statement.Exp)
+		if statement.Bind {
+			genCode.pushIns(Instruction{Type: Load, Val: -1})
+		}
 		genCode.pushIns(Instruction{
 			Type: LoadO,
 			Str:  statement.Val,
 		})
+		if statement.Bind {
+			genCode.pushIns(Instruction{Type: Bind})
+		}
 	case ast.ForExpression:
 		genCode.genForStatement(statement)
+	case *ast.BreakObject:
+		genCode.genLoopJump(true, statement.Label)
+	case *ast.ContinueObject:
+		genCode.genLoopJump(false, statement.Label)
 	case ast.IncFieldStatement:
 		genCode.genIncFieldStatement(statement)
 	case ast.ObjectInitStatement:
 		genCode.genObjectInitStatement(statement)
 	case *ast.TypeObject:
 		genCode.genTypeObject(statement)
+	case *ast.InterfaceObject:
+		// interfaces are checked by Is only
+	case ast.IsExpression:
+		genCode.genValue(statement.Exp)
+		genCode.pushIns(Instruction{Type: Is, Str: genCode.typePatterns(statement.Types)})
 	case objectInitStatement:
 		genCode.genInitStatement(statement)
 	case createObjectStatement:
@@ -243,8 +318,33 @@ func (genCode *CodeGenerator) genStatement(statement runtime.Invokable) int {
 		genCode.genNilObject(statement)
 	case ast.ParenthesisExpression:
 		genCode.genStatement(statement.Exp)
+	case *ast.MakeArrayStatement:
+		genCode.pushIns(Instruction{Type: MakeArray})
+		for _, element := range statement.Inits {
+			genCode.genValue(element)
+			genCode.pushIns(Instruction{Type: Append})
+		}
+	case *ast.MakeMapStatement:
+		genCode.genMakeMapStatement(statement)
+	case ast.TryStatement:
+		genCode.genTryStatement(statement)
+	case ast.ImportStatement:
+		genCode.genImportStatement(statement)
+	case ast.IndexExpression:
+		genCode.genValue(statement.Exp)
+		genCode.genValue(statement.Index)
+		genCode.pushIns(Instruction{Type: Index})
+	case ast.SliceExpression:
+		genCode.genValue(statement.Exp)
+		for _, bound := range []runtime.Invokable{statement.Low, statement.High} {
+			if bound == nil {
+				bound = ast.NilObject{}
+			}
+			genCode.genValue(bound)
+		}
+		genCode.pushIns(Instruction{Type: Slice})
 	default:
-		log.Panicf("unknown statement %s", reflect.TypeOf(statement).String())
+		genCode.errorf("unknown statement %s", reflect.TypeOf(statement).String())
 	}
 	return 0
 }
@@ -282,6 +382,11 @@ func (genCode *CodeGenerator) genOpCode(op lexer.Type) {
 			Type:   Cmp,
 			CmpTyp: Greater,
 		})
+	case lexer.GreaterEqualType:
+		genCode.pushIns(Instruction{
+			Type:   Cmp,
+			CmpTyp: GreaterEQ,
+		})
 	case lexer.SubType:
 		genCode.pushIns(Instruction{
 			Type: Sub,
@@ -299,7 +404,7 @@ func (genCode *CodeGenerator) genOpCode(op lexer.Type) {
 		genCode.pushIns(Instruction{Type: Cmp,
 			CmpTyp: Equal})
 	default:
-		log.Panicf("unknown instruction %s", op.String())
+		genCode.errorf("unknown instruction %s", op.String())
 	}
 }
 
@@ -308,91 +413,206 @@ func (genCode *CodeGenerator) genStoreIns(label string) {
 	genCode.symbolTable.addSymbol(label)
 }
 
+// genIfStatement gen branches of if,else if in order,the branch taken
+// jumps to the end after its statements
 func (genCode *CodeGenerator) genIfStatement(statement ast.IfExpression) {
-	genCode.genStatement(statement.Check)
-	genCode.pushIns(Instruction{
-		Type:    Jump,
-		JumpTyp: RJump,
-		Val:     3,
-	})
-	genCode.pushIns(Instruction{
-		Type:   Push,
-		ValTyp: Bool,
-		Val:    TRUE,
-	})
-	genCode.pushIns(Instruction{
-		Type:    Jump,
-		JumpTyp: RJump,
-	})
-	index := len(genCode.ins)
+	var ends []int
+	branches := append([]ast.IfExpression{statement}, statement.ElseIf...)
+	for index, branch := range branches {
+		genCode.genStatement(branch.Check)
+		genCode.pushIns(Instruction{
+			Type:    Jump,
+			JumpTyp: RJump,
+			Val:     3,
+		})
+		next := genCode.genJump()
+		genCode.genBlock(branch.Statements)
+		if index != len(branches)-1 || len(statement.Else) != 0 {
+			ends = append(ends, genCode.genJump())
+		}
+		//fix jump val
+		genCode.ins[next].Val = int64(len(genCode.ins) - next)
+	}
+	if len(statement.Else) != 0 {
+		genCode.genBlock(statement.Else)
+	}
+	for _, end := range ends {
+		genCode.ins[end].Val = int64(len(genCode.ins) - end)
+	}
+}
 
-	//if statement
+// genBlock gen statements of if,else block in new stack frame
+func (genCode *CodeGenerator) genBlock(statements ast.Expressions) {
 	genCode.sm.pushStackFrame(false)
-	stackSize := genCode.genStatement(statement.Statements)
+	stackSize := genCode.genStatement(statements)
 	genCode.sm.popStackFrame()
 
-	//clear if expression stack
+	//clear block stack
 	genCode.pushIns(Instruction{
 		Type: MoveStack,
 		Val:  -int64(stackSize),
 	})
-
-	//fix jump val
-	jumpTo := len(genCode.ins) - index + 1
-	genCode.ins[index-1].Val = int64(jumpTo)
 }
 
 func (genCode *CodeGenerator) genForStatement(statement ast.ForExpression) {
-
+	if statement.Range != nil {
+		genCode.genForRangeStatement(statement)
+		return
+	}
 	genCode.sm.pushStackFrame(false)
 	preStackSize := genCode.genStatement(statement.Pre)
 
 	begin := len(genCode.ins)
-	genCode.genStatement(statement.Check)
+	genCode.genValue(statement.Check)
+	genCode.pushIns(Instruction{
+		Type:    Jump,
+		JumpTyp: RJump,
+		Val:     3,
+	})
+	exit := genCode.genJump()
 
-	if preStackSize > 0 {
+	// statement
+	loop := genCode.pushLoop(statement.Label)
+	genCode.sm.pushStackFrame(false)
+	stackSize := genCode.genStatement(statement.Statements)
+	genCode.sm.popStackFrame()
+
+	//reset for expression Stack
+	if stackSize > 0 {
 		genCode.pushIns(Instruction{
-			Type:    Jump,
-			JumpTyp: RJump,
-			Val:     4,
+			Type: MoveStack,
+			Val:  -int64(stackSize),
 		})
+	}
+	post := len(genCode.ins)
+	genCode.genStatement(statement.Post)
+	genCode.ins[genCode.genJump()].Val = int64(begin - len(genCode.ins) + 1)
+
+	end := len(genCode.ins)
+	genCode.ins[exit].Val = int64(end - exit)
+	genCode.popLoop(loop, post, end)
+
+	//reset pre statement stack
+	if preStackSize > 0 {
 		genCode.pushIns(Instruction{
 			Type: MoveStack,
 			Val:  -int64(preStackSize),
 		})
-	} else {
-		genCode.pushIns(Instruction{
-			Type:    Jump,
-			JumpTyp: RJump,
-			Val:     3,
-		})
 	}
+	genCode.sm.popStackFrame()
+}
+
+// genForRangeStatement gen for k, v := range object {},the iterator
+// made by Range instruction is kept in stack until loop done
+func (genCode *CodeGenerator) genForRangeStatement(statement ast.ForExpression) {
+	genCode.sm.pushStackFrame(false)
+	genCode.genValue(statement.Range)
+	genCode.pushIns(Instruction{Type: Range})
+	iterator := genCode.sm.SP()
+	genCode.sm.Store("")
+
+	begin := len(genCode.ins)
 	genCode.pushIns(Instruction{
-		Type:   Push,
-		ValTyp: Bool,
-		Val:    TRUE,
+		Type:   Load,
+		Val:    iterator,
+		symbol: genCode.symbolTable.addSymbol("range"),
 	})
+	genCode.pushIns(Instruction{Type: Next})
 	genCode.pushIns(Instruction{
 		Type:    Jump,
 		JumpTyp: RJump,
+		Val:     3,
 	})
-	jumpStatement := len(genCode.ins)
-
-	// statement
+	exit := genCode.genJump()
 
+	loop := genCode.pushLoop(statement.Label)
+	genCode.sm.pushStackFrame(false)
+	for _, label := range []string{statement.Key, statement.Value} {
+		if label == "_" {
+			label = ""
+		}
+		genCode.genStoreIns(label)
+	}
 	stackSize := genCode.genStatement(statement.Statements)
+	genCode.sm.popStackFrame()
+	genCode.pushIns(Instruction{
+		Type: MoveStack,
+		Val:  -int64(stackSize + 2), // key,value
+	})
+	next := len(genCode.ins)
+	genCode.ins[genCode.genJump()].Val = int64(begin - len(genCode.ins) + 1)
 
-	//reset for expression Stack
+	end := len(genCode.ins)
+	genCode.ins[exit].Val = int64(end - exit)
+	genCode.popLoop(loop, next, end)
+
+	genCode.pushIns(Instruction{
+		Type: MoveStack,
+		Val:  -1, // iterator
+	})
+	genCode.sm.popStackFrame()
+}
+
+// genTryStatement gen try {} catch e {},when error raised the machine reset
+// stack to the size when try begin and push the error for the catch block
+func (genCode *CodeGenerator) genTryStatement(statement ast.TryStatement) {
+	try := len(genCode.ins)
+	genCode.pushIns(Instruction{Type: Try})
+	genCode.tries++
+	genCode.sm.pushStackFrame(false)
+	stackSize := genCode.genStatement(statement.Statements)
+	genCode.sm.popStackFrame()
+	genCode.tries--
 	if stackSize > 0 {
 		genCode.pushIns(Instruction{
 			Type: MoveStack,
 			Val:  -int64(stackSize),
 		})
 	}
+	genCode.pushIns(Instruction{Type: EndTry})
+	end := genCode.genJump()
 
-	genCode.genStatement(statement.Post)
+	genCode.ins[try].Val = int64(len(genCode.ins) - try)
 	genCode.sm.pushStackFrame(false)
+	genCode.genStoreIns(statement.Label)
+	stackSize = genCode.genStatement(statement.Catch)
+	genCode.sm.popStackFrame()
+	genCode.pushIns(Instruction{
+		Type: MoveStack,
+		Val:  -int64(stackSize + 1), // error
+	})
+	genCode.ins[end].Val = int64(len(genCode.ins) - end)
+}
+
+// loopContext is the for loop being generated
+type loopContext struct {
+	label     string
+	tries     int   // count of try statements when loop begin
+	sp        int64 // stack size when loop statements begin
+	breaks    []int // jump instructions to loop end
+	continues []int // jump instructions to loop post statement
+}
 
+func (genCode *CodeGenerator) pushLoop(label string) *loopContext {
+	loop := &loopContext{label: label, tries: genCode.tries, sp: genCode.sm.SP()}
+	genCode.loops = append(genCode.loops, loop)
+	return loop
+}
+
+// popLoop fix jump of break and continue
+func (genCode *CodeGenerator) popLoop(loop *loopContext, post, end int) {
+	for _, IP := range loop.continues {
+		genCode.ins[IP].Val = int64(post - IP)
+	}
+	for _, IP := range loop.breaks {
+		genCode.ins[IP].Val = int64(end - IP)
+	}
+	genCode.loops = genCode.loops[:len(genCode.loops)-1]
+}
+
+// genJump gen jump without condition,return index of jump instruction
+// for fixing jump val later
+func (genCode *CodeGenerator) genJump() int {
 	genCode.pushIns(Instruction{
 		Type:   Push,
 		ValTyp: Bool,
@@ -401,17 +621,47 @@ func (genCode *CodeGenerator) genForStatement(statement ast.ForExpression) {
 	genCode.pushIns(Instruction{
 		Type:    Jump,
 		JumpTyp: RJump,
-		Val:     int64(begin - len(genCode.ins)),
 	})
+	return len(genCode.ins) - 1
+}
 
-	//fix jump val
-	genCode.ins[jumpStatement-1].Val = int64(len(genCode.ins) - jumpStatement + 1)
+// genLoopJump gen jump of break or continue to the loop of label,
+// the innermost loop if label is empty.The stack of the loops jumped
+// out is reset before jump
+func (genCode *CodeGenerator) genLoopJump(isBreak bool, label string) {
+	var loop *loopContext
+	for i := len(genCode.loops) - 1; i >= 0; i-- {
+		if label == "" || genCode.loops[i].label == label {
+			loop = genCode.loops[i]
+			break
+		}
+	}
+	if loop == nil && label != "" {
+		genCode.errorf("undefined label `%s`", label)
+	}
+	if loop == nil {
+		genCode.errorf("break or continue is not in for loop")
+	}
+	if size := genCode.sm.SP() - loop.sp; size > 0 {
+		genCode.pushIns(Instruction{
+			Type: MoveStack,
+			Val:  -size,
+		})
+	}
+	for i := loop.tries; i < genCode.tries; i++ {
+		genCode.pushIns(Instruction{Type: EndTry})
+	}
+	if isBreak {
+		loop.breaks = append(loop.breaks, genCode.genJump())
+	} else {
+		loop.continues = append(loop.continues, genCode.genJump())
+	}
 }
 
 func (genCode *CodeGenerator) genLoadIns(label string) {
 	index, ok := genCode.sm.load(label)
 	if ok == false {
-		log.Panicln("no find label`" + label + "`")
+		genCode.errorf("undefined `%s`", label)
 	}
 	genCode.pushIns(Instruction{
 		Type:   Load,
@@ -447,8 +697,6 @@ func (genCode *CodeGenerator) genArguments(statement *ast.CallStatement) {
 }
 
 func (genCode *CodeGenerator) genCallStatement(statement *ast.CallStatement) {
-
-	//statement.ParentExp todo
 	var retIP = int64(len(genCode.ins))
 	switch function := statement.Function.(type) {
 	case ast.GetVarStatement:
@@ -461,57 +709,115 @@ func (genCode *CodeGenerator) genCallStatement(statement *ast.CallStatement) {
 
 		if ok {
 			genCode.pushIns(Instruction{
-				Type: Call,
-				Val:  index,
+				Type:   Call,
+				Val:    index,
+				symbol: index,
 			})
 		} else if index, ok := genCode.sm.load(function.Label); ok {
 			genCode.pushIns(Instruction{
-				Type: Load,
-				Val:  index,
+				Type:   Load,
+				Val:    index,
+				symbol: genCode.symbolTable.addSymbol(function.Label),
 			})
 			genCode.pushIns(Instruction{
 				Type: CallO,
 			})
 		} else {
-			genCode.pushIns(Instruction{
-				Type:   Push,
-				ValTyp: Bool,
-				Val:    TRUE,
-			})
-			genCode.pushIns(Instruction{
-				Type:    Jump,
-				JumpTyp: DJump,
-				Val:     -1, //todo link
-			})
-			genCode.toLinks = append(genCode.toLinks, toLink{
-				label: function.Label,
-				IP:    int64(len(genCode.ins)) - 1,
-			})
+			label := function.Label
+			if genCode.module != nil && genCode.module.VM.GlobalFunctions[label] != nil {
+				label = genCode.qualify(label)
+			}
+			genCode.genLinkJump(label)
 		}
 		if ok == false {
 			genCode.ins[retIP].Val = int64(len(genCode.ins)) - retIP
 		}
-
+	case ast.ModuleMember:
+		genCode.pushIns(Instruction{Type: Push, ValTyp: IP})
+		genCode.genArguments(statement)
+		genCode.genLinkJump(function.Module.Name + "." + function.Label)
+		genCode.ins[retIP].Val = int64(len(genCode.ins)) - retIP
 	case ast.PeriodStatement:
+		// the method is bound to the object,CallO pass the object after
+		// arguments
+		function.Bind = true
 		genCode.pushIns(Instruction{Type: Push, ValTyp: IP})
-		genCode.genStatement(function.Exp)
+		genCode.genStatement(function)
+		genCode.genArguments(statement)
+
 		genCode.pushIns(Instruction{
-			Type:   LoadO,
-			ValTyp: String,
-			Str:    function.Val,
+			Type: CallO,
 		})
-		statement.Arguments = append(statement.Arguments, statement.ParentExp)
+		genCode.ins[retIP].Val = int64(len(genCode.ins)) - retIP
+	default:
+		// function is value of expression,fs[0](),mk()()
+		genCode.pushIns(Instruction{Type: Push, ValTyp: IP})
+		genCode.genValue(function)
 		genCode.genArguments(statement)
 
 		genCode.pushIns(Instruction{
 			Type: CallO,
 		})
 		genCode.ins[retIP].Val = int64(len(genCode.ins)) - retIP
-	default:
-		log.Panicf("unkown function type %s", reflect.TypeOf(function).String())
 	}
 }
 
+// genLinkJump jump to function label,the target is set by Linker
+func (genCode *CodeGenerator) genLinkJump(label string) {
+	genCode.pushIns(Instruction{
+		Type:   Push,
+		ValTyp: Bool,
+		Val:    TRUE,
+	})
+	genCode.pushIns(Instruction{
+		Type:    Jump,
+		JumpTyp: DJump,
+		Val:     -1, //todo link
+	})
+	genCode.toLinks = append(genCode.toLinks, toLink{
+		label: label,
+		IP:    int64(len(genCode.ins)) - 1,
+		pos:   genCode.pos,
+	})
+}
+
+// qualify return the label of function or type defined by module being
+// generated,labels of modules are prefixed by name of module
+func (genCode *CodeGenerator) qualify(label string) string {
+	if genCode.module == nil {
+		return label
+	}
+	return genCode.module.Name + "." + label
+}
+
+// genImportStatement generate statements of module in place,functions
+// and types of module are linked with labels prefixed by name of module.
+// Module is generated once,later imports are ignored
+func (genCode *CodeGenerator) genImportStatement(statement ast.ImportStatement) {
+	module := statement.Module
+	if genCode.imported[module] {
+		return
+	}
+	genCode.imported[module] = true
+	outer := genCode.module
+	genCode.module = module
+	defer func() {
+		genCode.module = outer
+	}()
+
+	genCode.sm.pushStackFrame(false)
+	stackSize := genCode.genStatement(module.Statements)
+	for _, object := range module.VM.Objects() {
+		genCode.genStatement(object)
+	}
+	genCode.sm.popStackFrame()
+	// vars of module are invisible to functions,drop them
+	genCode.pushIns(Instruction{
+		Type: MoveStack,
+		Val:  -int64(stackSize),
+	})
+}
+
 type createObjectStatement struct {
 	label string
 }
@@ -538,18 +844,84 @@ func (genCode *CodeGenerator) genCreateObjectStatement(statement createObjectSta
 }
 
 func (genCode *CodeGenerator) genObjectInitStatement(statement ast.ObjectInitStatement) {
+	var label, typeName string
+	var vm = statement.VM
 	switch obj := statement.Exp.(type) {
 	case ast.GetVarStatement:
-		genCode.genCallStatement(&ast.CallStatement{
-			ParentExp: nil,
-			Function:  ast.GetVarStatement{Label: obj.Label + "." + objectInitFunctionName},
-			Arguments: []ast.Expression{createObjectStatement{label: obj.Label}},
-		})
-		genCode.pushIns(Instruction{Type: LoadR, Val: 1})
+		label, typeName = obj.Label, obj.Label
+		if vm.GetTypeObject(typeName) != nil {
+			label = genCode.qualify(typeName)
+		}
+	case ast.ModuleMember:
+		label, typeName, vm = obj.Module.Name+"."+obj.Label, obj.Label, obj.Module.VM
 	default:
-		log.Panicln(reflect.TypeOf(obj).String())
+		genCode.errorf("unknown statement %s", reflect.TypeOf(obj).String())
 	}
+	genCode.genCallStatement(&ast.CallStatement{
+		Function:  ast.GetVarStatement{Label: label + "." + objectInitFunctionName},
+		Arguments: []ast.Expression{createObjectStatement{label: label}},
+	})
+	genCode.pushIns(Instruction{Type: LoadR, Val: 1})
+	// fields of type,then fields of init statement
+	var templates []ast.TypeObjectPropTemplate
+	var object *ast.TypeObject
+	if it := vm.GetTypeObject(typeName); it != nil {
+		object, _ = it.Pointer.(*ast.TypeObject)
+	}
+	if object != nil {
+	Loop:
+		for _, init := range object.TypeObjectPropTemplates {
+			for _, prop := range statement.PropTemplates {
+				if init.Name == prop.Name {
+					continue Loop
+				}
+			}
+			templates = append(templates, init)
+		}
+	}
+	for _, init := range append(templates, statement.PropTemplates...) {
+		genCode.genValue(init.Exp)
+		genCode.pushIns(Instruction{Type: Load, Val: -2})
+		genCode.pushIns(Instruction{Type: StoreO, Str: init.Name})
+	}
+	// hidden fields,the type for printing and Is,strict after all fields
+	// stored
+	genCode.pushIns(Instruction{Type: Push, ValTyp: String, Str: label})
+	genCode.pushIns(Instruction{Type: Load, Val: -2})
+	genCode.pushIns(Instruction{Type: StoreO, Str: typeLabel})
+	if object != nil && object.Strict {
+		genCode.pushIns(Instruction{Type: Push, ValTyp: Bool, Val: TRUE})
+		genCode.pushIns(Instruction{Type: Load, Val: -2})
+		genCode.pushIns(Instruction{Type: StoreO, Str: strictLabel})
+	}
+	genCode.genConstructor(statement, object)
+}
 
+// genConstructor call init of object on the top of stack,as
+// ObjectInitStatement of tree engine
+func (genCode *CodeGenerator) genConstructor(statement ast.ObjectInitStatement, object *ast.TypeObject) {
+	var method *ast.FuncExpression
+	if object != nil {
+		method = object.Constructor()
+	}
+	if method == nil {
+		if len(statement.Arguments) != 0 {
+			genCode.errorf("type %s has no method `%s`", statement.Exp.String(), ast.ConstructorName)
+		}
+		return
+	}
+	if statement.Constructor == false && len(method.Parameters) != 1 {
+		return
+	}
+	if msg := ast.ConstructorArity(statement, method); msg != "" {
+		genCode.pushIns(Instruction{Type: Panic, Str: msg})
+		return
+	}
+	// object is under the return address pushed by call
+	genCode.genCallStatement(&ast.CallStatement{
+		Function:  ast.PeriodStatement{Exp: topValue{offset: 2}, Val: ast.ConstructorName},
+		Arguments: statement.Arguments,
+	})
 }
 
 /*
@@ -563,13 +935,41 @@ func (genCode *CodeGenerator) genObject(label *runtime.Object) {
 
 const closureLabel = "__Closure__"
 
+// hidden fields of object: label of type,prefixed by module for types of
+// module,true when type is strict
+const (
+	typeLabel   = "__type__"
+	strictLabel = "__strict__"
+)
+
+// typePatterns return Str of Is: patterns separated by `|`,a pattern is
+// name of built in type,label of type or `{method,...}` of interface
+func (genCode *CodeGenerator) typePatterns(types []ast.TypeName) string {
+	var patterns []string
+	for _, t := range types {
+		switch declaration := t.Declaration().(type) {
+		case *ast.TypeObject:
+			if t.Module != nil {
+				patterns = append(patterns, t.Module.Name+"."+t.Label)
+			} else {
+				patterns = append(patterns, genCode.qualify(t.Label))
+			}
+		case *ast.InterfaceObject:
+			patterns = append(patterns, "{"+strings.Join(declaration.MethodNames(), ",")+"}")
+		default:
+			patterns = append(patterns, t.Label)
+		}
+	}
+	return strings.Join(patterns, "|")
+}
+
 func (genCode *CodeGenerator) genFuncStatement(statement *ast.FuncExpression) {
 	if statement.Closure {
 
 		//generate function label
 		hash := crc32.NewIEEE()
 		hash.Write([]byte(statement.String()))
-		statement.Label = fmt.Sprintf("lambda_%d", hash.Sum32())
+		statement.Label = genCode.qualify(fmt.Sprintf("lambda_%d", hash.Sum32()))
 
 		// link
 		defer func() {
@@ -589,11 +989,12 @@ func (genCode *CodeGenerator) genFuncStatement(statement *ast.FuncExpression) {
 			for _, label := range statement.ClosureLabel {
 				index, ok := genCode.sm.load(label)
 				if ok == false {
-					log.Panicf("no find label `%s`", label)
+					genCode.errorf("undefined `%s`", label)
 				}
 				genCode.pushIns(Instruction{
-					Type: Load,
-					Val:  index,
+					Type:   Load,
+					Val:    index,
+					symbol: genCode.symbolTable.addSymbol(label),
 				})
 				genCode.pushIns(Instruction{Type: Append})
 			}
@@ -615,6 +1016,9 @@ func (genCode *CodeGenerator) genFuncStatement(statement *ast.FuncExpression) {
 	if len(label) == 0 {
 		label = strings.Join(statement.Labels, ".")
 	}
+	if statement.Closure == false {
+		label = genCode.qualify(label)
+	}
 	done := genCode.prepareGenFunction(label)
 	defer done()
 
@@ -626,24 +1030,26 @@ func (genCode *CodeGenerator) genFuncStatement(statement *ast.FuncExpression) {
 		Type: MakeStack,
 	})
 
-	if len(statement.Parameters) > 1 {
-		if statement.Parameters[0] == "this" {
-			statement.Parameters = append(statement.Parameters[1:], "this")
-		}
+	// `this` is the first parameter of method in tree engine,but the
+	// object bound is passed after arguments by CallO
+	parameters := statement.Parameters
+	if statement.IsMethod() {
+		parameters = append(append([]string{}, parameters[1:]...), "this")
 	}
 	// arguments
-	for i := 0; i < len(statement.Parameters); i++ {
+	for i := 0; i < len(parameters); i++ {
 		genCode.pushIns(Instruction{
 			Type: LoadR,
 			Val:  int64(i + 1),
 		})
-		genCode.symbolTable.addSymbol(statement.Parameters[i])
-		genCode.sm.Store(statement.Parameters[i])
+		genCode.symbolTable.addSymbol(parameters[i])
+		genCode.sm.Store(parameters[i])
 	}
 
 	for _, it := range statement.ClosureLabel {
 		genCode.symbolTable.addSymbol(it)
 		genCode.sm.Store(it)
+		genCode.sm.currStack.vars.closure = true
 	}
 
 	if statement.Closure {
@@ -690,12 +1096,13 @@ func (genCode *CodeGenerator) genInitStatement(statement objectInitStatement) {
 			Val:    -1, // to link
 		})
 		genCode.toLinks = append(genCode.toLinks, toLink{
-			label: strings.Join(function.Labels, "."),
+			label: genCode.qualify(strings.Join(function.Labels, ".")),
 			IP:    int64(len(genCode.ins)) - 1,
 		})
 		genCode.pushIns(Instruction{
-			Type: Load,
-			Val:  0,
+			Type:   Load,
+			Val:    0,
+			symbol: genCode.symbolTable.addSymbol("this"),
 		})
 		genCode.pushIns(Instruction{
 			Type: StoreO,
@@ -733,9 +1140,15 @@ func (genCode *CodeGenerator) genTypeObject(statement *ast.TypeObject) {
 func (genCode *CodeGenerator) prepareGenFunction(label string) func() {
 	ins := genCode.ins
 	toLink := genCode.toLinks
+	loops := genCode.loops
+	tries := genCode.tries
 	genCode.ins = nil
 	genCode.toLinks = nil
+	genCode.loops = nil
+	genCode.tries = 0
 	return func() {
+		genCode.loops = loops
+		genCode.tries = tries
 		genCode.funcInstructions[label] = FuncInstruction{
 			toLinks: genCode.toLinks,
 			ins:     genCode.ins,
@@ -747,14 +1160,31 @@ func (genCode *CodeGenerator) prepareGenFunction(label string) func() {
 }
 
 func (genCode *CodeGenerator) genReturnStatement(statement ast.ReturnStatement) {
-	genCode.genStatement(statement.Exp)
-	if statement.Exp.GetType() == lexer.CallType {
-		genCode.pushIns(Instruction{Type: LoadR, Val: 1})
+	if tuple, ok := statement.Exp.(ast.TupleExpression); ok {
+		// R[0] is count of values,values are stored to R[1...]
+		if len(tuple.Exps) >= len(Machine{}.R) {
+			genCode.errorf("too many return values %d", len(tuple.Exps))
+		}
+		for _, exp := range tuple.Exps {
+			genCode.genValue(exp)
+		}
+		genCode.pushIns(Instruction{Type: Push, ValTyp: Int, Val: int64(len(tuple.Exps))})
+		genCode.pushIns(Instruction{Type: StoreR, Val: 0})
+		for index := len(tuple.Exps); index > 0; index-- {
+			genCode.pushIns(Instruction{Type: StoreR, Val: int64(index)})
+		}
+	} else if statement.Exp.GetType() == lexer.CallType {
+		// `return f()` return all values of f,they are in registers already
+		genCode.genStatement(statement.Exp)
+	} else {
+		genCode.genValue(statement.Exp)
+		genCode.pushIns(Instruction{
+			Type: StoreR,
+			Val:  1,
+		})
+		genCode.pushIns(Instruction{Type: Push, ValTyp: Int, Val: 1})
+		genCode.pushIns(Instruction{Type: StoreR, Val: 0})
 	}
-	genCode.pushIns(Instruction{
-		Type: StoreR,
-		Val:  1,
-	})
 	genCode.pushIns(Instruction{Type: PopStack})
 	genCode.pushIns(Instruction{Type: Ret})
 }
@@ -765,37 +1195,122 @@ func (genCode *CodeGenerator) GenExit() {
 	})
 }
 
+// genValue gen statement and load the result of call to stack
+func (genCode *CodeGenerator) genValue(statement runtime.Invokable) {
+	genCode.genStatement(statement)
+	if statement.GetType() == lexer.CallType {
+		genCode.pushIns(Instruction{Type: LoadR, Val: 1})
+	}
+}
+
+func (genCode *CodeGenerator) genMakeMapStatement(statement *ast.MakeMapStatement) {
+	genCode.pushIns(Instruction{Type: MakeMap})
+	for index := range statement.Keys {
+		genCode.genValue(statement.Values[index])
+		genCode.pushIns(Instruction{Type: Load, Val: -2})
+		genCode.genValue(statement.Keys[index])
+		genCode.pushIns(Instruction{Type: StoreIndex})
+	}
+}
+
 func (genCode *CodeGenerator) genAssignStatement(statement ast.AssignStatement) {
-	genCode.genStatement(statement.Exp)
+	genCode.genValue(statement.Exp)
 	switch obj := statement.Left.(type) {
 	case ast.GetVarStatement:
-		if index, ok := genCode.sm.load(obj.Label); ok {
-			genCode.pushIns(Instruction{
-				Type: Store,
-				Val:  index,
-			})
+		index, ok := genCode.sm.load(obj.Label)
+		if ok == false {
+			genCode.setPosition(obj)
+			genCode.errorf("undefined `%s`", obj.Label)
 		}
+		genCode.pushIns(Instruction{
+			Type:   Store,
+			Val:    index,
+			symbol: genCode.symbolTable.addSymbol(obj.Label),
+		})
 	case ast.PeriodStatement:
 		genCode.genStatement(obj.Exp)
 		genCode.pushIns(Instruction{
 			Type: StoreO,
 			Str:  obj.Val,
 		})
+	case ast.IndexExpression:
+		genCode.genValue(obj.Exp)
+		genCode.genValue(obj.Index)
+		genCode.pushIns(Instruction{Type: StoreIndex})
 	default:
-		log.Panicln(reflect.TypeOf(obj).String())
+		genCode.errorf("unknown statement %s", reflect.TypeOf(obj).String())
 	}
 }
 
+// topValue load the value at offset from top of stack
+type topValue struct {
+	offset int64
+}
+
+func (t topValue) Invoke() runtime.Invokable {
+	panic("implement me")
+}
+
+func (t topValue) GetType() lexer.Type {
+	return lexer.ObjectType
+}
+
+func (t topValue) String() string {
+	return "top " + strconv.FormatInt(t.offset, 10)
+}
+
+// genMultiAssignStatement push values to stack,the values are the vars of
+// `a, b := f()`,or they are assigned to lefts one by one and dropped
+func (genCode *CodeGenerator) genMultiAssignStatement(statement ast.MultiAssignStatement) int {
+	var count = int64(len(statement.Lefts))
+	if tuple, ok := statement.Exp.(ast.TupleExpression); ok {
+		for _, exp := range tuple.Exps {
+			genCode.genValue(exp)
+		}
+	} else {
+		genCode.genStatement(statement.Exp)
+		genCode.pushIns(Instruction{Type: CheckR, Val: count})
+		for index := int64(1); index <= count; index++ {
+			genCode.pushIns(Instruction{Type: LoadR, Val: index})
+		}
+	}
+	if statement.Define {
+		for _, left := range statement.Lefts {
+			if label := left.(ast.GetVarStatement).Label; label != "_" {
+				genCode.genStoreIns(label)
+			} else {
+				genCode.sm.Store("")
+			}
+		}
+		return int(count)
+	}
+	for index, left := range statement.Lefts {
+		if left, ok := left.(ast.GetVarStatement); ok && left.Label == "_" {
+			continue
+		}
+		genCode.genAssignStatement(ast.AssignStatement{
+			Left: left,
+			Exp:  topValue{offset: count - int64(index)},
+		})
+	}
+	genCode.pushIns(Instruction{
+		Type: MoveStack,
+		Val:  -count,
+	})
+	return 0
+}
+
 func (genCode *CodeGenerator) genIncFieldStatement(statement ast.IncFieldStatement) {
 	switch obj := statement.Exp.(type) {
 	case ast.GetVarStatement:
 		index, ok := genCode.sm.load(obj.Label)
 		if ok == false {
-			log.Panicln("no find label`" + obj.Label + "`")
+			genCode.errorf("undefined `%s`", obj.Label)
 		}
 		genCode.pushIns(Instruction{
-			Type: Load,
-			Val:  index,
+			Type:   Load,
+			Val:    index,
+			symbol: genCode.symbolTable.addSymbol(obj.Label),
 		})
 		genCode.pushIns(Instruction{
 			Type:   Push,
@@ -806,8 +1321,9 @@ func (genCode *CodeGenerator) genIncFieldStatement(statement ast.IncFieldStateme
 			Type: Add,
 		})
 		genCode.pushIns(Instruction{
-			Type: Store,
-			Val:  index,
+			Type:   Store,
+			Val:    index,
+			symbol: genCode.symbolTable.addSymbol(obj.Label),
 		})
 	case ast.PeriodStatement:
 		genCode.genStatement(obj.Exp)
@@ -829,6 +1345,19 @@ func (genCode *CodeGenerator) genIncFieldStatement(statement ast.IncFieldStateme
 			Type: StoreO,
 			Str:  obj.Val,
 		})
+	case ast.IndexExpression:
+		genCode.genStatement(obj)
+		genCode.pushIns(Instruction{
+			Type:   Push,
+			ValTyp: Int,
+			Val:    1,
+		})
+		genCode.pushIns(Instruction{
+			Type: Add,
+		})
+		genCode.genValue(obj.Exp)
+		genCode.genValue(obj.Index)
+		genCode.pushIns(Instruction{Type: StoreIndex})
 	}
 }
 
@@ -24,7 +24,9 @@ func runScript(script string) {
 	GC := NewCodeGenerator()
 	fmt.Println(GC.Gen(statements))
 	m := NewMachine(GC, Options{Debug: false})
-	m.Run()
+	if err := m.Run(); err != nil {
+		panic(err)
+	}
 }
 
 func TestGenStoreIns(t *testing.T) {
@@ -256,3 +258,68 @@ for var count = 0;count < 10;count++{
 
 `)
 }
+
+func TestGenMap(t *testing.T) {
+	script := `
+var m = {"a": 1, "b": 2,}
+m["c"] = 3
+m["a"]++
+println(m, len(m), m["a"], m["x"])
+delete(m, "b")
+println(keys(m))
+if m["c"] == 3 {
+	var n = {1: "one", true: {"k": len("abc")}}
+	println(n[1], n[true]["k"])
+}
+`
+	runScript(script)
+}
+
+func TestGenFloat(t *testing.T) {
+	script := `
+var a = 1.5
+var b = a * 2 + 1e-3
+println(a, b, 7 / 2, 7 / 2.0, 7 % 3, 1.5E+3, int(b), float(3), int("12"), 3 < 3.5)
+`
+	runScript(script)
+}
+
+func TestGenForRange(t *testing.T) {
+	script := `
+var arr = [10, 20, 30, 40]
+for i, v := range arr {
+	var x = v
+	if i == 1 {
+		continue
+	}
+	if x == 40 {
+		break
+	}
+	println(i, x)
+}
+for _, c := range "hé" {
+	println(c)
+}
+var m = {"a": 1, "b": 2}
+for k, v := range m {
+	println(k, v)
+}
+var n = 0
+for range arr {
+	n++
+}
+for i := 0; i < 5; i++ {
+	var y = i
+	if y % 2 == 0 {
+		continue
+	}
+	if y > 3 {
+		break
+	}
+	println("odd", y)
+}
+var after = 1
+println(n, after)
+`
+	runScript(script)
+}
@@ -0,0 +1,69 @@
+package stackmachine
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// iterator is the Obj of Iterator made by Range instruction,
+// keys are snapshot when loop begin
+type iterator struct {
+	object Object
+	keys   ObjectArray
+	index  int
+}
+
+func newIterator(object Object) *iterator {
+	it := &iterator{object: object}
+	switch object.Type {
+	case Array:
+		for index := range object.Obj.(ObjectArray) {
+			it.keys = append(it.keys, Object{Type: Int, Int: int64(index)})
+		}
+	case String:
+		for index := range object.Obj.(string) {
+			it.keys = append(it.keys, Object{Type: Int, Int: int64(index)})
+		}
+	case Map:
+		it.keys = object.Obj.(*mapObject).keyObjects()
+	case Obj:
+		var names []string
+		for name := range object.fields() {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			it.keys = append(it.keys, Object{Type: String, Obj: name})
+		}
+	case Nil:
+	default:
+		panicln("can't range over", object.String())
+	}
+	return it
+}
+
+// next return key and value of next loop,false if loop is done
+func (it *iterator) next() (Object, Object, bool) {
+	for it.index < len(it.keys) {
+		key := it.keys[it.index]
+		it.index++
+		switch it.object.Type {
+		case Array:
+			if array := it.object.Obj.(ObjectArray); key.Int < int64(len(array)) {
+				return key, array[key.Int], true
+			}
+		case String:
+			char, _ := utf8.DecodeRuneInString(it.object.Obj.(string)[key.Int:])
+			return key, Object{Type: String, Obj: string(char)}, true
+		case Map:
+			if value, ok := it.object.Obj.(*mapObject).values[toMapKey(key)]; ok {
+				return key, *value, true
+			}
+		case Obj:
+			if value, ok := it.object.Obj.(objectMap)[key.Obj.(string)]; ok {
+				return key, *value, true
+			}
+		}
+	}
+	return Object{}, Object{}, false
+}
@@ -1,6 +1,6 @@
 package stackmachine
 
-import "log"
+import "gitlab.com/akzj/qp/ast"
 
 type Linker struct {
 	functionPoint      map[string]int64
@@ -30,28 +30,27 @@ func (linker *Linker) link() []Instruction {
 	for _, link := range linker.toLink {
 		ins := linker.ins[link.IP]
 		if ins.Val != -1{
-			log.Panicln("toLink error", ins.String(linker.symbolTable, linker.builtInSymbolTable), link.IP)
+			ast.Panicln("toLink error", ins.String(linker.symbolTable, linker.builtInSymbolTable), link.IP)
 		}
-		ins.Val = linker.getFunctionPoint(link.label)
+		ins.Val = linker.getFunctionPoint(link)
 		linker.ins[link.IP] = ins
-		log.Println(ins)
 	}
 	return linker.ins
 }
 
-func (linker *Linker) getFunctionPoint(label string) int64 {
-	if point, ok := linker.functionPoint[label]; ok {
+func (linker *Linker) getFunctionPoint(link toLink) int64 {
+	if point, ok := linker.functionPoint[link.label]; ok {
 		return point
 	}
-	function, ok := linker.functions[label]
+	function, ok := linker.functions[link.label]
 	if ok == false {
-		log.Panicf("no find function `%s`\n", label)
+		ast.PanicAt(link.pos, "undefined function `%s`", link.label)
 	}
 	index := int64(len(linker.ins))
-	linker.functionPoint[label] = index
+	linker.functionPoint[link.label] = index
 	linker.ins = append(linker.ins, function.ins...)
 	for _, link := range function.toLinks {
-		linker.ins[link.IP+index].Val = linker.getFunctionPoint(link.label)
+		linker.ins[link.IP+index].Val = linker.getFunctionPoint(link)
 	}
 	return index
 }
@@ -3,9 +3,16 @@ package stackmachine
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"log"
+	"math"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
+
+	"gitlab.com/akzj/qp/runtime"
 )
 
 type InstType byte
@@ -49,6 +56,26 @@ const (
 	MakeArray   // make array
 	Append      // append to array
 	InitClosure // init lambda closure
+	MakeMap     // make map
+	Index       // load element of map or array
+	StoreIndex  // store element to map
+	Range       // make iterator for range loop
+	Next        // next key,value of iterator
+	Try         // begin try statement,Val is the relative IP of catch block
+	EndTry      // end try statement
+	AddInt      // + of int operands,known by type checker
+	SubInt      // - of int operands
+	MulInt      // * of int operands
+	DivInt      // / of int operands
+	ModInt      // % of int operands
+	CmpInt      // compare int operands
+	Is          // check type of value,Str is patterns of types
+	Bind        // bind object to method loaded from it
+	Slice       // make array of elements from low to high,nil bound is omitted
+	CheckR      // check count of values in R0 is Val,for multiple assignment
+	Panic       // raise runtime error of message Str
+
+	instTypeCount // number of instruction types
 
 	TRUE  int64 = 1
 	FALSE int64 = 0
@@ -66,7 +93,15 @@ const (
 	Duration
 	Obj
 	Array
-	Nil // nilObject
+	Nil   // nilObject
+	GFunc // go function
+	Map   // map
+	Float // float64,bits are stored in Int
+	Iterator
+	Error  // runtime error caught by catch,Obj is the message
+	Method // method bound to object,Obj is *boundMethod
+
+	valTypeCount // number of value types
 
 	DJump JumpType = 0
 	RJump JumpType = 1
@@ -113,6 +148,9 @@ type Instruction struct {
 	symbol        int64
 	Val           int64
 	Str           string
+	file          int64 // index of file table
+	Line          int   // source line,0 if unknown
+	vars          *localVar
 }
 
 func (i Instruction) String(table, builtIn *SymbolTable) string {
@@ -125,6 +163,12 @@ func (i Instruction) String(table, builtIn *SymbolTable) string {
 		return "mul"
 	case Mod:
 		return "mod"
+	case Div:
+		return "div"
+	case AddInt, SubInt, MulInt, DivInt, ModInt:
+		return Instruction{Type: i.Type.generic()}.String(table, builtIn) + "_int"
+	case CmpInt:
+		return strings.Replace(Instruction{Type: Cmp, CmpTyp: i.CmpTyp}.String(table, builtIn), "cmp", "cmp_int", 1)
 	case And:
 		return "&&"
 	case Jump:
@@ -138,6 +182,8 @@ func (i Instruction) String(table, builtIn *SymbolTable) string {
 			return "push ip " + strconv.FormatInt(i.Val, 10)
 		} else if i.ValTyp == Int {
 			return "push " + strconv.FormatInt(i.Val, 10)
+		} else if i.ValTyp == Float {
+			return "push float " + strconv.FormatFloat(math.Float64frombits(uint64(i.Val)), 'g', -1, 64)
 		} else if i.ValTyp == Bool {
 			if i.Val == TRUE {
 				return "push true"
@@ -159,6 +205,30 @@ func (i Instruction) String(table, builtIn *SymbolTable) string {
 		return "exit"
 	case InitClosure:
 		return "init_closure"
+	case MakeMap:
+		return "make_map"
+	case Index:
+		return "index"
+	case StoreIndex:
+		return "store_index"
+	case Range:
+		return "range"
+	case Next:
+		return "next"
+	case Try:
+		return "try " + strconv.FormatInt(i.Val, 10)
+	case EndTry:
+		return "end_try"
+	case Is:
+		return "is " + i.Str
+	case Bind:
+		return "bind"
+	case Slice:
+		return "slice"
+	case CheckR:
+		return "checkR " + strconv.FormatInt(i.Val, 10)
+	case Panic:
+		return "panic " + strconv.Quote(i.Str)
 	case StoreO:
 		return "StoreO \"" + i.Str + "\""
 	case MakeArray:
@@ -166,6 +236,9 @@ func (i Instruction) String(table, builtIn *SymbolTable) string {
 	case Append:
 		return "append"
 	case Load:
+		if i.Val < 0 {
+			return "load top " + strconv.FormatInt(-i.Val, 10)
+		}
 		return "load " + table.symbols[i.symbol] + " " + strconv.FormatInt(i.Val, 10)
 	case LoadR:
 		return "loadR " + strconv.FormatInt(i.Val, 10)
@@ -230,6 +303,144 @@ func (obj *Object) loadObj(label string) *Object {
 	return o
 }
 
+// checkField panic when object of strict type has no field label
+func (obj *Object) checkField(label string) {
+	object, ok := obj.Obj.(objectMap)
+	if ok == false || object[strictLabel] == nil || object[label] != nil {
+		return
+	}
+	panicf("type %s has no field `%s`", obj.typeName(), label)
+}
+
+// typeName return name of type of object without module,"" if object is no
+// created by type
+func (obj Object) typeName() string {
+	object, _ := obj.Obj.(objectMap)
+	typ := object[typeLabel]
+	if typ == nil {
+		return ""
+	}
+	name := typ.Obj.(string)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// is check object is of one of types of patterns,see typePatterns
+func (obj Object) is(patterns string) bool {
+	for _, pattern := range strings.Split(patterns, "|") {
+		if obj.match(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func (obj Object) match(pattern string) bool {
+	switch pattern {
+	case "any", "{}":
+		return obj.Type != Nil
+	case "int":
+		return obj.Type == Int
+	case "float":
+		return obj.Type == Float
+	case "string":
+		return obj.Type == String
+	case "bool":
+		return obj.Type == Bool
+	case "array":
+		return obj.Type == Array
+	case "map":
+		return obj.Type == Map
+	case "func":
+		return obj.isFunc()
+	case "nil":
+		return obj.Type == Nil
+	}
+	object, ok := obj.Obj.(objectMap)
+	if obj.Type != Obj || ok == false {
+		return false
+	}
+	if strings.HasPrefix(pattern, "{") {
+		// interface,object has all methods
+		for _, method := range strings.Split(pattern[1:len(pattern)-1], ",") {
+			if field := object[method]; field == nil || field.isFunc() == false {
+				return false
+			}
+		}
+		return true
+	}
+	typ := object[typeLabel]
+	return typ != nil && typ.Obj.(string) == pattern
+}
+
+// arrayIndex return index of element of array,error raised when index is
+// no int or out of range
+func arrayIndex(array ObjectArray, index Object) int64 {
+	if index.Type != Int {
+		panicln("array index is no int", index.String())
+	}
+	if index.Int < 0 {
+		panicf("invalid array index %d (index must be non-negative)", index.Int)
+	}
+	if index.Int >= int64(len(array)) {
+		panicf("index %d out of range [0:%d]", index.Int, len(array))
+	}
+	return index.Int
+}
+
+// slice return array of elements of array from low to high,bounds of nil
+// are 0 and length of array.Elements are copied
+func slice(array, low, high Object) Object {
+	if array.Type != Array {
+		panicln("slice no array", array.String())
+	}
+	data := array.Obj.(ObjectArray)
+	lo, hi := sliceBound(low, 0), sliceBound(high, int64(len(data)))
+	if lo < 0 || hi > int64(len(data)) || lo > hi {
+		panicf("slice bounds out of range [%d:%d] with length %d", lo, hi, len(data))
+	}
+	result := make(ObjectArray, hi-lo)
+	copy(result, data[lo:hi])
+	return Object{Type: Array, Obj: result}
+}
+
+// sliceBound return value of bound,value is the default of bound of nil
+func sliceBound(bound Object, value int64) int64 {
+	switch bound.Type {
+	case Nil:
+		return value
+	case Int:
+		return bound.Int
+	}
+	panicln("slice index is no int", bound.String())
+	return 0
+}
+
+// boundMethod is method taken from object,`f := u.print`,the object is
+// passed as `this` when it is called
+type boundMethod struct {
+	function Object
+	this     Object
+}
+
+// bind return method loaded from object bound to the object,fields of
+// other values are returned as they are
+func bind(object, field Object) Object {
+	switch {
+	case field.Type == OFunc,
+		field.Type == BFunc && object.Type == String:
+		return Object{Type: Method, Obj: &boundMethod{function: field, this: object}}
+	}
+	return field
+}
+
+func (obj Object) isFunc() bool {
+	switch obj.Type {
+	case BFunc, OFunc, GFunc, Lambda, Method:
+		return true
+	}
+	return false
+}
+
 func (obj *Object) Store(str string, ele Object) {
 	if obj.Obj == nil {
 		obj.Obj = make(objectMap)
@@ -237,9 +448,21 @@ func (obj *Object) Store(str string, ele Object) {
 	obj.Obj.(objectMap)[str] = &ele
 }
 
+// NewFloat make Float object
+func NewFloat(f float64) Object {
+	return Object{Type: Float, Int: int64(math.Float64bits(f))}
+}
+
+// Float return value of Float object
+func (obj Object) Float() float64 {
+	return math.Float64frombits(uint64(obj.Int))
+}
+
 func (obj Object) String() string {
 	if obj.Type == Int {
 		return strconv.FormatInt(obj.Int, 10)
+	} else if obj.Type == Float {
+		return strconv.FormatFloat(obj.Float(), 'g', -1, 64)
 	} else if obj.Type == Bool {
 		if obj.Int == TRUE {
 			return "true"
@@ -253,13 +476,23 @@ func (obj Object) String() string {
 	} else if obj.Type == Duration {
 		return time.Duration(obj.Int).String()
 	} else if obj.Type == Obj {
-		return "object"
+		return obj.format(map[uintptr]bool{})
+	} else if obj.Type == GFunc {
+		return "{ function " + obj.Obj.(Function).Name + " }"
 	} else if obj.Type == OFunc || obj.Type == BFunc {
 		return "{ function " + strconv.FormatInt(obj.Int, 10) + " }"
 	} else if obj.Type == Lambda {
 		return "{ lambda " + strconv.FormatInt(obj.Int, 10) + " }"
+	} else if obj.Type == Method {
+		return obj.Obj.(*boundMethod).function.String()
 	} else if obj.Type == Array {
 		return fmt.Sprintf("%+v", obj.Obj)
+	} else if obj.Type == Map {
+		return obj.Obj.(*mapObject).String()
+	} else if obj.Type == Nil {
+		return "nil"
+	} else if obj.Type == Error {
+		return obj.Obj.(string)
 	} else {
 		return fmt.Sprintf("{%d %d}", obj.Type, obj.Int)
 	}
@@ -268,6 +501,7 @@ func (obj Object) String() string {
 type StackFrame struct {
 	stack []Object
 	SP    int64
+	ret   int64 // return address of the call,-1 if unknown
 }
 
 type Machine struct {
@@ -281,13 +515,36 @@ type Machine struct {
 	IP                 int64
 	R                  [32]Object //register
 	closure            ObjectArray
+	functions          []Function   // built in functions
+	files              *SymbolTable // source files of instructions
+	handlers           []handler    // try statements running
+	hook               func() bool  // called before instruction,false stop machine
+}
+
+// handler is the catch block of running try statement
+type handler struct {
+	catch  int64 // IP of catch block
+	frames int   // count of stack frames when try begin
+	stack  []Object
+	SP     int64
 }
 
 type Options struct {
 	Debug bool
+	// Stdout is the writer of println,os.Stdout when nil
+	Stdout io.Writer
 }
 
+// stackReserve is the least free slots of stack for function call,call with
+// less free slots raise stack overflow
+const stackReserve = 1024
+
 func NewMachine(gen *CodeGenerator, options Options) *Machine {
+	functions := BuiltInFunctions
+	if options.Stdout != nil {
+		functions = append([]Function{}, BuiltInFunctions...)
+		functions[BuiltInFunctionsIndex["println"]].Call = Println(options.Stdout)
+	}
 	return &Machine{
 		Options:            options,
 		symbolTable:        gen.symbolTable,
@@ -296,6 +553,8 @@ func NewMachine(gen *CodeGenerator, options Options) *Machine {
 		SP:                 -1,
 		instructions:       gen.ins,
 		IP:                 0,
+		functions:          functions,
+		files:              gen.files,
 	}
 }
 
@@ -307,9 +566,51 @@ func getBuiltInSymbolTable() *SymbolTable {
 	return table
 }
 
-func (m *Machine) Run() {
+// Run execute the code,runtime error of script no caught is returned
+// as *RuntimeError
+func (m *Machine) Run() error {
+	for {
+		r := m.runRecover()
+		if r == nil {
+			return nil
+		}
+		if m.catch(r) == false {
+			return m.runtimeError(r)
+		}
+	}
+}
+
+// runRecover run code until exit or panic,the value of panic is returned
+func (m *Machine) runRecover() (r interface{}) {
+	defer func() {
+		r = recover()
+	}()
+	m.run()
+	return nil
+}
+
+// catch jump to the catch block of the innermost try statement,the stack
+// is reset to the size when try begin and the error is pushed
+func (m *Machine) catch(r interface{}) bool {
+	if len(m.handlers) == 0 {
+		return false
+	}
+	h := m.handlers[len(m.handlers)-1]
+	m.handlers = m.handlers[:len(m.handlers)-1]
+	m.stackFrames = m.stackFrames[:h.frames]
+	m.stack = h.stack
+	m.SP = h.SP + 1
+	m.stack[m.SP] = Object{Type: Error, Obj: errorMessage(r)}
+	m.IP = h.catch
+	return true
+}
+
+func (m *Machine) run() {
 	for m.IP < int64(len(m.instructions)) {
 		ins := m.instructions[m.IP]
+		if m.hook != nil && m.hook() == false {
+			return
+		}
 		if m.Debug {
 			fmt.Println(m.IP, ins.String(m.symbolTable, m.builtInSymbolTable), " SP: ", m.SP)
 		}
@@ -330,7 +631,15 @@ func (m *Machine) Run() {
 				m.stack[m.SP].Int = ins.Val
 			}
 		case MakeStack:
-			m.stackFrames = append(m.stackFrames, StackFrame{SP: m.SP, stack: m.stack})
+			var ret int64 = -1
+			if m.SP >= 0 && m.stack[m.SP].Type == IP {
+				ret = m.stack[m.SP].Int
+			}
+			if len(m.stackFrames) >= runtime.MaxCallDepth ||
+				len(m.stack)-int(m.SP) <= stackReserve {
+				panicln("stack overflow")
+			}
+			m.stackFrames = append(m.stackFrames, StackFrame{SP: m.SP, stack: m.stack, ret: ret})
 			m.stack = m.stack[m.SP+1:]
 			m.SP = -1
 		case PopStack:
@@ -338,8 +647,40 @@ func (m *Machine) Run() {
 			m.stack = frame.stack
 			m.SP = frame.SP
 			m.stackFrames = m.stackFrames[:len(m.stackFrames)-1]
-
-		case Add, Sub, Cmp, Mul:
+			// try statements of the function returned
+			for len(m.handlers) > 0 && m.handlers[len(m.handlers)-1].frames > len(m.stackFrames) {
+				m.handlers = m.handlers[:len(m.handlers)-1]
+			}
+		case Try:
+			m.handlers = append(m.handlers, handler{
+				catch:  m.IP + ins.Val,
+				frames: len(m.stackFrames),
+				stack:  m.stack,
+				SP:     m.SP,
+			})
+		case EndTry:
+			m.handlers = m.handlers[:len(m.handlers)-1]
+		case Bind:
+			m.SP--
+			m.stack[m.SP] = bind(m.stack[m.SP], m.stack[m.SP+1])
+			m.stack[m.SP+1].Obj = nil
+		case Is:
+			if m.stack[m.SP].is(ins.Str) {
+				m.stack[m.SP] = Object{Type: Bool, Int: TRUE}
+			} else {
+				m.stack[m.SP] = Object{Type: Bool, Int: FALSE}
+			}
+		case AddInt, SubInt, MulInt, DivInt, ModInt, CmpInt:
+			operand1, operand2 := &m.stack[m.SP-1], &m.stack[m.SP]
+			if operand1.Type == Int && operand2.Type == Int {
+				m.SP--
+				m.stack[m.SP] = intOp(ins, operand1.Int, operand2.Int)
+				break
+			}
+			// the value of untyped code may be no int
+			ins.Type = ins.Type.generic()
+			fallthrough
+		case Add, Sub, Cmp, Mul, Div, Mod:
 			operand2 := &m.stack[m.SP]
 			m.SP--
 			operand1 := &m.stack[m.SP]
@@ -380,7 +721,7 @@ func (m *Machine) Run() {
 								result.Int = TRUE
 							}
 						default:
-							log.Panicln("unknown instruction", ins, m.IP)
+							invalidOperation(ins, operand1, operand2)
 						}
 					case Mul:
 						result.Type = Int
@@ -398,8 +739,10 @@ func (m *Machine) Run() {
 						result.Type = Int
 						result.Int = operand1.Int % operand2.Int
 					default:
-						log.Panicln("unknown instruction", ins, m.IP)
+						invalidOperation(ins, operand1, operand2)
 					}
+				case Float:
+					result = floatOp(ins, float64(operand1.Int), operand2.Float())
 				case Nil:
 					result.Type = Bool
 					result.Int = FALSE
@@ -409,8 +752,22 @@ func (m *Machine) Run() {
 						result.Int = TRUE
 					}
 				default:
-					log.Panicln("unknown instruction",
-						ins.String(m.symbolTable, m.builtInSymbolTable), m.IP, operand2.String())
+					invalidOperation(ins, operand1, operand2)
+				}
+			case Float:
+				switch operand2.Type {
+				case Float:
+					result = floatOp(ins, operand1.Float(), operand2.Float())
+				case Int:
+					result = floatOp(ins, operand1.Float(), float64(operand2.Int))
+				case Nil:
+					result.Type = Bool
+					result.Int = FALSE
+					if ins.Type == Cmp && ins.CmpTyp == NoEqual {
+						result.Int = TRUE
+					}
+				default:
+					invalidOperation(ins, operand1, operand2)
 				}
 			case Time:
 				switch operand2.Type {
@@ -422,12 +779,25 @@ func (m *Machine) Run() {
 						operand1.Obj = nil
 						operand2.Obj = nil
 					default:
-						log.Panicln("unknown instruction", ins, m.IP)
+						invalidOperation(ins, operand1, operand2)
 					}
 				default:
-					log.Panicln("unknown instruction", ins, m.IP)
+					invalidOperation(ins, operand1, operand2)
 				}
-			case Obj:
+			case String:
+				switch operand2.Type {
+				case String:
+					result = stringOp(ins, operand1.Obj.(string), operand2.Obj.(string))
+				case Nil:
+					result.Type = Bool
+					result.Int = FALSE
+					if ins.Type == Cmp && ins.CmpTyp == NoEqual {
+						result.Int = TRUE
+					}
+				default:
+					invalidOperation(ins, operand1, operand2)
+				}
+			case Obj, Map:
 				switch operand2.Type {
 				case Nil:
 					switch ins.Type {
@@ -440,27 +810,49 @@ func (m *Machine) Run() {
 						case Equal:
 							result.Int = FALSE
 						default:
-							log.Panicln("unknown instruction", ins, m.IP)
+							invalidOperation(ins, operand1, operand2)
 						}
 					default:
-						log.Panicln("unknown instruction", ins, m.IP)
+						invalidOperation(ins, operand1, operand2)
+					}
+				case Obj, Map:
+					// objects are equal if they are the same one
+					if ins.Type != Cmp || ins.CmpTyp != Equal && ins.CmpTyp != NoEqual {
+						invalidOperation(ins, operand1, operand2)
+					}
+					same := operand1.Type == operand2.Type &&
+						reflect.ValueOf(operand1.Obj).Pointer() == reflect.ValueOf(operand2.Obj).Pointer()
+					result.Type = Bool
+					result.Int = FALSE
+					if same == (ins.CmpTyp == Equal) {
+						result.Int = TRUE
 					}
 				default:
-					log.Panicln("unknown instruction", ins, m.IP)
+					invalidOperation(ins, operand1, operand2)
 				}
 			case Bool:
 				switch operand2.Type {
 				case Bool:
 					result.Type = Bool
 					result.Int = FALSE
-					switch ins.Type {
-					case And:
+					switch {
+					case ins.Type == And:
 						if operand1.Int == TRUE && operand2.Int == TRUE {
 							result.Int = TRUE
 						}
+					case ins.Type == Cmp && ins.CmpTyp == Equal:
+						if operand1.Int == operand2.Int {
+							result.Int = TRUE
+						}
+					case ins.Type == Cmp && ins.CmpTyp == NoEqual:
+						if operand1.Int != operand2.Int {
+							result.Int = TRUE
+						}
 					default:
-						log.Panicln("unknown instruction", ins, m.IP)
+						invalidOperation(ins, operand1, operand2)
 					}
+				default:
+					result = compareNil(ins, operand1, operand2)
 				}
 			case Nil:
 				switch operand2.Type {
@@ -472,14 +864,13 @@ func (m *Machine) Run() {
 						result.Int = TRUE
 					case NoEqual:
 					default:
-						log.Panicln("unknown instruction",
-							ins.String(m.symbolTable, m.builtInSymbolTable),
-							m.IP,
-							operand2.String())
+						invalidOperation(ins, operand1, operand2)
 					}
+				default:
+					result = compareNil(ins, operand1, operand2)
 				}
 			default:
-				log.Panicln("unknown instruction", ins, m.IP)
+				invalidOperation(ins, operand1, operand2)
 			}
 			//log.Println(operand1, operand2, result)
 			m.SP++
@@ -497,13 +888,19 @@ func (m *Machine) Run() {
 		case LoadR:
 			m.SP++
 			m.stack[m.SP] = m.R[ins.Val]
+		case CheckR:
+			if m.R[0].Int != ins.Val {
+				panicf("assignment mismatch: %d variables but %d values", ins.Val, m.R[0].Int)
+			}
+		case Panic:
+			panicln(ins.Str)
 		case Load:
 			SP := ins.Val
 			if ins.Val < 0 {
 				SP = m.SP + ins.Val + 1 // -1 top
 			}
 			if SP > m.SP || SP < 0 {
-				log.Panicln("stack error", SP, m.SP)
+				panicln("stack error", SP, m.SP)
 			}
 			m.SP++
 			m.stack[m.SP] = m.stack[SP]
@@ -518,17 +915,22 @@ func (m *Machine) Run() {
 			case String:
 				index, ok := BuiltInFunctionsIndex["string."+ins.Str]
 				if ok == false {
-					log.Panicln("no find string." + ins.Str)
+					panicln("no find string." + ins.Str)
 				}
-				m.SP++
 				m.stack[m.SP] = Object{
 					Type: BFunc,
 					Int:  index,
 				}
 			case Obj, Lambda:
+				obj.checkField(ins.Str)
 				m.stack[m.SP] = *obj.loadObj(ins.Str)
+			case Error:
+				if ins.Str != "message" {
+					panicf("error has no field `%s`", ins.Str)
+				}
+				m.stack[m.SP] = Object{Type: String, Obj: obj.Obj}
 			default:
-				log.Panicln("unknown obj type", obj, m.SP)
+				panicln("unknown obj type", obj, m.SP)
 			}
 		case StoreO:
 			obj := m.stack[m.SP]
@@ -537,11 +939,12 @@ func (m *Machine) Run() {
 			m.SP--
 			switch obj.Type {
 			case Obj, Lambda:
+				obj.checkField(ins.Str)
 				obj.Store(ins.Str, ele)
 				obj.Obj = nil
 				ele.Obj = nil
 			default:
-				log.Panicln("unknown obj type", obj.Type)
+				panicln("unknown obj type", obj.Type)
 			}
 		case Call:
 			count := m.R[0].Int
@@ -556,12 +959,27 @@ func (m *Machine) Run() {
 		case CallO:
 			f := &m.stack[m.SP]
 			m.SP--
+			if f.Type == Method {
+				// object is the argument after arguments
+				method := f.Obj.(*boundMethod)
+				m.R[0].Int++
+				m.R[m.R[0].Int] = method.this
+				*f = method.function
+			}
 			switch f.Type {
-			case BFunc:
-				objects := m.CallFunc(f.Int, m.stack[m.SP])
+			case BFunc, GFunc:
+				// arguments are in registers,object is the last one
+				count := m.R[0].Int
+				var objects []Object
+				if f.Type == BFunc {
+					objects = m.CallFunc(f.Int, m.R[1:count+1]...)
+				} else {
+					objects = f.Obj.(Function).Call(m.R[1 : count+1]...)
+				}
+				for i := range m.R[1 : count+1] {
+					m.R[i+1].Obj = nil
+				}
 				f.Obj = nil
-				m.stack[m.SP].Obj = nil
-				m.SP--
 				m.SP-- //pop IP on the stack
 				m.R[0].Int = int64(len(objects))
 				for index, obj := range objects {
@@ -574,7 +992,7 @@ func (m *Machine) Run() {
 				m.closure = f.loadObj(closureLabel).Obj.(ObjectArray)
 				continue
 			default:
-				log.Panicln("no function type", f.Type, m.IP)
+				panicln("no function type", f.Type, m.IP)
 			}
 
 		case Label:
@@ -584,7 +1002,7 @@ func (m *Machine) Run() {
 			check := m.stack[m.SP]
 			m.SP--
 			if check.Type != Bool {
-				log.Panicln("expect bool value for check", m.IP, m.SP)
+				panicln("expect bool value for check", m.IP, m.SP)
 			}
 			if check.Int == TRUE {
 				if m.Debug {
@@ -610,6 +1028,65 @@ func (m *Machine) Run() {
 			m.stack[m.SP-1].Obj = append(m.stack[m.SP-1].Obj.(ObjectArray), m.stack[m.SP])
 			m.stack[m.SP].Obj = nil
 			m.SP--
+		case MakeMap:
+			m.SP++
+			m.stack[m.SP] = Object{
+				Type: Map,
+				Obj:  newMapObject(),
+			}
+		case Index:
+			key := m.stack[m.SP]
+			m.SP--
+			container := m.stack[m.SP]
+			switch container.Type {
+			case Map:
+				m.stack[m.SP] = container.Obj.(*mapObject).get(key)
+			case Array:
+				array := container.Obj.(ObjectArray)
+				m.stack[m.SP] = array[arrayIndex(array, key)]
+			default:
+				panicln("index no map or array", container.String(), m.IP)
+			}
+		case StoreIndex:
+			key := m.stack[m.SP]
+			container := m.stack[m.SP-1]
+			value := m.stack[m.SP-2]
+			m.stack[m.SP].Obj = nil
+			m.stack[m.SP-1].Obj = nil
+			m.stack[m.SP-2].Obj = nil
+			m.SP -= 3
+			switch container.Type {
+			case Map:
+				container.Obj.(*mapObject).store(key, value)
+			case Array:
+				array := container.Obj.(ObjectArray)
+				array[arrayIndex(array, key)] = value
+			default:
+				panicln("store index no map or array", container.String(), m.IP)
+			}
+		case Slice:
+			high := m.stack[m.SP]
+			low := m.stack[m.SP-1]
+			m.SP -= 2
+			m.stack[m.SP] = slice(m.stack[m.SP], low, high)
+			m.stack[m.SP+1].Obj = nil
+			m.stack[m.SP+2].Obj = nil
+		case Range:
+			m.stack[m.SP] = Object{
+				Type: Iterator,
+				Obj:  newIterator(m.stack[m.SP]),
+			}
+		case Next:
+			key, value, ok := m.stack[m.SP].Obj.(*iterator).next()
+			if ok {
+				m.stack[m.SP] = key
+				m.SP++
+				m.stack[m.SP] = value
+				m.SP++
+				m.stack[m.SP] = Object{Type: Bool, Int: TRUE}
+			} else {
+				m.stack[m.SP] = Object{Type: Bool, Int: FALSE}
+			}
 		case InitClosure:
 			for _, obj := range m.closure {
 				m.SP++
@@ -622,8 +1099,231 @@ func (m *Machine) Run() {
 	}
 }
 
+// floatOp eval arithmetic or compare instruction of float,
+// int operand is promoted to float before
+func floatOp(ins Instruction, operand1, operand2 float64) Object {
+	switch ins.Type {
+	case Add:
+		return NewFloat(operand1 + operand2)
+	case Sub:
+		return NewFloat(operand1 - operand2)
+	case Mul:
+		return NewFloat(operand1 * operand2)
+	case Div:
+		return NewFloat(operand1 / operand2)
+	case Mod:
+		return NewFloat(math.Mod(operand1, operand2))
+	case Cmp:
+		var b bool
+		switch ins.CmpTyp {
+		case Less:
+			b = operand1 < operand2
+		case LessEQ:
+			b = operand1 <= operand2
+		case Greater:
+			b = operand1 > operand2
+		case GreaterEQ:
+			b = operand1 >= operand2
+		case Equal:
+			b = operand1 == operand2
+		case NoEqual:
+			b = operand1 != operand2
+		}
+		if b {
+			return Object{Type: Bool, Int: TRUE}
+		}
+		return Object{Type: Bool, Int: FALSE}
+	}
+	panicf("invalid operation: float %s float", ins.operator())
+	return Object{}
+}
+
+// intOp eval instruction specialized for int operands
+func intOp(ins Instruction, operand1, operand2 int64) Object {
+	switch ins.Type {
+	case AddInt:
+		return Object{Type: Int, Int: operand1 + operand2}
+	case SubInt:
+		return Object{Type: Int, Int: operand1 - operand2}
+	case MulInt:
+		return Object{Type: Int, Int: operand1 * operand2}
+	case DivInt:
+		return Object{Type: Int, Int: operand1 / operand2}
+	case ModInt:
+		return Object{Type: Int, Int: operand1 % operand2}
+	}
+	var b bool
+	switch ins.CmpTyp {
+	case Less:
+		b = operand1 < operand2
+	case LessEQ:
+		b = operand1 <= operand2
+	case Greater:
+		b = operand1 > operand2
+	case GreaterEQ:
+		b = operand1 >= operand2
+	case Equal:
+		b = operand1 == operand2
+	case NoEqual:
+		b = operand1 != operand2
+	}
+	if b {
+		return Object{Type: Bool, Int: TRUE}
+	}
+	return Object{Type: Bool, Int: FALSE}
+}
+
+// specialized return int instruction of add,sub,mul,div,mod,cmp,typ self
+// for others
+func (typ InstType) specialized() InstType {
+	switch typ {
+	case Add:
+		return AddInt
+	case Sub:
+		return SubInt
+	case Mul:
+		return MulInt
+	case Div:
+		return DivInt
+	case Mod:
+		return ModInt
+	case Cmp:
+		return CmpInt
+	}
+	return typ
+}
+
+// generic return instruction of int instruction typ,typ self for others
+func (typ InstType) generic() InstType {
+	switch typ {
+	case AddInt:
+		return Add
+	case SubInt:
+		return Sub
+	case MulInt:
+		return Mul
+	case DivInt:
+		return Div
+	case ModInt:
+		return Mod
+	case CmpInt:
+		return Cmp
+	}
+	return typ
+}
+
+// stringOp eval concat or compare instruction of string
+func stringOp(ins Instruction, operand1, operand2 string) Object {
+	switch ins.Type {
+	case Add:
+		return Object{Type: String, Obj: operand1 + operand2}
+	case Cmp:
+		var b bool
+		switch ins.CmpTyp {
+		case Less:
+			b = operand1 < operand2
+		case LessEQ:
+			b = operand1 <= operand2
+		case Greater:
+			b = operand1 > operand2
+		case GreaterEQ:
+			b = operand1 >= operand2
+		case Equal:
+			b = operand1 == operand2
+		case NoEqual:
+			b = operand1 != operand2
+		}
+		if b {
+			return Object{Type: Bool, Int: TRUE}
+		}
+		return Object{Type: Bool, Int: FALSE}
+	}
+	panicf("invalid operation: string %s string", ins.operator())
+	return Object{}
+}
+
+// operator return operator of qp of arithmetic or compare instruction
+func (i Instruction) operator() string {
+	switch i.Type.generic() {
+	case Add:
+		return "+"
+	case Sub:
+		return "-"
+	case Mul:
+		return "*"
+	case Div:
+		return "/"
+	case Mod:
+		return "%"
+	case And:
+		return "&&"
+	case Cmp:
+		switch i.CmpTyp {
+		case Less:
+			return "<"
+		case LessEQ:
+			return "<="
+		case Greater:
+			return ">"
+		case GreaterEQ:
+			return ">="
+		case Equal:
+			return "=="
+		case NoEqual:
+			return "!="
+		}
+	}
+	return fmt.Sprint(i.Type)
+}
+
+// invalidOperation raise error of operator of ins no defined on types of
+// operands,`invalid operation: int + string`
+func invalidOperation(ins Instruction, operand1, operand2 *Object) {
+	panicf("invalid operation: %s %s %s", operand1.TypeName(), ins.operator(), operand2.TypeName())
+}
+
+// compareNil eval `==` and `!=` of nil and value of other type,values of
+// different types are no equal
+func compareNil(ins Instruction, operand1, operand2 *Object) Object {
+	if ins.Type != Cmp || ins.CmpTyp != Equal && ins.CmpTyp != NoEqual ||
+		operand1.Type != Nil && operand2.Type != Nil {
+		invalidOperation(ins, operand1, operand2)
+	}
+	if ins.CmpTyp == NoEqual {
+		return Object{Type: Bool, Int: TRUE}
+	}
+	return Object{Type: Bool, Int: FALSE}
+}
+
+// format print Obj as `User{age:1 name:bob}`,functions and hidden fields
+// are skipped,object printing already is shown as `User{...}`
+func (obj Object) format(printing map[uintptr]bool) string {
+	object, _ := obj.Obj.(objectMap)
+	name := obj.typeName()
+	pointer := reflect.ValueOf(object).Pointer()
+	if printing[pointer] {
+		return name + "{...}"
+	}
+	printing[pointer] = true
+	defer delete(printing, pointer)
+	var labels []string
+	for label := range obj.fields() {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	var items []string
+	for _, label := range labels {
+		if field := object[label]; field.Type == Obj {
+			items = append(items, label+":"+field.format(printing))
+		} else {
+			items = append(items, label+":"+field.String())
+		}
+	}
+	return name + "{" + strings.Join(items, " ") + "}"
+}
+
 func (m *Machine) CallFunc(funcIndex int64, object ...Object) []Object {
-	return BuiltInFunctions[funcIndex].Call(object...)
+	return m.functions[funcIndex].Call(object...)
 }
 
 func (m *Machine) String() string {
@@ -0,0 +1,49 @@
+package stackmachine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp/parser"
+	"gitlab.com/akzj/qp/types"
+)
+
+func TestIntInstructions(t *testing.T) {
+	p := parser.New(`
+func calc(a int, b int) {
+	println(a + b, a - b, a * b, a / b, a % b, a < b, a >= b, a == b)
+}
+func same(v) {
+	return v
+}
+calc(7, 2)
+calc(same(7.5), same(2))
+`)
+	statements := p.Parse()
+	if errs := types.Check(p, statements); len(errs) != 0 {
+		t.Fatal(errs[0])
+	}
+	for _, it := range p.GetVMContext().Objects() {
+		statements = append(statements, it)
+	}
+	gen := NewCodeGenerator().Gen(statements)
+	var listing bytes.Buffer
+	if err := gen.Disassemble(&listing); err != nil {
+		t.Fatal(err)
+	}
+	for _, expect := range []string{"add_int", "sub_int", "mul_int", "div_int", "mod_int", "cmp_int <", "cmp_int >="} {
+		if strings.Contains(listing.String(), expect) == false {
+			t.Fatalf("expect `%s` in listing\n%s", expect, listing.String())
+		}
+	}
+	var output bytes.Buffer
+	if err := NewMachine(gen, Options{Stdout: &output}).Run(); err != nil {
+		t.Fatal(err)
+	}
+	// float values of untyped code fall back to the generic instructions
+	expect := "9 5 14 3 1 false true false\n9.5 5.5 15 3.75 1.5 false true false\n"
+	if output.String() != expect {
+		t.Fatalf("unexpected output\n%s", output.String())
+	}
+}
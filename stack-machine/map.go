@@ -0,0 +1,88 @@
+package stackmachine
+
+import (
+	"strings"
+)
+
+type mapKey struct {
+	typ ValType
+	int int64
+	str string
+}
+
+// mapObject is the Obj of Map,keys keep insertion order
+type mapObject struct {
+	keys   []mapKey
+	values map[mapKey]*Object
+}
+
+func newMapObject() *mapObject {
+	return &mapObject{values: map[mapKey]*Object{}}
+}
+
+func toMapKey(key Object) mapKey {
+	switch key.Type {
+	case Int, Bool:
+		return mapKey{typ: key.Type, int: key.Int}
+	case String:
+		return mapKey{typ: String, str: key.Obj.(string)}
+	default:
+		panicln("invalid map key", key.String())
+	}
+	return mapKey{}
+}
+
+func (key mapKey) object() Object {
+	if key.typ == String {
+		return Object{Type: String, Obj: key.str}
+	}
+	return Object{Type: key.typ, Int: key.int}
+}
+
+// get return the value of key,Nil if key no exist
+func (m *mapObject) get(key Object) Object {
+	if object, ok := m.values[toMapKey(key)]; ok {
+		return *object
+	}
+	return Object{Type: Nil}
+}
+
+func (m *mapObject) store(key Object, value Object) {
+	k := toMapKey(key)
+	if object, ok := m.values[k]; ok {
+		*object = value
+		return
+	}
+	m.values[k] = &value
+	m.keys = append(m.keys, k)
+}
+
+func (m *mapObject) delete(key Object) {
+	k := toMapKey(key)
+	if _, ok := m.values[k]; ok == false {
+		return
+	}
+	delete(m.values, k)
+	for index, it := range m.keys {
+		if it == k {
+			m.keys = append(m.keys[:index], m.keys[index+1:]...)
+			break
+		}
+	}
+}
+
+func (m *mapObject) keyObjects() ObjectArray {
+	keys := make(ObjectArray, 0, len(m.keys))
+	for _, key := range m.keys {
+		keys = append(keys, key.object())
+	}
+	return keys
+}
+
+func (m *mapObject) String() string {
+	var items []string
+	for _, key := range m.keys {
+		items = append(items, key.object().String()+":"+m.values[key].String())
+	}
+	return "map[" + strings.Join(items, " ") + "]"
+}
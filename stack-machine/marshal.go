@@ -0,0 +1,403 @@
+package stackmachine
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"gitlab.com/akzj/qp/internal/marshal"
+)
+
+var objectType = reflect.TypeOf(Object{})
+
+// ToObject convert Go value to machine Object,it works as qp.ToValue
+//
+//	int,uint kinds    -> Int
+//	float kinds       -> Float
+//	bool              -> Bool
+//	string            -> String
+//	slice,array       -> Array
+//	map               -> Map,key must be string,int or bool kind
+//	struct            -> Obj,fields are named by `qp` tag or field name
+//	time.Time         -> Time
+//	time.Duration     -> Duration
+//	func              -> GFunc
+//	nil,nil pointer   -> Nil
+//
+// value which refers to itself returns error
+func ToObject(value interface{}) (Object, error) {
+	if value == nil {
+		return Object{Type: Nil}, nil
+	}
+	return toObject(reflect.ValueOf(value), marshal.Visiting{})
+}
+
+func toObject(value reflect.Value, visiting marshal.Visiting) (Object, error) {
+	if value.IsValid() == false {
+		return Object{Type: Nil}, nil
+	}
+	switch value.Type() {
+	case objectType:
+		return value.Interface().(Object), nil
+	case marshal.TimeType:
+		return Object{Type: Time, Obj: value.Interface().(time.Time)}, nil
+	case marshal.DurationType:
+		return Object{Type: Duration, Int: value.Int()}, nil
+	}
+	switch value.Kind() {
+	case reflect.Bool:
+		if value.Bool() {
+			return Object{Type: Bool, Int: TRUE}, nil
+		}
+		return Object{Type: Bool, Int: FALSE}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return Object{Type: Int, Int: value.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if value.Uint() > math.MaxInt64 {
+			return Object{}, fmt.Errorf("%d overflows int", value.Uint())
+		}
+		return Object{Type: Int, Int: int64(value.Uint())}, nil
+	case reflect.Float32, reflect.Float64:
+		return NewFloat(value.Float()), nil
+	case reflect.String:
+		return Object{Type: String, Obj: value.String()}, nil
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return Object{Type: Nil}, nil
+		}
+		if visiting.Enter(value) == false {
+			return Object{}, cycleError(value.Type())
+		}
+		defer visiting.Leave(value)
+		array := make(ObjectArray, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			element, err := toObject(value.Index(i), visiting)
+			if err != nil {
+				return Object{}, err
+			}
+			array = append(array, element)
+		}
+		return Object{Type: Array, Obj: array}, nil
+	case reflect.Map:
+		if value.IsNil() {
+			return Object{Type: Nil}, nil
+		}
+		if marshal.IsMapKey(value.Type().Key()) == false {
+			return Object{}, fmt.Errorf("invalid map key type `%s`", value.Type().Key())
+		}
+		if visiting.Enter(value) == false {
+			return Object{}, cycleError(value.Type())
+		}
+		defer visiting.Leave(value)
+		object := newMapObject()
+		for _, key := range marshal.SortedKeys(value) {
+			mapKey, err := toObject(key, visiting)
+			if err != nil {
+				return Object{}, err
+			}
+			element, err := toObject(value.MapIndex(key), visiting)
+			if err != nil {
+				return Object{}, err
+			}
+			object.store(mapKey, element)
+		}
+		return Object{Type: Map, Obj: object}, nil
+	case reflect.Struct:
+		object := Object{Type: Obj, Obj: make(objectMap)}
+		for _, field := range marshal.StructFields(value.Type()) {
+			element, err := toObject(value.FieldByIndex(field.Index), visiting)
+			if err != nil {
+				return Object{}, fmt.Errorf("field `%s`: %w", field.Name, err)
+			}
+			object.Store(field.Name, element)
+		}
+		return object, nil
+	case reflect.Ptr, reflect.Interface:
+		if value.IsNil() {
+			return Object{Type: Nil}, nil
+		}
+		if visiting.Enter(value) == false {
+			return Object{}, cycleError(value.Type())
+		}
+		defer visiting.Leave(value)
+		return toObject(value.Elem(), visiting)
+	case reflect.Func:
+		if value.IsNil() {
+			return Object{Type: Nil}, nil
+		}
+		return Object{Type: GFunc, Obj: wrapFunc(value)}, nil
+	}
+	return Object{}, fmt.Errorf("unsupported type `%s`", value.Type())
+}
+
+// wrapFunc wrap Go function as Function,a non nil error result panics
+func wrapFunc(fn reflect.Value) Function {
+	function := marshal.NewFunc(fn)
+	return Function{
+		Name: function.Name(),
+		Call: func(objects ...Object) []Object {
+			out, err := function.Call(len(objects), func(index int, dst reflect.Value) error {
+				return fromObject(objects[index], dst, marshal.Visiting{})
+			})
+			if err != nil {
+				panic(err)
+			}
+			var results []Object
+			for _, value := range out {
+				result, err := toObject(value, marshal.Visiting{})
+				if err != nil {
+					panic(err)
+				}
+				results = append(results, result)
+			}
+			return results
+		},
+	}
+}
+
+// FromObject store object to the Go value dst points to,it is the
+// reverse of ToObject.Decoding to interface{} makes int64,float64,bool,string,
+// []interface{},map[string]interface{},time.Time,time.Duration or nil,
+// a map with non string keys makes map[interface{}]interface{}.
+// object which refers to itself returns error
+func FromObject(object Object, dst interface{}) error {
+	ptr := reflect.ValueOf(dst)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() {
+		return fmt.Errorf("dst require non nil pointer")
+	}
+	return fromObject(object, ptr.Elem(), marshal.Visiting{})
+}
+
+func fromObject(object Object, dst reflect.Value, visiting marshal.Visiting) error {
+	if dst.Type() == objectType {
+		dst.Set(reflect.ValueOf(object))
+		return nil
+	}
+	if object.Type == Nil {
+		switch dst.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		return typeError(object, dst.Type())
+	}
+	switch dst.Type() {
+	case marshal.TimeType:
+		if object.Type != Time {
+			return typeError(object, dst.Type())
+		}
+		dst.Set(reflect.ValueOf(object.Obj.(time.Time)))
+		return nil
+	case marshal.DurationType:
+		if object.Type != Duration && object.Type != Int {
+			return typeError(object, dst.Type())
+		}
+		dst.SetInt(object.Int)
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return typeError(object, dst.Type())
+		}
+		val, err := toInterface(object, visiting)
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			dst.Set(reflect.Zero(dst.Type()))
+		} else {
+			dst.Set(reflect.ValueOf(val))
+		}
+	case reflect.Bool:
+		if object.Type != Bool {
+			return typeError(object, dst.Type())
+		}
+		dst.SetBool(object.Int == TRUE)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if object.Type != Int {
+			return typeError(object, dst.Type())
+		}
+		if dst.OverflowInt(object.Int) {
+			return fmt.Errorf("%d overflows `%s`", object.Int, dst.Type())
+		}
+		dst.SetInt(object.Int)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if object.Type != Int {
+			return typeError(object, dst.Type())
+		}
+		if object.Int < 0 || dst.OverflowUint(uint64(object.Int)) {
+			return fmt.Errorf("%d overflows `%s`", object.Int, dst.Type())
+		}
+		dst.SetUint(uint64(object.Int))
+	case reflect.Float32, reflect.Float64:
+		switch object.Type {
+		case Float:
+			dst.SetFloat(object.Float())
+		case Int:
+			dst.SetFloat(float64(object.Int))
+		default:
+			return typeError(object, dst.Type())
+		}
+	case reflect.String:
+		if object.Type != String {
+			return typeError(object, dst.Type())
+		}
+		dst.SetString(object.Obj.(string))
+	case reflect.Slice, reflect.Array:
+		if object.Type != Array {
+			return typeError(object, dst.Type())
+		}
+		array := object.Obj.(ObjectArray)
+		if visiting.Enter(reflect.ValueOf(array)) == false {
+			return cycleError(dst.Type())
+		}
+		defer visiting.Leave(reflect.ValueOf(array))
+		if dst.Kind() == reflect.Slice {
+			dst.Set(reflect.MakeSlice(dst.Type(), len(array), len(array)))
+		} else if dst.Len() != len(array) {
+			return fmt.Errorf("array length %d no match `%s`", len(array), dst.Type())
+		}
+		for index, element := range array {
+			if err := fromObject(element, dst.Index(index), visiting); err != nil {
+				return fmt.Errorf("index %d: %w", index, err)
+			}
+		}
+	case reflect.Map:
+		if visiting.Enter(reflect.ValueOf(object.Obj)) == false {
+			return cycleError(dst.Type())
+		}
+		defer visiting.Leave(reflect.ValueOf(object.Obj))
+		if object.Type == Map {
+			values := object.Obj.(*mapObject)
+			dst.Set(reflect.MakeMap(dst.Type()))
+			for _, key := range values.keys {
+				mapKey := reflect.New(dst.Type().Key()).Elem()
+				if err := fromObject(key.object(), mapKey, visiting); err != nil {
+					return fmt.Errorf("key %s: %w", key.object().String(), err)
+				}
+				element := reflect.New(dst.Type().Elem()).Elem()
+				if err := fromObject(*values.values[key], element, visiting); err != nil {
+					return fmt.Errorf("key %s: %w", key.object().String(), err)
+				}
+				dst.SetMapIndex(mapKey, element)
+			}
+			return nil
+		}
+		if object.Type != Obj || dst.Type().Key().Kind() != reflect.String {
+			return typeError(object, dst.Type())
+		}
+		dst.Set(reflect.MakeMap(dst.Type()))
+		for name, field := range object.fields() {
+			element := reflect.New(dst.Type().Elem()).Elem()
+			if err := fromObject(*field, element, visiting); err != nil {
+				return fmt.Errorf("field `%s`: %w", name, err)
+			}
+			dst.SetMapIndex(reflect.ValueOf(name).Convert(dst.Type().Key()), element)
+		}
+	case reflect.Struct:
+		if object.Type != Obj {
+			return typeError(object, dst.Type())
+		}
+		if visiting.Enter(reflect.ValueOf(object.Obj)) == false {
+			return cycleError(dst.Type())
+		}
+		defer visiting.Leave(reflect.ValueOf(object.Obj))
+		objects := object.fields()
+		for _, field := range marshal.StructFields(dst.Type()) {
+			if element, ok := objects[field.Name]; ok {
+				if err := fromObject(*element, dst.FieldByIndex(field.Index), visiting); err != nil {
+					return fmt.Errorf("field `%s`: %w", field.Name, err)
+				}
+			}
+		}
+	case reflect.Ptr:
+		element := reflect.New(dst.Type().Elem())
+		if err := fromObject(object, element.Elem(), visiting); err != nil {
+			return err
+		}
+		dst.Set(element)
+	default:
+		return typeError(object, dst.Type())
+	}
+	return nil
+}
+
+// fields return data fields of Obj,member functions and hidden fields are
+// skipped
+func (obj Object) fields() objectMap {
+	fields := objectMap{}
+	if obj.Obj == nil {
+		return fields
+	}
+	for name, field := range obj.Obj.(objectMap) {
+		switch field.Type {
+		case OFunc, BFunc, GFunc, Lambda, Method:
+			continue
+		}
+		if strings.HasPrefix(name, "__") {
+			continue
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+func toInterface(object Object, visiting marshal.Visiting) (interface{}, error) {
+	switch object.Type {
+	case Int:
+		return object.Int, nil
+	case Float:
+		return object.Float(), nil
+	case Bool:
+		return object.Int == TRUE, nil
+	case String:
+		return object.Obj.(string), nil
+	case Time:
+		return object.Obj.(time.Time), nil
+	case Duration:
+		return time.Duration(object.Int), nil
+	case Nil:
+		return nil, nil
+	case Array:
+		var values []interface{}
+		if err := fromObject(object, reflect.ValueOf(&values).Elem(), visiting); err != nil {
+			return nil, err
+		}
+		return values, nil
+	case Map:
+		for _, key := range object.Obj.(*mapObject).keys {
+			if key.typ != String {
+				var values map[interface{}]interface{}
+				if err := fromObject(object, reflect.ValueOf(&values).Elem(), visiting); err != nil {
+					return nil, err
+				}
+				return values, nil
+			}
+		}
+		var values map[string]interface{}
+		if err := fromObject(object, reflect.ValueOf(&values).Elem(), visiting); err != nil {
+			return nil, err
+		}
+		return values, nil
+	case Obj:
+		var values map[string]interface{}
+		if err := fromObject(object, reflect.ValueOf(&values).Elem(), visiting); err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("unsupported object `%s`", object.String())
+}
+
+// cycleError is returned when value converted to or from typ refers to
+// itself
+func cycleError(typ reflect.Type) error {
+	return fmt.Errorf("cycle in value of `%s`", typ)
+}
+
+func typeError(object Object, typ reflect.Type) error {
+	return fmt.Errorf("cannot store `%s` to `%s`", object.String(), typ)
+}
@@ -0,0 +1,96 @@
+package stackmachine
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalObject(t *testing.T) {
+	type item struct {
+		ID      int64
+		Name    string `qp:"name"`
+		Values  []int
+		Attrs   map[string]bool
+		Timeout time.Duration
+	}
+	in := item{
+		ID:      1,
+		Name:    "item",
+		Values:  []int{1, 2, 3},
+		Attrs:   map[string]bool{"ok": true},
+		Timeout: time.Minute,
+	}
+	object, err := ToObject(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.Type != Obj || object.loadObj("name").Obj != "item" {
+		t.Fatalf("%+v", object)
+	}
+	var out item
+	if err := FromObject(object, &out); err != nil {
+		t.Fatal(err)
+	}
+	if reflect.DeepEqual(in, out) == false {
+		t.Fatalf("%+v\n%+v", in, out)
+	}
+	if err := FromObject(Object{Type: String, Obj: "1"}, &out.ID); err == nil {
+		t.Fatal("expect type error")
+	}
+}
+
+func TestMarshalGoFunc(t *testing.T) {
+	object, err := ToObject(func(a, b int) (int, error) {
+		return a + b, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.Type != GFunc {
+		t.Fatalf("expect GFunc,found %d", object.Type)
+	}
+	results := object.Obj.(Function).Call(Object{Type: Int, Int: 1}, Object{Type: Int, Int: 2})
+	if len(results) != 1 || results[0].Int != 3 {
+		t.Fatalf("%+v", results)
+	}
+}
+
+func TestMarshalMap(t *testing.T) {
+	object, err := ToObject(map[int]string{2: "b", 1: "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if object.Type != Map || object.String() != "map[1:a 2:b]" {
+		t.Fatalf("%+v", object)
+	}
+	var generic interface{}
+	if err := FromObject(object, &generic); err != nil {
+		t.Fatal(err)
+	}
+	if generic.(map[interface{}]interface{})[int64(2)] != "b" {
+		t.Fatalf("%+v", generic)
+	}
+}
+
+func TestMarshalCycle(t *testing.T) {
+	type node struct {
+		Next *node `qp:"next"`
+	}
+	self := &node{}
+	self.Next = self
+	if _, err := ToObject(self); err == nil {
+		t.Fatal("expect cycle error")
+	}
+	object := Object{Type: Obj, Obj: make(objectMap)}
+	object.Store("next", object)
+	var generic interface{}
+	if err := FromObject(object, &generic); err == nil || strings.Contains(err.Error(), "cycle") == false {
+		t.Fatal(err)
+	}
+	var out node
+	if err := FromObject(object, &out); err == nil || strings.Contains(err.Error(), "cycle") == false {
+		t.Fatal(err)
+	}
+}
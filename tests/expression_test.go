@@ -11,10 +11,12 @@ func run(script string, printIns bool) {
 	GC := stackmachine.NewCodeGenerator()
 	GC.Gen(statements)
 	m := stackmachine.NewMachine(GC, stackmachine.Options{Debug: printIns})
-	m.Run()
+	if err := m.Run(); err != nil {
+		panic(err)
+	}
 }
 
-func ExampleForExpression_i_j() {
+func Example_forExpressionIJ() {
 	run(`
 	for i := 0;i < 3;i++{
 		for j := 0;j < 3;j++{
@@ -34,7 +36,7 @@ func ExampleForExpression_i_j() {
 	//2 2
 }
 
-func ExampleForExpression_if() {
+func Example_forExpressionIf() {
 	run(`
 	for i := 0;i < 3;i++{
 		for j := 0;j < 3;j++{
@@ -50,7 +52,7 @@ func ExampleForExpression_if() {
 	//2 0
 }
 
-func ExampleForExpression_3() {
+func Example_forExpression3() {
 	run(`
 	a := 0
 	for i := 0;i < 3;i++{
@@ -66,7 +68,7 @@ func ExampleForExpression_3() {
 	//27
 }
 
-func ExampleIfExpression() {
+func Example_ifExpression() {
 	run(`
 	if 0 < 1{
 		println("hello")
@@ -76,7 +78,7 @@ func ExampleIfExpression() {
 	//hello
 }
 
-func ExampleIfVarCheck() {
+func Example_ifVarCheck() {
 	run(`
 	a := 1
 	if (a +1) * 0 <= 2{
@@ -86,3 +88,114 @@ func ExampleIfVarCheck() {
 	//Output:
 	//2
 }
+
+func Example_mapLiteral() {
+	run(`
+	var m = {"a": 1, "b": 2}
+	m["c"] = m["a"] + m["b"]
+	m["a"]++
+	delete(m, "b")
+	println(m, len(m), m["b"])
+	`, false)
+	//Output:
+	//map[a:2 c:3] 2 nil
+}
+
+func Example_float() {
+	run(`
+	var r = 2
+	var area = 3.14 * r * r
+	println(area, 7 / 2, 7 / 2.0, 1e-3, int(2.9), float(1), 1 < 1.5)
+	`, false)
+	//Output:
+	//12.56 3 3.5 0.001 2 1 true
+}
+
+func Example_forRange() {
+	run(`
+	var sum = 0
+	for i, v := range [1, 2, 3, 4, 5] {
+		if i == 0 {
+			continue
+		}
+		if v > 4 {
+			break
+		}
+		sum = sum + v
+	}
+	for k, v := range {"a": 1, "b": 2} {
+		if k == "b" {
+			break
+		}
+		println(k, v)
+	}
+	println(sum)
+	`, false)
+	//Output:
+	//a 1
+	//9
+}
+
+func Example_labeledLoop() {
+	run(`
+	outer: for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			var k = i + j
+			if j == 1 {
+				continue outer
+			}
+			if i == 2 {
+				break outer
+			}
+			println(i, k)
+		}
+	}
+	rows: for _, row := range [[1, 2], [3, 4], [5, 6]] {
+		for _, v := range row {
+			if v == 4 {
+				continue rows
+			}
+			if v == 5 {
+				break rows
+			}
+			println(v)
+		}
+	}
+	var after = 10
+	println(after)
+	`, false)
+	//Output:
+	//0 0
+	//1 1
+	//1
+	//2
+	//3
+	//10
+}
+
+func Example_tryCatch() {
+	run(`
+	var arr = [1, 2, 3]
+	for i := 1; i < 5; i++ {
+		try {
+			var x = arr[i]
+			if i == 2 {
+				panic("stop at", i)
+			}
+			println(x)
+		} catch e {
+			println("caught", e.message)
+			if i == 3 {
+				break
+			}
+		}
+	}
+	var after = 10
+	println(after)
+	`, false)
+	//Output:
+	//2
+	//caught stop at 2
+	//caught index 3 out of range [0:3]
+	//10
+}
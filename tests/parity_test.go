@@ -0,0 +1,145 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp"
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/parser"
+	stackmachine "gitlab.com/akzj/qp/stack-machine"
+	"gitlab.com/akzj/qp/types"
+)
+
+// libPath is the search path of modules imported by scripts
+var libPath = []string{"../lib"}
+
+func runTree(script string) (output string, err error) {
+	var buffer bytes.Buffer
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+		output = buffer.String()
+	}()
+	_, err = qp.New(qp.Options{Stdout: &buffer, Path: libPath}).Eval(script)
+	return
+}
+
+func runVM(script string) (output string, err error) {
+	var buffer bytes.Buffer
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+		output = buffer.String()
+	}()
+	p := parser.New(script).SetImporter(parser.NewImporter(libPath))
+	statements := p.Parse()
+	if errs := types.Check(p, statements); len(errs) != 0 {
+		return "", errs[0]
+	}
+	for _, object := range p.GetVMContext().Objects() {
+		statements = append(statements, object)
+	}
+	generator := stackmachine.NewCodeGenerator().Gen(statements)
+	err = stackmachine.NewMachine(generator, stackmachine.Options{Stdout: &buffer}).Run()
+	return
+}
+
+func TestEngineParity(t *testing.T) {
+	var files []string
+	for _, pattern := range []string{"*.qp", "../lib/*.qp"} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		t.Fatal("no script found")
+	}
+	for _, file := range files {
+		file := file
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			treeOutput, err := runTree(string(data))
+			if err != nil {
+				t.Fatalf("tree engine failed %s", err.Error())
+			}
+			vmOutput, err := runVM(string(data))
+			if err != nil {
+				t.Fatalf("vm engine failed %s", err.Error())
+			}
+			if treeOutput != vmOutput {
+				t.Fatalf("output mismatch\ntree:\n%s\nvm:\n%s", treeOutput, vmOutput)
+			}
+		})
+	}
+}
+
+// TestEngineParityErrors check both engines fail scripts with the same
+// error
+func TestEngineParityErrors(t *testing.T) {
+	for _, test := range []struct {
+		script string
+		expect string
+	}{
+		{"zz = 1\n", "1:1: undefined `zz`"},
+		{"var g = 1\nfunc f() {\n\treturn g\n}\nprintln(f())\n", "3:9: undefined `g`"},
+		{"for i := 0; i < 100000; i++ {\n\tzz = i\n}\n", "2:2: undefined `zz`"},
+		{"var a = 1\nzz++\n", "2:1: undefined `zz`"},
+		{"println(nofunc(1))\n", "1:15: undefined function `nofunc`"},
+	} {
+		_, treeErr := runTree(test.script)
+		_, vmErr := runVM(test.script)
+		for engine, err := range map[string]error{"tree": treeErr, "vm": vmErr} {
+			if err == nil || strings.TrimPrefix(err.Error(), "runtime error: ") != test.expect {
+				t.Errorf("%s engine: expect error `%s` of %q,got %v", engine, test.expect, test.script, err)
+			}
+		}
+	}
+}
+
+// TestConstructorArity run constructors without the type checker,which
+// reports the arity statically,both engines raise the same error
+func TestConstructorArity(t *testing.T) {
+	for _, test := range []struct {
+		call   string
+		expect string
+	}{
+		{"C()", "not enough arguments in call to C.init"},
+		{"C(1, 2)", "too many arguments in call to C.init"},
+	} {
+		script := "type C {\n\tn\n}\nfunc C.init(n) {\n\tthis.n = n\n}\nvar c = " + test.call + "\n"
+		treeMessage := func() (message string) {
+			defer func() {
+				if err, ok := recover().(*ast.Error); ok {
+					message = err.Message
+				}
+			}()
+			parser.New(script).Parse().Invoke()
+			return ""
+		}()
+		p := parser.New(script)
+		statements := p.Parse()
+		for _, object := range p.GetVMContext().Objects() {
+			statements = append(statements, object)
+		}
+		var vmMessage string
+		err := stackmachine.NewMachine(stackmachine.NewCodeGenerator().Gen(statements), stackmachine.Options{}).Run()
+		if err, ok := err.(*stackmachine.RuntimeError); ok {
+			vmMessage = err.Message
+		}
+		if treeMessage != test.expect || vmMessage != test.expect {
+			t.Errorf("%s: expect `%s`,tree `%s`,vm `%s`", test.call, test.expect, treeMessage, vmMessage)
+		}
+	}
+}
@@ -0,0 +1,830 @@
+package types
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/parser"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+// scope is types of variables of block,function body or lambda
+type scope struct {
+	parent   *scope
+	isolated bool // body of named function,variables of outer scopes are invisible
+	vars     map[string]Type
+	// objects is strict type of variable without annotation initialized by
+	// its literal or constructor,only fields stored to it are checked
+	objects map[string]Type
+}
+
+func (s *scope) lookup(name string) (Type, bool) {
+	for ; s != nil; s = s.parent {
+		if t, ok := s.vars[name]; ok {
+			return t, true
+		}
+		if s.isolated {
+			break
+		}
+	}
+	return Any, false
+}
+
+// object return strict type of variable name initialized by object,Any
+// when unknown
+func (s *scope) object(name string) Type {
+	for ; s != nil; s = s.parent {
+		if _, ok := s.vars[name]; ok {
+			if t, ok := s.objects[name]; ok {
+				return t
+			}
+			return Any
+		}
+		if s.isolated {
+			break
+		}
+	}
+	return Any
+}
+
+// forget make object type of variable name unknown after it is assigned
+func (s *scope) forget(name string) {
+	for ; s != nil; s = s.parent {
+		if _, ok := s.vars[name]; ok {
+			delete(s.objects, name)
+			return
+		}
+		if s.isolated {
+			return
+		}
+	}
+}
+
+type checker struct {
+	p       *parser.Parser
+	vm      *runtime.VMRuntime     // vm of script or module checked
+	modules map[string]*ast.Module // modules imported by script checked,key is the name
+	checked map[*ast.Module]bool
+	scope   *scope
+	returns []Type         // return types of function checked,nil when no annotated
+	pos     lexer.Position // position of statement checked,for expressions without position
+	errs    []error
+}
+
+// Check check statements parsed by p,functions,methods and types declared
+// and modules imported.Statements are rewritten with the types found: int
+// literal used as float is converted to float,BinaryOpExpression of int
+// operands is marked Int for the code generator
+func Check(p *parser.Parser, statements ast.Expressions) []error {
+	c := &checker{p: p, checked: map[*ast.Module]bool{}}
+	c.unit(p.GetVMContext(), statements)
+	sort.SliceStable(c.errs, func(i, j int) bool {
+		left, right := c.errs[i].(*parser.Error), c.errs[j].(*parser.Error)
+		if left.File != right.File {
+			return left.File == "" || left.File < right.File
+		}
+		if left.Line != right.Line {
+			return left.Line < right.Line
+		}
+		return left.Column < right.Column
+	})
+	return c.errs
+}
+
+func (c *checker) errorf(pos lexer.Position, format string, args ...interface{}) {
+	if pos.Line == 0 {
+		pos = c.pos
+	}
+	c.errs = append(c.errs, c.p.PositionError(pos, fmt.Sprintf(format, args...)))
+}
+
+// unit check statements of script or module with declarations in vm
+func (c *checker) unit(vm *runtime.VMRuntime, statements ast.Expressions) {
+	vm, c.vm = c.vm, vm
+	modules := c.modules
+	c.modules = map[string]*ast.Module{}
+	defer func() {
+		c.vm, c.modules = vm, modules
+	}()
+	for _, statement := range statements {
+		if statement, ok := statement.(ast.ImportStatement); ok {
+			c.modules[path.Base(statement.Module.Name)] = statement.Module
+		}
+	}
+	for _, module := range c.modules {
+		if c.checked[module] == false {
+			c.checked[module] = true
+			c.unit(module.VM, module.Statements)
+		}
+	}
+
+	c.openScope(true)
+	c.block(statements)
+	c.closeScope()
+
+	objects := c.vm.Objects()
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Label < objects[j].Label
+	})
+	for _, object := range objects {
+		switch object := object.Pointer.(type) {
+		case *ast.FuncExpression:
+			c.function(object, true)
+		case *ast.TypeObject:
+			for index, template := range object.TypeObjectPropTemplates {
+				if template.Type != "" {
+					t := c.resolveType(template.Type, object.Position)
+					object.TypeObjectPropTemplates[index].Exp = c.convert(template.Exp, t, object.Position,
+						"default value of `"+template.Name+"`")
+				}
+			}
+			var names []string
+			for name := range object.GetObjects() {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				if method, ok := object.GetObject(name).Pointer.(*ast.FuncExpression); ok {
+					c.function(method, true)
+				}
+			}
+		case *ast.InterfaceObject:
+			for _, method := range object.Methods {
+				for _, name := range append(append([]string{}, method.ParameterTypes...), method.Returns...) {
+					if name != "" {
+						c.resolveType(name, method.Position)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (c *checker) openScope(isolated bool) {
+	c.scope = &scope{parent: c.scope, isolated: isolated, vars: map[string]Type{}, objects: map[string]Type{}}
+}
+
+func (c *checker) closeScope() {
+	c.scope = c.scope.parent
+}
+
+func (c *checker) declare(name string, t Type) {
+	c.scope.vars[name] = t
+	delete(c.scope.objects, name)
+}
+
+// declareObject declare variable name without annotation initialized by
+// exp of type t,the type is kept for fields stored when exp is literal or
+// constructor of strict type,`var p = Point{}`.The variable is Any
+func (c *checker) declareObject(name string, exp runtime.Invokable, t Type) {
+	c.declare(name, Any)
+	if _, ok := exp.(ast.ObjectInitStatement); ok {
+		if object := c.typeObject(t); object != nil && object.Strict {
+			c.scope.objects[name] = t
+		}
+	}
+}
+
+// declaration return the declaration of user type or interface t,nil when
+// t is no declared
+func (c *checker) declaration(t Type) runtime.Invokable {
+	name := string(t)
+	vm := c.vm
+	if index := strings.Index(name, "."); index != -1 {
+		module, ok := c.modules[name[:index]]
+		if ok == false {
+			return nil
+		}
+		name, vm = name[index+1:], module.VM
+	}
+	if object := vm.GetTypeObject(name); object != nil {
+		return object.Pointer
+	}
+	return nil
+}
+
+// typeObject return the declaration of user type t,nil when t is no user
+// type
+func (c *checker) typeObject(t Type) *ast.TypeObject {
+	object, _ := c.declaration(t).(*ast.TypeObject)
+	return object
+}
+
+// interfaceObject return the declaration of interface t,nil when t is no
+// interface
+func (c *checker) interfaceObject(t Type) *ast.InterfaceObject {
+	object, _ := c.declaration(t).(*ast.InterfaceObject)
+	return object
+}
+
+// typeOf return Type of annotation,Any for unknown type
+func (c *checker) typeOf(name string) Type {
+	t := annotation(name)
+	if basics[t] || c.declaration(t) != nil {
+		return t
+	}
+	return Any
+}
+
+// resolveType return Type of annotation,unknown type is reported
+func (c *checker) resolveType(name string, pos lexer.Position) Type {
+	t := c.typeOf(name)
+	if t == Any && name != string(Any) {
+		c.errorf(pos, "undefined type `%s`", name)
+	}
+	return t
+}
+
+// fieldType return type of field or method of user type t,field no
+// declared by strict type is reported at pos
+func (c *checker) fieldType(t Type, name string, pos lexer.Position) Type {
+	if object := c.interfaceObject(t); object != nil {
+		if object.Method(name) == nil {
+			c.errorf(pos, "type %s has no field or method `%s`", t, name)
+		}
+		return Func
+	}
+	object := c.typeObject(t)
+	if object == nil {
+		return Any
+	}
+	for _, template := range object.TypeObjectPropTemplates {
+		if template.Name == name {
+			return c.typeOf(template.Type)
+		}
+	}
+	if object.GetObject(name) != nil {
+		return Func
+	}
+	if object.Strict {
+		c.errorf(pos, "type %s has no field or method `%s`", t, name)
+	}
+	return Any
+}
+
+// varType return type of variable or Func for function name
+func (c *checker) varType(name string) Type {
+	if t, ok := c.scope.lookup(name); ok {
+		return t
+	}
+	if _, ok := c.vm.GlobalFunctions[name]; ok {
+		return Func
+	}
+	if _, ok := c.vm.Functions[name]; ok {
+		return Func
+	}
+	return Any
+}
+
+// function check body of function,named function can not see variables
+// out of it
+func (c *checker) function(f *ast.FuncExpression, named bool) {
+	returns := c.returns
+	c.returns = nil
+	c.pos = f.Position
+	for _, name := range f.Returns {
+		c.returns = append(c.returns, c.resolveType(name, f.Position))
+	}
+	c.openScope(named)
+	for index, parameter := range f.Parameters {
+		t := Any
+		if index < len(f.ParameterTypes) && f.ParameterTypes[index] != "" {
+			t = c.resolveType(f.ParameterTypes[index], f.Position)
+		} else if index == 0 && len(f.Labels) != 0 {
+			t = Type(f.Labels[0]) // this
+		}
+		c.declare(parameter, t)
+	}
+	c.block(f.Statements)
+	c.closeScope()
+	c.returns = returns
+}
+
+// parameters return types of parameters of function,`this` is excluded
+func (c *checker) parameters(f *ast.FuncExpression) []Type {
+	var types []Type
+	for index := range f.Parameters {
+		if index == 0 && len(f.Labels) != 0 {
+			continue
+		}
+		t := Any
+		if index < len(f.ParameterTypes) {
+			t = c.typeOf(f.ParameterTypes[index])
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+// results return types of return values of function,nil when no annotated
+func (c *checker) results(f *ast.FuncExpression) []Type {
+	var types []Type
+	for _, name := range f.Returns {
+		types = append(types, c.typeOf(name))
+	}
+	return types
+}
+
+func (c *checker) block(statements ast.Expressions) {
+	for index := range statements {
+		if pos := ast.PositionOf(statements[index]); pos.Line != 0 {
+			c.pos = pos
+		}
+		statements[index], _ = c.expr(statements[index])
+	}
+}
+
+// scoped check statements in new block scope
+func (c *checker) scoped(statements ast.Expressions) {
+	c.openScope(false)
+	c.block(statements)
+	c.closeScope()
+}
+
+func (c *checker) values(exps ast.Expressions) []Type {
+	var types []Type
+	for index := range exps {
+		var t Type
+		exps[index], t = c.expr(exps[index])
+		types = append(types, t)
+	}
+	return types
+}
+
+// convert check value of exp can be assigned to type to,int literal is
+// converted to float
+func (c *checker) convert(exp runtime.Invokable, to Type, pos lexer.Position, context string) runtime.Invokable {
+	exp, from := c.expr(exp)
+	if value, ok := exp.(ast.Int); ok && to == Float {
+		return ast.Float(value)
+	}
+	if ok, reason := c.assignable(from, to); ok == false {
+		if expPos := ast.PositionOf(exp); expPos.Line != 0 {
+			pos = expPos
+		}
+		if reason != "" {
+			reason = ": " + reason
+		}
+		c.errorf(pos, "cannot use %s (type %s) as %s in %s%s", exp.String(), from, to, context, reason)
+	}
+	return exp
+}
+
+// condition check exp of if or for statement is bool
+func (c *checker) condition(exp runtime.Invokable, pos lexer.Position, statement string) runtime.Invokable {
+	exp, t := c.expr(exp)
+	if t != Any && t != Bool {
+		if expPos := ast.PositionOf(exp); expPos.Line != 0 {
+			pos = expPos
+		}
+		c.errorf(pos, "non-bool %s (type %s) used as %s condition", exp.String(), t, statement)
+	}
+	return exp
+}
+
+// expr check expression or statement,the rewritten expression and its
+// type are returned
+func (c *checker) expr(exp runtime.Invokable) (runtime.Invokable, Type) {
+	var t Type
+	switch exp := exp.(type) {
+	case ast.Int:
+		return exp, Int
+	case ast.Float:
+		return exp, Float
+	case ast.String:
+		return exp, String
+	case ast.Bool:
+		return exp, Bool
+	case ast.NilObject:
+		return exp, Nil
+	case ast.GetVarStatement:
+		return exp, c.varType(exp.Label)
+	case ast.ParenthesisExpression:
+		exp.Exp, t = c.expr(exp.Exp)
+		return exp, t
+	case ast.BinaryOpExpression:
+		return c.binary(exp)
+	case ast.NoStatement:
+		exp.Exp, t = c.expr(exp.Exp)
+		if t != Any && t != Bool {
+			c.errorf(ast.PositionOf(exp), "invalid operation: operator ! not defined on %s (type %s)", exp.Exp.String(), t)
+		}
+		return exp, Bool
+	case ast.PeriodStatement:
+		exp.Exp, t = c.expr(exp.Exp)
+		return exp, c.fieldType(t, exp.Val, ast.PositionOf(exp.Exp))
+	case ast.IndexExpression:
+		exp.Exp, t = c.expr(exp.Exp)
+		if t == Array {
+			exp.Index = c.index(exp.Index, exp.Position)
+		} else {
+			exp.Index, _ = c.expr(exp.Index)
+		}
+		return exp, Any
+	case ast.SliceExpression:
+		exp.Exp, t = c.expr(exp.Exp)
+		if t != Any && t != Array {
+			c.errorf(exp.Position, "cannot slice %s (type %s)", exp.Exp.String(), t)
+		}
+		if exp.Low != nil {
+			exp.Low = c.index(exp.Low, exp.Position)
+		}
+		if exp.High != nil {
+			exp.High = c.index(exp.High, exp.Position)
+		}
+		return exp, Array
+	case *ast.MakeArrayStatement:
+		c.values(exp.Inits)
+		return exp, Array
+	case *ast.MakeMapStatement:
+		c.values(exp.Keys)
+		c.values(exp.Values)
+		return exp, Map
+	case ast.ObjectInitStatement:
+		return c.objectInit(exp)
+	case ast.IsExpression:
+		exp.Exp, _ = c.expr(exp.Exp)
+		for _, typ := range exp.Types {
+			if typ.Module == nil && ast.BasicTypes[typ.Label] {
+				continue
+			}
+			c.resolveType(typ.String(), exp.Position)
+		}
+		return exp, Bool
+	case ast.TupleExpression:
+		c.values(exp.Exps)
+		return exp, Any
+	case *ast.CallStatement:
+		if results := c.call(exp); len(results) != 0 {
+			return exp, results[0]
+		}
+		return exp, Any
+	case *ast.FuncExpression:
+		c.function(exp, false)
+		return exp, Func
+	case ast.ModuleMember:
+		if _, ok := exp.Module.VM.GlobalFunctions[exp.Label]; ok {
+			return exp, Func
+		}
+		return exp, Any
+	case ast.IncFieldStatement:
+		exp.Exp, t = c.expr(exp.Exp)
+		if t != Any && t.numeric() == false {
+			c.errorf(exp.Position, "invalid operation: %s++ (non-numeric type %s)", exp.Exp.String(), t)
+		}
+		return exp, Any
+	case ast.VarStatement:
+		c.declare(exp.Label, Any)
+	case ast.VarAssignStatement:
+		if exp.Type != "" {
+			t = c.resolveType(exp.Type, exp.Position)
+			exp.Exp = c.convert(exp.Exp, t, exp.Position, "variable declaration")
+			c.declare(exp.Name, t)
+		} else {
+			exp.Exp, t = c.expr(exp.Exp)
+			c.declareObject(exp.Name, exp.Exp, t)
+		}
+		return exp, Any
+	case ast.VarInitExpression:
+		exp.Exp, t = c.expr(exp.Exp)
+		c.declareObject(exp.Name, exp.Exp, t)
+		return exp, Any
+	case ast.AssignStatement:
+		return c.assign(exp), Any
+	case ast.MultiAssignStatement:
+		return c.multiAssign(exp), Any
+	case ast.ReturnStatement:
+		return c.returnStatement(exp), Any
+	case ast.IfExpression:
+		return c.ifStatement(exp), Any
+	case ast.ForExpression:
+		return c.loop(exp), Any
+	case *ast.ForExpression:
+		*exp = c.loop(*exp)
+		return exp, Any
+	case ast.TryStatement:
+		c.scoped(exp.Statements)
+		c.openScope(false)
+		c.declare(exp.Label, Any)
+		c.block(exp.Catch)
+		c.closeScope()
+		return exp, Any
+	}
+	return exp, Any
+}
+
+// index check index of array or bound of slice is int
+func (c *checker) index(exp runtime.Invokable, pos lexer.Position) runtime.Invokable {
+	exp, t := c.expr(exp)
+	if t != Any && t != Int {
+		c.errorf(pos, "invalid argument: index %s (type %s) must be integer", exp.String(), t)
+	}
+	return exp
+}
+
+func (c *checker) binary(exp ast.BinaryOpExpression) (runtime.Invokable, Type) {
+	var left, right Type
+	exp.Left, left = c.expr(exp.Left)
+	exp.Right, right = c.expr(exp.Right)
+	result, ok := binary(exp.OP, left, right)
+	if ok == false {
+		if left == right {
+			c.errorf(ast.PositionOf(exp), "invalid operation: operator %s not defined on %s (type %s)",
+				exp.OP.String(), exp.Left.String(), left)
+		} else {
+			c.errorf(ast.PositionOf(exp), "invalid operation: %s (mismatched types %s and %s)",
+				exp.String(), left, right)
+		}
+	}
+	exp.Int = left == Int && right == Int && exp.OP != lexer.AndType && exp.OP != lexer.OrType
+	return exp, result
+}
+
+// objectInit check values of typed fields of `Type{field: value}`
+func (c *checker) objectInit(exp ast.ObjectInitStatement) (runtime.Invokable, Type) {
+	t := Any
+	switch typ := exp.Exp.(type) {
+	case ast.GetVarStatement:
+		if c.vm.GetTypeObject(typ.Label) != nil {
+			t = Type(typ.Label)
+		}
+		if c.interfaceObject(t) != nil {
+			c.errorf(typ.Position, "cannot create value of interface type %s", t)
+			t = Any
+		}
+	case ast.ModuleMember:
+		if typ.Module.VM.GetTypeObject(typ.Label) != nil {
+			t = Type(typ.String())
+		}
+	}
+	pos := ast.PositionOf(exp.Exp)
+	for index, template := range exp.PropTemplates {
+		exp.PropTemplates[index].Exp = c.convert(template.Exp, c.fieldType(t, template.Name, pos), pos,
+			"field value of `"+template.Name+"`")
+	}
+	c.constructor(exp, t, pos)
+	return exp, t
+}
+
+// constructor check arguments of `Type(arguments)` passed to init
+func (c *checker) constructor(exp ast.ObjectInitStatement, t Type, pos lexer.Position) {
+	object := c.typeObject(t)
+	if object == nil {
+		c.values(exp.Arguments)
+		return
+	}
+	method := object.Constructor()
+	if method == nil {
+		if len(exp.Arguments) != 0 {
+			c.errorf(pos, "type %s has no method `%s`", t, ast.ConstructorName)
+		}
+		c.values(exp.Arguments)
+		return
+	}
+	parameters := c.parameters(method)
+	for index, argument := range exp.Arguments {
+		if index < len(parameters) {
+			exp.Arguments[index] = c.convert(argument, parameters[index], argumentPosition(exp.ArgumentPositions, index, pos),
+				"argument to "+string(t)+"."+ast.ConstructorName)
+		} else {
+			exp.Arguments[index], _ = c.expr(argument)
+		}
+	}
+	if exp.Constructor {
+		if msg := ast.ConstructorArity(exp, method); msg != "" {
+			c.errorf(argumentPosition(exp.ArgumentPositions, len(parameters), pos), "%s", msg)
+		}
+	}
+}
+
+// argumentPosition return position of argument index,pos of call when it
+// is unknown
+func argumentPosition(positions []lexer.Position, index int, pos lexer.Position) lexer.Position {
+	if index < len(positions) && positions[index].Line != 0 {
+		return positions[index]
+	}
+	return pos
+}
+
+// call check arguments of call,types of return values of function called
+// are returned,nil when unknown
+func (c *checker) call(call *ast.CallStatement) []Type {
+	var function *ast.FuncExpression
+	var name string
+	switch callee := call.Function.(type) {
+	case ast.GetVarStatement:
+		name = callee.Label
+		if _, ok := c.scope.lookup(name); ok {
+			break
+		}
+		if object, ok := c.vm.GlobalFunctions[name]; ok {
+			function = object.Pointer.(*ast.FuncExpression)
+		} else if t, ok := builtinResults[name]; ok {
+			c.values(call.Arguments)
+			return []Type{t}
+		}
+	case ast.ModuleMember:
+		name = callee.String()
+		if object, ok := callee.Module.VM.GlobalFunctions[callee.Label]; ok {
+			function = object.Pointer.(*ast.FuncExpression)
+		}
+	case ast.PeriodStatement:
+		var receiver Type
+		callee.Exp, receiver = c.expr(callee.Exp)
+		call.Function = callee
+		name = callee.Val
+		if object := c.interfaceObject(receiver); object != nil {
+			if function = object.Method(callee.Val); function == nil {
+				c.fieldType(receiver, callee.Val, ast.PositionOf(callee.Exp))
+			}
+		} else if object := c.typeObject(receiver); object != nil {
+			if method := object.GetObject(callee.Val); method != nil {
+				function, _ = method.Pointer.(*ast.FuncExpression)
+			} else {
+				c.fieldType(receiver, callee.Val, ast.PositionOf(callee.Exp))
+			}
+		}
+	default:
+		call.Function, _ = c.expr(call.Function)
+		function, _ = call.Function.(*ast.FuncExpression)
+		name = "func"
+	}
+	if function == nil {
+		c.values(call.Arguments)
+		return nil
+	}
+	parameters := c.parameters(function)
+	for index, argument := range call.Arguments {
+		if index < len(parameters) {
+			call.Arguments[index] = c.convert(argument, parameters[index], argumentPosition(call.ArgumentPositions, index, call.Position),
+				"argument to "+name)
+		} else {
+			call.Arguments[index], _ = c.expr(argument)
+		}
+	}
+	return c.results(function)
+}
+
+// assign check value assigned to typed variable or field
+func (c *checker) assign(exp ast.AssignStatement) ast.AssignStatement {
+	switch left := exp.Left.(type) {
+	case ast.GetVarStatement:
+		t, _ := c.scope.lookup(left.Label)
+		exp.Exp = c.convert(exp.Exp, t, exp.Position, "assignment")
+		c.scope.forget(left.Label)
+	case ast.PeriodStatement:
+		var receiver Type
+		left.Exp, receiver = c.expr(left.Exp)
+		if name, ok := left.Exp.(ast.GetVarStatement); ok && receiver == Any {
+			receiver = c.scope.object(name.Label)
+		}
+		exp.Left = left
+		exp.Exp = c.convert(exp.Exp, c.fieldType(receiver, left.Val, ast.PositionOf(left.Exp)), exp.Position, "assignment")
+	default:
+		exp.Left, _ = c.expr(exp.Left)
+		exp.Exp, _ = c.expr(exp.Exp)
+	}
+	return exp
+}
+
+// target return type of left of assignment
+func (c *checker) target(left runtime.Invokable) Type {
+	switch left := left.(type) {
+	case ast.GetVarStatement:
+		t, _ := c.scope.lookup(left.Label)
+		c.scope.forget(left.Label)
+		return t
+	case ast.PeriodStatement:
+		var receiver Type
+		left.Exp, receiver = c.expr(left.Exp)
+		return c.fieldType(receiver, left.Val, ast.PositionOf(left.Exp))
+	}
+	c.expr(left)
+	return Any
+}
+
+func (c *checker) multiAssign(exp ast.MultiAssignStatement) ast.MultiAssignStatement {
+	targets := make([]Type, len(exp.Lefts))
+	for index := range targets {
+		targets[index] = Any
+	}
+	switch value := exp.Exp.(type) {
+	case ast.TupleExpression:
+		if exp.Define == false {
+			for index, left := range exp.Lefts {
+				targets[index] = c.target(left)
+			}
+		}
+		for index := range value.Exps {
+			value.Exps[index] = c.convert(value.Exps[index], targets[index], exp.Position, "assignment")
+		}
+	case *ast.CallStatement:
+		results := c.call(value)
+		if exp.Define || len(results) != len(exp.Lefts) {
+			break
+		}
+		for index, left := range exp.Lefts {
+			if t := c.target(left); c.assignableTo(results[index], t) == false {
+				c.errorf(exp.Position, "cannot use %s value as %s in assignment", results[index], t)
+			}
+		}
+	default:
+		exp.Exp, _ = c.expr(exp.Exp)
+	}
+	if exp.Define {
+		for _, left := range exp.Lefts {
+			if left, ok := left.(ast.GetVarStatement); ok {
+				c.declare(left.Label, Any)
+			}
+		}
+	}
+	return exp
+}
+
+func (c *checker) returnStatement(exp ast.ReturnStatement) ast.ReturnStatement {
+	if c.returns == nil {
+		exp.Exp, _ = c.expr(exp.Exp)
+		return exp
+	}
+	count := func(have int) {
+		problem := "not enough"
+		if have > len(c.returns) {
+			problem = "too many"
+		}
+		c.errorf(exp.Position, "%s return values: have %d, want %d", problem, have, len(c.returns))
+	}
+	if exp.Val != nil {
+		// return without value
+		count(0)
+		return exp
+	}
+	switch value := exp.Exp.(type) {
+	case ast.TupleExpression:
+		if len(value.Exps) != len(c.returns) {
+			c.values(value.Exps)
+			count(len(value.Exps))
+			break
+		}
+		for index := range value.Exps {
+			value.Exps[index] = c.convert(value.Exps[index], c.returns[index], exp.Position, "return statement")
+		}
+	case *ast.CallStatement:
+		if len(c.returns) == 1 {
+			exp.Exp = c.convert(value, c.returns[0], exp.Position, "return statement")
+			break
+		}
+		results := c.call(value)
+		if results == nil {
+			break
+		}
+		if len(results) != len(c.returns) {
+			count(len(results))
+			break
+		}
+		for index, result := range results {
+			if c.assignableTo(result, c.returns[index]) == false {
+				c.errorf(exp.Position, "cannot use %s value as %s in return statement", result, c.returns[index])
+			}
+		}
+	default:
+		if len(c.returns) != 1 {
+			c.expr(value)
+			count(1)
+			break
+		}
+		exp.Exp = c.convert(value, c.returns[0], exp.Position, "return statement")
+	}
+	return exp
+}
+
+func (c *checker) ifStatement(exp ast.IfExpression) ast.IfExpression {
+	exp.Check = c.condition(exp.Check, exp.Position, "if")
+	c.scoped(exp.Statements)
+	for index := range exp.ElseIf {
+		exp.ElseIf[index] = c.ifStatement(exp.ElseIf[index])
+	}
+	c.scoped(exp.Else)
+	return exp
+}
+
+func (c *checker) loop(exp ast.ForExpression) ast.ForExpression {
+	if exp.Range != nil {
+		exp.Range, _ = c.expr(exp.Range)
+	}
+	c.openScope(false)
+	if exp.Range != nil {
+		c.declare(exp.Key, Any)
+		c.declare(exp.Value, Any)
+	} else {
+		exp.Pre, _ = c.expr(exp.Pre)
+		exp.Check = c.condition(exp.Check, exp.Position, "for")
+		exp.Post, _ = c.expr(exp.Post)
+	}
+	c.scoped(exp.Statements)
+	c.closeScope()
+	return exp
+}
@@ -0,0 +1,291 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/parser"
+)
+
+func check(t *testing.T, src string) (ast.Expressions, []error) {
+	p := parser.New(src).SetFile("main.qp")
+	statements, errs := p.ParseWithErrors()
+	if len(errs) != 0 {
+		t.Fatalf("parse failed %s", errs[0].Error())
+	}
+	return statements, Check(p, statements)
+}
+
+func TestCheck(t *testing.T) {
+	src := `type User {
+	name string
+	age int
+	tags: nil
+}
+func add(a int, b int) int {
+	return a + b
+}
+func pair() (int, string) {
+	return 1
+}
+func User.rename(name string) {
+	this.name = name
+}
+var n int = "one"
+add(1, "two")
+var u User = User{name: 3}
+u.rename(4)
+u.age = "old"
+if n {
+}
+s := "a" - "b"
+x := 1 + "c"
+var v Unknown
+var ok bool = nil
+var any = untyped(1) + "d"
+var text string
+text++
+var m map = nil
+a, b := pair()
+`
+	_, errs := check(t, src)
+	var lines []string
+	for _, err := range errs {
+		e := err.(*parser.Error)
+		lines = append(lines, e.Position.String()+": "+e.Msg)
+	}
+	expect := []string{
+		"main.qp:10:2: not enough return values: have 1, want 2",
+		"main.qp:15:5: cannot use \"one\" (type string) as int in variable declaration",
+		"main.qp:16:8: cannot use \"two\" (type string) as int in argument to add",
+		"main.qp:17:14: cannot use 3 (type int) as string in field value of `name`",
+		"main.qp:18:10: cannot use 4 (type int) as string in argument to rename",
+		"main.qp:19:7: cannot use \"old\" (type string) as int in assignment",
+		"main.qp:20:4: non-bool n (type int) used as if condition",
+		"main.qp:22:1: invalid operation: operator - not defined on \"a\" (type string)",
+		"main.qp:23:1: invalid operation: 1 + \"c\" (mismatched types int and string)",
+		"main.qp:24:5: undefined type `Unknown`",
+		"main.qp:25:5: cannot use nil (type nil) as bool in variable declaration",
+		"main.qp:28:1: invalid operation: text++ (non-numeric type string)",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected errors\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestCheckRewrite(t *testing.T) {
+	statements, errs := check(t, `var f float = 1
+func scale(x float) float {
+	return x * 2
+}
+var n int = 3
+println(scale(2), n * 2, n * f)
+`)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	if _, ok := statements[0].(ast.VarAssignStatement).Exp.(ast.Float); ok == false {
+		t.Fatalf("int literal no converted to float %s", statements[0].String())
+	}
+	arguments := statements[2].(*ast.CallStatement).Arguments
+	if _, ok := arguments[0].(*ast.CallStatement).Arguments[0].(ast.Float); ok == false {
+		t.Fatalf("argument no converted to float %s", arguments[0].String())
+	}
+	if arguments[1].(ast.BinaryOpExpression).Int == false {
+		t.Fatalf("int operation no marked %s", arguments[1].String())
+	}
+	if arguments[2].(ast.BinaryOpExpression).Int {
+		t.Fatalf("float operation marked int %s", arguments[2].String())
+	}
+}
+
+func TestCheckStrict(t *testing.T) {
+	_, errs := check(t, `type User strict {
+	name string
+	age int = "old"
+}
+func User.rename(name string) {
+	this.nmae = name
+	this.save()
+}
+var u User = User{name: "bob", agee: 1}
+println(u.age, u.rename, u.title)
+inferred := User{}
+inferred.anything = 1
+loose := u
+loose.anything = 1
+`)
+	var lines []string
+	for _, err := range errs {
+		e := err.(*parser.Error)
+		lines = append(lines, e.Position.String()+": "+e.Msg)
+	}
+	expect := []string{
+		"main.qp:1:6: cannot use \"old\" (type string) as int in default value of `age`",
+		"main.qp:6:2: type User has no field or method `nmae`",
+		"main.qp:7:2: type User has no field or method `save`",
+		"main.qp:9:14: type User has no field or method `agee`",
+		"main.qp:10:26: type User has no field or method `title`",
+		"main.qp:12:1: type User has no field or method `anything`",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected errors\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestCheckInferred(t *testing.T) {
+	_, errs := check(t, `type P strict {
+	x int
+}
+func P.init(x int) {
+	this.x = x
+}
+var p = P{x: 1}
+p.y = 2
+q := P(1)
+q.z = 3
+println(q.z)
+p = 3
+p.y = 4
+type U {
+	a int = 1
+}
+var u = U{}
+u.b = 1
+u = 3
+`)
+	var lines []string
+	for _, err := range errs {
+		e := err.(*parser.Error)
+		lines = append(lines, e.Position.String()+": "+e.Msg)
+	}
+	expect := []string{
+		"main.qp:8:1: type P has no field or method `y`",
+		"main.qp:10:1: type P has no field or method `z`",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected errors\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestCheckInterface(t *testing.T) {
+	_, errs := check(t, `interface Printer {
+	print(prefix string) string
+}
+interface Sized {
+	size() int
+	Printer
+}
+type Doc {
+	title string
+}
+func Doc.print(prefix string) string {
+	return prefix + this.title
+}
+type Box {}
+func Box.print(prefix int) string {
+	return ""
+}
+type Page {
+	Doc
+}
+func show(p Printer) {
+	println(p.print("> "), p.size())
+}
+show(Doc{title: "a"})
+show(Page{})
+show(Box{})
+show(1)
+var s Sized = Doc{}
+p := Printer{}
+var ok bool = p is Sized
+`)
+	var lines []string
+	for _, err := range errs {
+		e := err.(*parser.Error)
+		lines = append(lines, e.Position.String()+": "+e.Msg)
+	}
+	expect := []string{
+		"main.qp:22:25: type Printer has no field or method `size`",
+		"main.qp:26:6: cannot use Box{} (type Box) as Printer in argument to show: Box does not implement Printer (wrong type for method print)",
+		"main.qp:27:6: cannot use 1 (type int) as Printer in argument to show: int does not implement Printer (missing method print)",
+		"main.qp:28:5: cannot use Doc{} (type Doc) as Sized in variable declaration: Doc does not implement Sized (missing method size)",
+		"main.qp:29:6: cannot create value of interface type Printer",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected errors\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestCheckConstructor(t *testing.T) {
+	_, errs := check(t, `type Counter {
+	n int
+}
+func Counter.init(start int) {
+	this.n = start
+}
+type Box {}
+var c = Counter("one")
+var b = Box(1)
+var ok = Box()
+var few = Counter()
+var many = Counter(1, 2)
+`)
+	var lines []string
+	for _, err := range errs {
+		e := err.(*parser.Error)
+		lines = append(lines, e.Position.String()+": "+e.Msg)
+	}
+	expect := []string{
+		"main.qp:8:17: cannot use \"one\" (type string) as int in argument to Counter.init",
+		"main.qp:9:9: type Box has no method `init`",
+		"main.qp:11:11: not enough arguments in call to Counter.init",
+		"main.qp:12:23: too many arguments in call to Counter.init",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected errors\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestCheckArgument(t *testing.T) {
+	_, errs := check(t, `func add(a int, b int) int {
+	return a + b
+}
+add("x", "y")
+`)
+	var lines []string
+	for _, err := range errs {
+		e := err.(*parser.Error)
+		lines = append(lines, e.Position.String()+": "+e.Msg)
+	}
+	expect := []string{
+		"main.qp:4:5: cannot use \"x\" (type string) as int in argument to add",
+		"main.qp:4:10: cannot use \"y\" (type string) as int in argument to add",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected errors\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestCheckIndex(t *testing.T) {
+	_, errs := check(t, `var a array = [1, 2, 3]
+var n int = 1
+println(a[n], a["one"], a[n:], a[:"two"])
+var s string = "abc"
+println(s[0:1], {}["k"])
+`)
+	var lines []string
+	for _, err := range errs {
+		e := err.(*parser.Error)
+		lines = append(lines, e.Position.String()+": "+e.Msg)
+	}
+	expect := []string{
+		"main.qp:3:16: invalid argument: index \"one\" (type string) must be integer",
+		"main.qp:3:33: invalid argument: index \"two\" (type string) must be integer",
+		"main.qp:5:10: cannot slice s (type string)",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected errors\n%s", strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"fmt"
+
+	"gitlab.com/akzj/qp/ast"
+)
+
+// assignable check value of type from can be assigned to type to,reason
+// is why type from does not implement interface to.Value of interface is
+// assignable to every type like Any,it is checked at runtime by `is`
+func (c *checker) assignable(from, to Type) (ok bool, reason string) {
+	if assignable(from, to) || c.interfaceObject(from) != nil {
+		return true, ""
+	}
+	object := c.interfaceObject(to)
+	if object == nil || from == Nil {
+		return false, ""
+	}
+	if reason := c.implements(from, object); reason != "" {
+		return false, fmt.Sprintf("%s does not implement %s (%s)", from, to, reason)
+	}
+	return true, ""
+}
+
+func (c *checker) assignableTo(from, to Type) bool {
+	ok, _ := c.assignable(from, to)
+	return ok
+}
+
+// implements return why type t does not implement object,"" if it does.
+// Methods are matched by name and number of parameters,types of
+// parameters and results are matched when both are annotated
+func (c *checker) implements(t Type, object *ast.InterfaceObject) string {
+	typeObject := c.typeObject(t)
+	for _, name := range object.MethodNames() {
+		if typeObject == nil {
+			return "missing method " + name
+		}
+		member := typeObject.GetObject(name)
+		if member == nil {
+			if funcField(typeObject, name) {
+				continue
+			}
+			return "missing method " + name
+		}
+		method, ok := member.Pointer.(*ast.FuncExpression)
+		if ok && c.sameSignature(method, object.Method(name)) == false {
+			return "wrong type for method " + name
+		}
+	}
+	return ""
+}
+
+// sameSignature check method of type matches method of interface
+func (c *checker) sameSignature(method, declared *ast.FuncExpression) bool {
+	parameters, expects := c.parameters(method), c.parameters(declared)
+	if len(parameters) != len(expects) {
+		return false
+	}
+	for index := range parameters {
+		if parameters[index] != Any && expects[index] != Any && parameters[index] != expects[index] {
+			return false
+		}
+	}
+	results, expects := c.results(method), c.results(declared)
+	if len(results) == 0 || len(expects) == 0 {
+		return true
+	}
+	if len(results) != len(expects) {
+		return false
+	}
+	for index := range results {
+		if results[index] != expects[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// funcField check type has field of name may be func
+func funcField(object *ast.TypeObject, name string) bool {
+	for _, template := range object.TypeObjectPropTemplates {
+		if template.Name == name {
+			return template.Type == "" || template.Type == string(Func)
+		}
+	}
+	return false
+}
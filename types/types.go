@@ -0,0 +1,100 @@
+// Package types check the optional type annotations of qp source.
+//
+//	func add(a int, b int) int {
+//		return a + b
+//	}
+//	var total int = add(1, 2)
+//
+// Values of untyped code have type Any,they are assignable to and from
+// every type,so only mismatches of known types are reported
+package types
+
+import "gitlab.com/akzj/qp/lexer"
+
+// Type is static type of value,it is the name of the type in annotation:
+// int,float,string,bool,array,map,func,any,or name of user type
+type Type string
+
+const (
+	Any    Type = "any"
+	Int    Type = "int"
+	Float  Type = "float"
+	String Type = "string"
+	Bool   Type = "bool"
+	Array  Type = "array"
+	Map    Type = "map"
+	Func   Type = "func"
+	Nil    Type = "nil" // type of nil,no annotation
+)
+
+// basics is the types built in
+var basics = map[Type]bool{Any: true, Int: true, Float: true, String: true, Bool: true, Array: true, Map: true, Func: true}
+
+// annotation return Type of annotation,"" is Any
+func annotation(name string) Type {
+	if name == "" {
+		return Any
+	}
+	return Type(name)
+}
+
+func (t Type) numeric() bool {
+	return t == Int || t == Float
+}
+
+// nullable check nil is assignable to t
+func (t Type) nullable() bool {
+	switch t {
+	case Int, Float, String, Bool:
+		return false
+	}
+	return true
+}
+
+// assignable check value of type from can be assigned to type to
+func assignable(from, to Type) bool {
+	switch {
+	case from == Any || to == Any || from == to:
+		return true
+	case from == Nil:
+		return to.nullable()
+	}
+	return false
+}
+
+// builtinResults is the result type of built in functions
+var builtinResults = map[string]Type{
+	"len":   Int,
+	"int":   Int,
+	"float": Float,
+	"keys":  Array,
+}
+
+// binary return the type of `left op right`,ok is false when the operator
+// is no defined on the operand types
+func binary(op lexer.Type, left, right Type) (result Type, ok bool) {
+	switch op {
+	case lexer.AndType, lexer.OrType:
+		return Bool, (left == Any || left == Bool) && (right == Any || right == Bool)
+	case lexer.EqualType, lexer.NoEqualType:
+		return Bool, assignable(left, right) || assignable(right, left) || left.numeric() && right.numeric()
+	case lexer.LessType, lexer.LessEqualType, lexer.GreaterType, lexer.GreaterEqualType:
+		if left == Any || right == Any {
+			return Bool, true
+		}
+		return Bool, left.numeric() && right.numeric() || left == String && right == String
+	}
+	// arithmetic
+	if left == Any || right == Any {
+		return Any, true
+	}
+	switch {
+	case left == Int && right == Int:
+		return Int, true
+	case left.numeric() && right.numeric():
+		return Float, true
+	case left == String && right == String:
+		return String, op == lexer.AddType
+	}
+	return Any, false
+}
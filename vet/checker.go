@@ -0,0 +1,412 @@
+package vet
+
+import (
+	"strings"
+
+	"gitlab.com/akzj/qp/ast"
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/parser"
+	"gitlab.com/akzj/qp/runtime"
+)
+
+type variable struct {
+	name  string
+	pos   lexer.Position
+	used  bool
+	check bool // report when no used,false for parameters
+}
+
+// scope is variables of block,function body or lambda
+type scope struct {
+	parent   *scope
+	isolated bool // body of named function,variables of outer scopes are invisible
+	vars     map[string]*variable
+	order    []*variable // all variables declared,redeclared ones included
+}
+
+func (s *scope) lookup(name string) *variable {
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+		if s.isolated {
+			return nil
+		}
+	}
+	return nil
+}
+
+type checker struct {
+	file        string
+	vm          *runtime.VMRuntime
+	definitions []parser.Definition
+	methods     map[string]int // arity of methods by name
+	scope       *scope
+	function    string          // function checked,references of itself no count
+	used        map[string]bool // functions referenced
+	diagnostics []Diagnostic
+}
+
+func newChecker(file string, p *parser.Parser) *checker {
+	return &checker{
+		file:        file,
+		vm:          p.GetVMContext(),
+		definitions: p.Definitions(),
+		methods:     methodArity(p.Definitions()),
+		used:        map[string]bool{},
+	}
+}
+
+// check statements of script and bodies of functions,methods declared in
+// file
+func (c *checker) check(statements ast.Expressions) {
+	c.openScope(true)
+	c.block(statements)
+	c.closeScope()
+	for _, definition := range c.definitions {
+		if definition.Position.File != c.file {
+			continue
+		}
+		switch definition.Kind {
+		case parser.FunctionDefinition:
+			if object, ok := c.vm.GlobalFunctions[definition.Name]; ok {
+				c.function = definition.Name
+				c.body(object.Pointer.(*ast.FuncExpression), true)
+			}
+		case parser.MethodDefinition:
+			if function := c.method(definition.Name); function != nil {
+				c.function = definition.Name
+				c.body(function, true)
+			}
+		}
+	}
+	c.function = ""
+	if isModule(statements) {
+		// functions of module are used by scripts importing it
+		return
+	}
+	for _, definition := range c.definitions {
+		if definition.Kind == parser.FunctionDefinition && definition.Position.File == c.file &&
+			c.used[definition.Name] == false {
+			c.report(definition.Position, UnusedCheck, "function `%s` is unused", definition.Name)
+		}
+	}
+}
+
+// isModule check script has no statements but imports,declarations
+func isModule(statements ast.Expressions) bool {
+	for _, statement := range statements {
+		switch statement.(type) {
+		case ast.ImportStatement, ast.NopStatement:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// method return function of method `Type.method`
+func (c *checker) method(name string) *ast.FuncExpression {
+	index := strings.Index(name, ".")
+	object := c.vm.GetTypeObject(name[:index])
+	if object == nil {
+		return nil
+	}
+	typeObject, ok := object.Pointer.(*ast.TypeObject)
+	if ok == false {
+		return nil
+	}
+	method := typeObject.GetObject(name[index+1:])
+	if method == nil {
+		return nil
+	}
+	function, _ := method.Pointer.(*ast.FuncExpression)
+	return function
+}
+
+func (c *checker) openScope(isolated bool) {
+	c.scope = &scope{parent: c.scope, isolated: isolated, vars: map[string]*variable{}}
+}
+
+// closeScope leave scope,variables no used are reported
+func (c *checker) closeScope() {
+	for _, v := range c.scope.order {
+		if v.check && v.used == false {
+			c.report(v.pos, UnusedCheck, "`%s` declared and not used", v.name)
+		}
+	}
+	c.scope = c.scope.parent
+}
+
+// declare add variable to current scope,variable hiding the one of outer
+// scope is reported
+func (c *checker) declare(name string, pos lexer.Position, check bool) {
+	if name == "_" {
+		return
+	}
+	if _, ok := c.scope.vars[name]; ok == false && c.scope.isolated == false {
+		if outer := c.scope.parent.lookup(name); outer != nil {
+			c.report(pos, ShadowCheck, "declaration of `%s` shadows declaration at line %d", name, outer.pos.Line)
+		}
+	}
+	v := &variable{name: name, pos: pos, check: check}
+	c.scope.vars[name] = v
+	c.scope.order = append(c.scope.order, v)
+}
+
+// resolve find name in scopes,functions,types,use is false for name
+// assigned
+func (c *checker) resolve(name string, pos lexer.Position, use bool) {
+	if v := c.scope.lookup(name); v != nil {
+		if use {
+			v.used = true
+		}
+		return
+	}
+	if _, ok := c.vm.GlobalFunctions[name]; ok {
+		if name != c.function {
+			c.used[name] = true
+		}
+		return
+	}
+	if _, ok := c.vm.Functions[name]; ok || c.vm.GetTypeObject(name) != nil {
+		return
+	}
+	c.report(pos, UndefinedCheck, "undefined: %s", name)
+}
+
+// body check function body,named function can not see variables out of it
+func (c *checker) body(function *ast.FuncExpression, named bool) {
+	c.openScope(named)
+	for _, parameter := range function.Parameters {
+		c.declare(parameter, function.Position, false)
+	}
+	c.block(function.Statements)
+	c.closeScope()
+}
+
+// block check statements,statements after return,break,continue or panic
+// are reported
+func (c *checker) block(statements ast.Expressions) {
+	var terminated, reported bool
+	for _, statement := range statements {
+		if terminated && reported == false {
+			if pos := ast.PositionOf(statement); pos.Line != 0 {
+				c.report(pos, UnreachableCheck, "unreachable code")
+				reported = true
+			}
+		}
+		c.walk(statement)
+		if c.terminates(statement) {
+			terminated = true
+		}
+	}
+}
+
+// scoped check statements in new block scope
+func (c *checker) scoped(statements ast.Expressions) {
+	c.openScope(false)
+	c.block(statements)
+	c.closeScope()
+}
+
+// terminates check statement never go on to the next statement
+func (c *checker) terminates(statement runtime.Invokable) bool {
+	switch statement := statement.(type) {
+	case ast.ReturnStatement, *ast.BreakObject, *ast.ContinueObject:
+		return true
+	case *ast.CallStatement:
+		name, ok := statement.Function.(ast.GetVarStatement)
+		return ok && name.Label == "panic" && c.scope.lookup("panic") == nil
+	case ast.IfExpression:
+		if len(statement.Else) == 0 || c.blockTerminates(statement.Statements) == false ||
+			c.blockTerminates(statement.Else) == false {
+			return false
+		}
+		for _, elseIf := range statement.ElseIf {
+			if c.blockTerminates(elseIf.Statements) == false {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func (c *checker) blockTerminates(statements ast.Expressions) bool {
+	for _, statement := range statements {
+		if c.terminates(statement) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *checker) walk(exp runtime.Invokable) {
+	switch exp := exp.(type) {
+	case ast.GetVarStatement:
+		c.resolve(exp.Label, exp.Position, true)
+	case ast.ParenthesisExpression:
+		c.walk(exp.Exp)
+	case ast.BinaryOpExpression:
+		c.walk(exp.Left)
+		c.walk(exp.Right)
+	case ast.NoStatement:
+		c.walk(exp.Exp)
+	case ast.IsExpression:
+		c.walk(exp.Exp)
+	case ast.PeriodStatement:
+		c.walk(exp.Exp)
+	case ast.IndexExpression:
+		c.walk(exp.Exp)
+		c.walk(exp.Index)
+	case ast.SliceExpression:
+		c.walk(exp.Exp)
+		c.walk(exp.Low)
+		c.walk(exp.High)
+	case *ast.MakeArrayStatement:
+		c.walkAll(exp.Inits)
+	case *ast.MakeMapStatement:
+		c.walkAll(exp.Keys)
+		c.walkAll(exp.Values)
+	case ast.ObjectInitStatement:
+		c.walk(exp.Exp)
+		for _, template := range exp.PropTemplates {
+			c.walk(template.Exp)
+		}
+		// arity of init is checked by the type checker
+		c.walkAll(exp.Arguments)
+	case ast.TupleExpression:
+		c.walkAll(exp.Exps)
+	case *ast.CallStatement:
+		c.call(exp)
+	case *ast.FuncExpression:
+		c.body(exp, false)
+	case ast.IncFieldStatement:
+		c.walk(exp.Exp)
+	case ast.AssignStatement:
+		c.walk(exp.Exp)
+		c.assign(exp.Left)
+	case ast.MultiAssignStatement:
+		c.walk(exp.Exp)
+		for _, left := range exp.Lefts {
+			if name, ok := left.(ast.GetVarStatement); ok && exp.Define {
+				c.declare(name.Label, name.Position, true)
+			} else {
+				c.assign(left)
+			}
+		}
+	case ast.VarStatement:
+		c.declare(exp.Label, exp.Position, true)
+	case ast.VarAssignStatement:
+		c.walk(exp.Exp)
+		c.declare(exp.Name, exp.Position, true)
+	case ast.VarInitExpression:
+		c.walk(exp.Exp)
+		c.declare(exp.Name, exp.Position, true)
+	case ast.ReturnStatement:
+		c.walk(exp.Exp)
+	case ast.IfExpression:
+		c.walk(exp.Check)
+		c.scoped(exp.Statements)
+		for _, elseIf := range exp.ElseIf {
+			c.walk(elseIf.Check)
+			c.scoped(elseIf.Statements)
+		}
+		c.scoped(exp.Else)
+	case ast.ForExpression:
+		c.loop(exp)
+	case *ast.ForExpression:
+		c.loop(*exp)
+	case ast.TryStatement:
+		c.scoped(exp.Statements)
+		c.openScope(false)
+		c.declare(exp.Label, exp.Position, false)
+		c.block(exp.Catch)
+		c.closeScope()
+	}
+}
+
+func (c *checker) walkAll(exps ast.Expressions) {
+	for _, exp := range exps {
+		c.walk(exp)
+	}
+}
+
+// assign check left of assignment,variable assigned is no used
+func (c *checker) assign(left runtime.Invokable) {
+	if name, ok := left.(ast.GetVarStatement); ok {
+		if name.Label != "_" {
+			c.resolve(name.Label, name.Position, false)
+		}
+		return
+	}
+	c.walk(left)
+}
+
+func (c *checker) loop(loop ast.ForExpression) {
+	if loop.Range != nil {
+		c.walk(loop.Range)
+	}
+	c.openScope(false)
+	if loop.Range != nil {
+		c.declare(loop.Key, loop.Position, true)
+		c.declare(loop.Value, loop.Position, true)
+	} else {
+		c.walk(loop.Pre)
+		c.walk(loop.Check)
+		c.walk(loop.Post)
+	}
+	c.scoped(loop.Statements)
+	c.closeScope()
+}
+
+// call check arguments of call match parameters of function called
+func (c *checker) call(call *ast.CallStatement) {
+	c.walk(call.Function)
+	c.walkAll(call.Arguments)
+	name, parameters := c.arity(call.Function)
+	c.checkArity(ast.PositionOf(call), name, len(call.Arguments), parameters)
+}
+
+// checkArity report count of arguments no match parameters,parameters is
+// negative when unknown
+func (c *checker) checkArity(pos lexer.Position, name string, arguments, parameters int) {
+	if parameters < 0 || parameters == arguments {
+		return
+	}
+	problem := "not enough"
+	if arguments > parameters {
+		problem = "too many"
+	}
+	c.report(pos, ArityCheck, "%s arguments in call to `%s`: have %d, want %d",
+		problem, name, arguments, parameters)
+}
+
+// arity return name and the number of parameters of function,negative
+// when unknown or variadic
+func (c *checker) arity(function runtime.Invokable) (string, int) {
+	switch function := function.(type) {
+	case ast.GetVarStatement:
+		if c.scope.lookup(function.Label) != nil {
+			return function.Label, unknownArity
+		}
+		if object, ok := c.vm.GlobalFunctions[function.Label]; ok {
+			return function.Label, len(object.Pointer.(*ast.FuncExpression).Parameters)
+		}
+		if count, ok := builtin(function.Label); ok {
+			return function.Label, count
+		}
+	case ast.ModuleMember:
+		if object, ok := function.Module.VM.GlobalFunctions[function.Label]; ok {
+			return function.String(), len(object.Pointer.(*ast.FuncExpression).Parameters)
+		}
+	case ast.PeriodStatement:
+		if count, ok := c.methods[function.Val]; ok {
+			return function.Val, count
+		}
+	case *ast.FuncExpression:
+		return "func", len(function.Parameters)
+	}
+	return "", unknownArity
+}
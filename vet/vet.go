@@ -0,0 +1,158 @@
+// Package vet report suspicious code of qp source: undefined names,calls
+// with wrong number of arguments,unreachable code,shadowed and unused
+// variables,unused functions,mismatches of type annotations
+package vet
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gitlab.com/akzj/qp/lexer"
+	"gitlab.com/akzj/qp/parser"
+	"gitlab.com/akzj/qp/runtime"
+	"gitlab.com/akzj/qp/types"
+)
+
+// checks of Diagnostic
+const (
+	SyntaxCheck      = "syntax"
+	UndefinedCheck   = "undefined"
+	ArityCheck       = "arity"
+	UnreachableCheck = "unreachable"
+	ShadowCheck      = "shadow"
+	UnusedCheck      = "unused"
+	TypeCheck        = "type"
+)
+
+// Diagnostic is problem found in source,Check is the name of check found it
+type Diagnostic struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Check   string `json:"check"`
+	Message string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	return lexer.Position{File: d.File, Line: d.Line, Column: d.Column}.String() + ": " + d.Message
+}
+
+// Source check src of file,source with syntax errors is no checked and the
+// syntax errors are returned
+func Source(file string, src []byte) []Diagnostic {
+	p := parser.New(string(src)).SetFile(file).SetImporter(parser.NewImporter(parser.DefaultPath()))
+	statements, errs := p.ParseWithErrors()
+	if len(errs) != 0 {
+		var diagnostics []Diagnostic
+		for _, err := range errs {
+			diagnostics = append(diagnostics, errorDiagnostic(file, SyntaxCheck, err))
+		}
+		return diagnostics
+	}
+	c := newChecker(file, p)
+	c.check(statements)
+	for _, err := range types.Check(p, statements) {
+		c.diagnostics = append(c.diagnostics, errorDiagnostic(file, TypeCheck, err))
+	}
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		left, right := c.diagnostics[i], c.diagnostics[j]
+		if left.Line != right.Line {
+			return left.Line < right.Line
+		}
+		return left.Column < right.Column
+	})
+	return c.diagnostics
+}
+
+// errorDiagnostic convert error of parser or type checker to Diagnostic
+func errorDiagnostic(file string, check string, err error) Diagnostic {
+	diagnostic := Diagnostic{File: file, Check: check, Message: err.Error()}
+	var pos lexer.Position
+	switch err := err.(type) {
+	case *parser.Error:
+		pos, diagnostic.Message = err.Position, err.Msg
+	case *lexer.Error:
+		pos, diagnostic.Message = err.Position, err.Msg
+	}
+	if pos.File != "" {
+		diagnostic.File = pos.File
+	}
+	diagnostic.Line, diagnostic.Column = pos.Line, pos.Column
+	return diagnostic
+}
+
+// builtinArity is the number of arguments of built in functions taking
+// fixed arguments,functions of runtime.Functions absent here are variadic
+var builtinArity = map[string]int{
+	"now":    0,
+	"len":    1,
+	"keys":   1,
+	"delete": 2,
+	"int":    1,
+	"float":  1,
+}
+
+// builtin return the number of arguments of built in function name,-1 for
+// variadic,ok is false when name is not registered in runtime.Functions
+func builtin(name string) (count int, ok bool) {
+	if _, ok := runtime.Functions[name]; ok == false {
+		return 0, false
+	}
+	if count, ok := builtinArity[name]; ok {
+		return count, true
+	}
+	return -1, true
+}
+
+// report add diagnostic at pos
+func (c *checker) report(pos lexer.Position, check string, format string, args ...interface{}) {
+	if pos.File == "" {
+		pos.File = c.file
+	}
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		File:    pos.File,
+		Line:    pos.Line,
+		Column:  pos.Column,
+		Check:   check,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// unknownArity is arity of function called that can not be resolved
+const unknownArity = -2
+
+// methodArity return the number of parameters of methods named name,
+// unknownArity when methods of different types differ or name may be field
+// or function of array and string
+func methodArity(definitions []parser.Definition) map[string]int {
+	arity := map[string]int{}
+	fields := map[string]bool{}
+	for name := range runtime.ArrayFunctions {
+		fields[name] = true
+	}
+	for name := range runtime.StringFunctions {
+		fields[name] = true
+	}
+	for _, definition := range definitions {
+		switch definition.Kind {
+		case parser.TypeDefinition:
+			for _, field := range definition.Fields {
+				fields[field] = true
+			}
+		case parser.MethodDefinition:
+			name := definition.Name[strings.Index(definition.Name, ".")+1:]
+			if count, ok := arity[name]; ok && count != len(definition.Parameters) {
+				arity[name] = unknownArity
+			} else {
+				arity[name] = len(definition.Parameters)
+			}
+		}
+	}
+	for name := range fields {
+		if _, ok := arity[name]; ok {
+			arity[name] = unknownArity
+		}
+	}
+	return arity
+}
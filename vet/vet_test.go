@@ -0,0 +1,103 @@
+package vet
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/akzj/qp/runtime"
+)
+
+func TestSource(t *testing.T) {
+	src := `func add(a, b){
+	return a + b
+	println(a)
+}
+func unused(){
+	unused()
+}
+func useGlobal(){
+	return g
+}
+type List{
+	size: 0
+}
+func List.insert(value){
+	this.size++
+}
+var g = add(1)
+var list = List{}
+list.insert(1, 2)
+x := 1
+if g > 0 {
+	x := 2
+	println(x)
+}
+for i := 0; i < 3; i++ {
+	y := i
+}
+println(undefinedVar, len(g, g))
+f := func(n){
+	return n + x
+}
+f(1)
+useGlobal()
+`
+	var lines []string
+	for _, diagnostic := range Source("main.qp", []byte(src)) {
+		lines = append(lines, diagnostic.String()+" ("+diagnostic.Check+")")
+	}
+	expect := []string{
+		"main.qp:3:2: unreachable code (unreachable)",
+		"main.qp:5:6: function `unused` is unused (unused)",
+		"main.qp:9:9: undefined: g (undefined)",
+		"main.qp:17:9: not enough arguments in call to `add`: have 1, want 2 (arity)",
+		"main.qp:19:1: too many arguments in call to `insert`: have 2, want 1 (arity)",
+		"main.qp:22:2: declaration of `x` shadows declaration at line 20 (shadow)",
+		"main.qp:26:2: `y` declared and not used (unused)",
+		"main.qp:28:9: undefined: undefinedVar (undefined)",
+		"main.qp:28:23: too many arguments in call to `len`: have 2, want 1 (arity)",
+	}
+	if strings.Join(lines, "\n") != strings.Join(expect, "\n") {
+		t.Fatalf("unexpected diagnostics\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestSourceSyntaxError(t *testing.T) {
+	diagnostics := Source("bad.qp", []byte("var a = 1\nfunc f(){\n\treturn )\n}\n"))
+	if len(diagnostics) == 0 || diagnostics[0].Check != SyntaxCheck || diagnostics[0].Line != 3 {
+		t.Fatalf("unexpected diagnostics %+v", diagnostics)
+	}
+}
+
+func TestSourceConstructor(t *testing.T) {
+	src := `type List{}
+func List.init(size){
+	this.size = size
+}
+var a = List(1)
+var b = List(1, 2)
+println(a, b)
+`
+	var lines []string
+	for _, diagnostic := range Source("main.qp", []byte(src)) {
+		lines = append(lines, diagnostic.String()+" ("+diagnostic.Check+")")
+	}
+	expect := "main.qp:6:17: too many arguments in call to List.init (type)"
+	if strings.Join(lines, "\n") != expect {
+		t.Fatalf("unexpected diagnostics\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestBuiltinArity(t *testing.T) {
+	for name := range builtinArity {
+		if _, ok := runtime.Functions[name]; ok == false {
+			t.Errorf("built in function `%s` is not registered", name)
+		}
+	}
+	if count, ok := builtin("println"); ok == false || count != -1 {
+		t.Fatalf("expect println variadic,got %d %v", count, ok)
+	}
+	if _, ok := builtin("print"); ok {
+		t.Fatal("expect print not built in")
+	}
+}